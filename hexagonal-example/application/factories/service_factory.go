@@ -2,17 +2,36 @@ package factories
 
 import (
 	"hexagonal-example/application/services"
+	"hexagonal-example/domain/entities"
 	"hexagonal-example/domain/repositories"
 	"hexagonal-example/infrastructure/events"
 )
 
+// conflictRetries es el número de reintentos que los procesadores aplican
+// cuando una modificación choca con otra concurrente sobre la misma entidad
+const conflictRetries = 3
+
 // ServiceFactory implementa el patrón Factory para crear servicios
 // Este patrón encapsula la lógica de creación de objetos complejos
 // y proporciona una interfaz unificada para crear diferentes tipos de servicios
 type ServiceFactory struct {
 	userRepo    repositories.UserRepository
 	productRepo repositories.ProductRepository
+	orderRepo   repositories.OrderRepository
 	eventBus    events.EventBus
+
+	// outbox indica que los eventos de producto se guardan en el outbox del repositorio
+	outbox bool
+
+	// uow hace atómicos los pedidos y las operaciones en lote de los servicios de
+	// gestión; puede ser nil
+	uow repositories.UnitOfWork
+
+	// searchIndex resuelve las búsquedas de texto de productos; puede ser nil
+	searchIndex repositories.ProductSearchIndex
+
+	// emailPolicy normaliza los emails de los usuarios
+	emailPolicy entities.EmailPolicy
 }
 
 // NewServiceFactory crea una nueva instancia del factory de servicios
@@ -20,21 +39,54 @@ type ServiceFactory struct {
 func NewServiceFactory(
 	userRepo repositories.UserRepository,
 	productRepo repositories.ProductRepository,
+	orderRepo repositories.OrderRepository,
 	eventBus events.EventBus,
 ) *ServiceFactory {
 	return &ServiceFactory{
 		userRepo:    userRepo,
 		productRepo: productRepo,
+		orderRepo:   orderRepo,
 		eventBus:    eventBus,
 	}
 }
 
+// WithOutbox hace que los servicios de producto guarden sus eventos en el outbox
+// del repositorio de productos en lugar de publicarlos directamente
+// Algún events.OutboxRelay debe publicarlos después
+func (f *ServiceFactory) WithOutbox() *ServiceFactory {
+	f.outbox = true
+	return f
+}
+
+// WithUnitOfWork hace que los pedidos y las operaciones en lote de los servicios de
+// gestión se ejecuten en una transacción de uow, que debe abarcar los repositorios
+// del factory
+func (f *ServiceFactory) WithUnitOfWork(uow repositories.UnitOfWork) *ServiceFactory {
+	f.uow = uow
+	return f
+}
+
+// WithSearchIndex hace que los servicios de producto resuelvan las búsquedas de
+// texto con index, que alguien debe mantener al día (ver search.ProductIndexer)
+func (f *ServiceFactory) WithSearchIndex(index repositories.ProductSearchIndex) *ServiceFactory {
+	f.searchIndex = index
+	return f
+}
+
+// WithEmailPolicy configura cómo normalizan los emails los servicios de usuario
+func (f *ServiceFactory) WithEmailPolicy(policy entities.EmailPolicy) *ServiceFactory {
+	f.emailPolicy = policy
+	return f
+}
+
 // CreateUserService crea un servicio de usuario con todas sus dependencias
 // El factory se encarga de inyectar las dependencias correctas
 func (f *ServiceFactory) CreateUserService() *services.UserService {
 	// Crear los servicios granulares
 	validator := services.NewUserValidator()
-	processor := services.NewUserProcessor(f.userRepo)
+	processor := services.NewUserProcessor(f.userRepo).
+		WithConflictRetries(conflictRetries).
+		WithEmailPolicy(f.emailPolicy)
 	publisher := services.NewUserEventPublisher(f.eventBus)
 
 	// Crear el servicio principal que orquesta los servicios granulares
@@ -45,24 +97,55 @@ func (f *ServiceFactory) CreateUserService() *services.UserService {
 func (f *ServiceFactory) CreateProductService() *services.ProductService {
 	// Crear los servicios granulares
 	validator := services.NewProductValidator()
-	processor := services.NewProductProcessor(f.productRepo)
+	processor := services.NewProductProcessor(f.productRepo).WithConflictRetries(conflictRetries)
+	if f.searchIndex != nil {
+		processor.WithSearchIndex(f.searchIndex)
+	}
 	publisher := services.NewProductEventPublisher(f.eventBus)
+	if f.outbox {
+		publisher.WithOutbox()
+	}
 
 	// Crear el servicio principal que orquesta los servicios granulares
 	return services.NewProductService(validator, processor, publisher)
 }
 
+// CreateOrderService crea un servicio de pedidos con todas sus dependencias
+// Los pedidos modifican el stock y consultan usuarios a través de sus servicios,
+// que el factory crea también
+func (f *ServiceFactory) CreateOrderService() *services.OrderService {
+	// Crear los servicios granulares
+	validator := services.NewOrderValidator()
+	processor := services.NewOrderProcessor(f.orderRepo, f.CreateUserService(), f.CreateProductService()).
+		WithConflictRetries(conflictRetries)
+	if f.uow != nil {
+		processor.WithUnitOfWork(f.uow)
+	}
+	publisher := services.NewOrderEventPublisher(f.eventBus)
+
+	// Crear el servicio principal que orquesta los servicios granulares
+	return services.NewOrderService(validator, processor, publisher)
+}
+
 // CreateUserManagementService crea un servicio de gestión de usuarios
 // Este servicio combina múltiples servicios para operaciones complejas
 func (f *ServiceFactory) CreateUserManagementService() *services.UserManagementService {
 	userService := f.CreateUserService()
-	return services.NewUserManagementService(userService, f.userRepo)
+	service := services.NewUserManagementService(userService, f.userRepo)
+	if f.uow != nil {
+		service.WithUnitOfWork(f.uow)
+	}
+	return service
 }
 
 // CreateProductManagementService crea un servicio de gestión de productos
 func (f *ServiceFactory) CreateProductManagementService() *services.ProductManagementService {
 	productService := f.CreateProductService()
-	return services.NewProductManagementService(productService, f.productRepo)
+	service := services.NewProductManagementService(productService, f.productRepo)
+	if f.uow != nil {
+		service.WithUnitOfWork(f.uow)
+	}
+	return service
 }
 
 // CreateAllServices crea todos los servicios disponibles
@@ -71,6 +154,7 @@ func (f *ServiceFactory) CreateAllServices() *AllServices {
 	return &AllServices{
 		UserService:            f.CreateUserService(),
 		ProductService:         f.CreateProductService(),
+		OrderService:           f.CreateOrderService(),
 		UserManagementService:  f.CreateUserManagementService(),
 		ProductManagementService: f.CreateProductManagementService(),
 	}
@@ -81,6 +165,7 @@ func (f *ServiceFactory) CreateAllServices() *AllServices {
 type AllServices struct {
 	UserService            *services.UserService
 	ProductService         *services.ProductService
+	OrderService           *services.OrderService
 	UserManagementService  *services.UserManagementService
 	ProductManagementService *services.ProductManagementService
 }
\ No newline at end of file
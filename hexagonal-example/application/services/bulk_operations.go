@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"hexagonal-example/domain/repositories"
+)
+
+// errBulkRolledBack indica que un lote transaccional falló y se deshizo por completo
+var errBulkRolledBack = errors.New("bulk operation rolled back")
+
+// runBulk aplica apply a cada solicitud y retorna los resultados y un
+// BulkOperationError por cada solicitud que falla
+// Sin uow cada solicitud se guarda por separado y el lote puede quedar a medias
+// Con uow el lote es una sola transacción: si alguna solicitud falla se deshace
+// todo y no se retorna ningún resultado
+func runBulk[R, T any](ctx context.Context, uow repositories.UnitOfWork, requests []R, apply func(context.Context, R) (*T, error)) ([]*T, []error) {
+	if uow == nil {
+		return applyBulk(ctx, requests, apply)
+	}
+
+	var results []*T
+	var errs []error
+	err := repositories.RunInTransaction(ctx, uow, func(ctx context.Context) error {
+		results, errs = applyBulk(ctx, requests, apply)
+		if len(errs) > 0 {
+			return errBulkRolledBack
+		}
+		return nil
+	})
+	if err == nil {
+		return results, nil
+	}
+	// Los errores de la propia transacción (begin, commit, rollback) se añaden al lote
+	// RunInTransaction une el fallo del rollback a errBulkRolledBack, que es interno
+	if !errors.Is(err, errBulkRolledBack) {
+		errs = append(errs, err)
+	} else if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, joinedErr := range joined.Unwrap() {
+			if !errors.Is(joinedErr, errBulkRolledBack) {
+				errs = append(errs, joinedErr)
+			}
+		}
+	}
+	return nil, errs
+}
+
+// applyBulk aplica apply a cada solicitud de forma independiente
+func applyBulk[R, T any](ctx context.Context, requests []R, apply func(context.Context, R) (*T, error)) ([]*T, []error) {
+	var results []*T
+	var errs []error
+
+	for i, req := range requests {
+		result, err := apply(ctx, req)
+		if err != nil {
+			errs = append(errs, &BulkOperationError{
+				Index:   i,
+				Message: err.Error(),
+			})
+		} else {
+			results = append(results, result)
+		}
+	}
+
+	return results, errs
+}
@@ -0,0 +1,17 @@
+package services
+
+// ValidationError representa un error de validación de los datos de entrada
+// Permite a los adaptadores (HTTP, CLI) distinguir los errores del cliente
+// de los errores internos del sistema
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// newValidationError crea un nuevo error de validación con el mensaje indicado
+func newValidationError(message string) error {
+	return &ValidationError{Message: message}
+}
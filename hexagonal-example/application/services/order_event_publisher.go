@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/infrastructure/events"
+)
+
+// OrderEventPublisher se encarga únicamente de publicar eventos relacionados con pedidos
+type OrderEventPublisher struct {
+	eventBus events.EventBus
+
+	// onPublishError recibe los errores de las publicaciones aplazadas hasta el commit
+	onPublishError PublishErrorHandler
+}
+
+// NewOrderEventPublisher crea una nueva instancia del publicador de eventos de pedido
+func NewOrderEventPublisher(eventBus events.EventBus) *OrderEventPublisher {
+	return &OrderEventPublisher{
+		eventBus:       eventBus,
+		onPublishError: LogPublishError,
+	}
+}
+
+// PublishOrderCreated publica un evento cuando se realiza un pedido
+func (p *OrderEventPublisher) PublishOrderCreated(ctx context.Context, order *entities.Order) error {
+	event := events.OrderCreatedEvent{
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Items:     orderItemData(order.Items),
+		Total:     order.Total(),
+		Currency:  order.Currency(),
+		CreatedAt: order.CreatedAt,
+	}
+
+	return publishEvent(ctx, p.eventBus, event, p.onPublishError)
+}
+
+// PublishOrderCancelled publica un evento cuando se cancela un pedido
+func (p *OrderEventPublisher) PublishOrderCancelled(ctx context.Context, order *entities.Order) error {
+	event := events.OrderCancelledEvent{
+		OrderID:     order.ID,
+		UserID:      order.UserID,
+		Items:       orderItemData(order.Items),
+		Currency:    order.Currency(),
+		CancelledAt: order.UpdatedAt,
+	}
+
+	return publishEvent(ctx, p.eventBus, event, p.onPublishError)
+}
+
+// orderItemData convierte las líneas del pedido a su representación en eventos
+func orderItemData(items []entities.OrderItem) []events.OrderItemData {
+	data := make([]events.OrderItemData, 0, len(items))
+	for _, item := range items {
+		data = append(data, events.OrderItemData{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+	return data
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// orderReservationTTL es la duración de las reservas de stock que se toman
+// mientras se realiza un pedido; solo cubre el tiempo entre reservar y confirmar
+const orderReservationTTL = time.Minute
+
+// OrderProcessor se encarga del procesamiento de la lógica de negocio de pedidos
+// El stock y los usuarios se gestionan a través de sus servicios, de modo que
+// los cambios de stock de un pedido validan y publican eventos como cualquier otro
+type OrderProcessor struct {
+	orderRepo      repositories.OrderRepository
+	userService    *UserService
+	productService *ProductService
+
+	// conflictRetries es el número de reintentos ante conflictos de versión
+	conflictRetries int
+
+	// uow hace atómicos los pedidos; sin él los pasos fallidos se compensan
+	uow repositories.UnitOfWork
+}
+
+// NewOrderProcessor crea una nueva instancia del procesador de pedidos
+func NewOrderProcessor(orderRepo repositories.OrderRepository, userService *UserService, productService *ProductService) *OrderProcessor {
+	return &OrderProcessor{
+		orderRepo:      orderRepo,
+		userService:    userService,
+		productService: productService,
+	}
+}
+
+// WithConflictRetries configura cuántas veces se reintenta una modificación
+// cuando otro proceso ha guardado el pedido entre la lectura y la escritura
+func (p *OrderProcessor) WithConflictRetries(retries int) *OrderProcessor {
+	p.conflictRetries = retries
+	return p
+}
+
+// WithUnitOfWork hace que cada pedido se cree o se cancele en una transacción de
+// uow, que debe abarcar los repositorios de pedidos, productos y usuarios
+func (p *OrderProcessor) WithUnitOfWork(uow repositories.UnitOfWork) *OrderProcessor {
+	p.uow = uow
+	return p
+}
+
+// CreateOrder realiza un pedido descontando el stock de cada línea
+// Primero se reserva el stock de todas las líneas, de modo que un pedido con una
+// línea sin stock no llega a descontar nada; después se confirman las reservas
+// y se guarda el pedido. Con WithUnitOfWork todo ocurre en una transacción; sin
+// ella, si un paso falla se compensan los anteriores
+func (p *OrderProcessor) CreateOrder(ctx context.Context, id, userID string, lines []OrderLine) (*entities.Order, error) {
+	return p.transact(ctx, func(ctx context.Context) (*entities.Order, error) {
+		return p.createOrder(ctx, id, userID, lines)
+	})
+}
+
+// createOrder realiza los pasos de CreateOrder
+func (p *OrderProcessor) createOrder(ctx context.Context, id, userID string, lines []OrderLine) (*entities.Order, error) {
+	// Verificar si el pedido ya existe
+	exists, err := p.orderRepo.Exists(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, repositories.ErrOrderAlreadyExists
+	}
+
+	// Solo los usuarios activos pueden realizar pedidos
+	user, err := p.userService.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, repositories.ErrUserNotActive
+	}
+
+	// Reservar el stock de todas las líneas
+	reservations := make([]*entities.StockReservation, 0, len(lines))
+	items := make([]entities.OrderItem, 0, len(lines))
+	for _, line := range lines {
+		reservation, err := p.productService.Reserve(ctx, line.ProductID, line.Quantity, orderReservationTTL)
+		if err != nil {
+			return nil, errors.Join(err, p.releaseReservations(ctx, lines, reservations))
+		}
+		reservations = append(reservations, reservation)
+
+		product, err := p.productService.GetProduct(ctx, line.ProductID)
+		if err != nil {
+			return nil, errors.Join(err, p.releaseReservations(ctx, lines, reservations))
+		}
+		items = append(items, entities.OrderItem{
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+			UnitPrice: product.Price,
+		})
+	}
+
+	// Crear la entidad de pedido
+	// Los datos de entrada ya están validados: un error aquí se debe a los
+	// productos (por ejemplo precios en monedas distintas)
+	order, err := entities.NewOrder(id, userID, items)
+	if err != nil {
+		return nil, errors.Join(newValidationError(err.Error()), p.releaseReservations(ctx, lines, reservations))
+	}
+
+	// Confirmar las reservas descontando el stock
+	for i, reservation := range reservations {
+		if _, err := p.productService.Confirm(ctx, lines[i].ProductID, reservation.ID); err != nil {
+			return nil, errors.Join(err, p.restock(ctx, items[:i]), p.releaseReservations(ctx, lines[i+1:], reservations[i+1:]))
+		}
+	}
+
+	// Guardar en el repositorio
+	if err := p.orderRepo.Save(ctx, order); err != nil {
+		return nil, errors.Join(err, p.restock(ctx, items))
+	}
+
+	return order, nil
+}
+
+// CancelOrder cancela un pedido y devuelve su stock
+// Con WithUnitOfWork, si no se puede devolver el stock el pedido no se cancela;
+// sin ella el pedido queda cancelado y se retorna junto con el error
+func (p *OrderProcessor) CancelOrder(ctx context.Context, id string) (*entities.Order, error) {
+	return p.transact(ctx, func(ctx context.Context) (*entities.Order, error) {
+		return p.cancelOrder(ctx, id)
+	})
+}
+
+// cancelOrder realiza los pasos de CancelOrder
+func (p *OrderProcessor) cancelOrder(ctx context.Context, id string) (*entities.Order, error) {
+	var order *entities.Order
+	err := retryOnConflict(p.conflictRetries, repositories.ErrOrderVersionConflict, func() error {
+		current, err := p.GetOrder(ctx, id)
+		if err != nil {
+			return err
+		}
+		if current.IsCancelled() {
+			return repositories.ErrOrderAlreadyCancelled
+		}
+
+		if err := current.Cancel(); err != nil {
+			return err
+		}
+		if err := p.orderRepo.Save(ctx, current); err != nil {
+			return err
+		}
+		order = current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// El pedido ya está cancelado: devolver el stock de los productos que sigan existiendo
+	var errs []error
+	for _, item := range order.Items {
+		_, err := p.productService.AddStock(ctx, item.ProductID, item.Quantity)
+		if err != nil && !errors.Is(err, repositories.ErrProductNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	return order, errors.Join(errs...)
+}
+
+// GetOrder obtiene un pedido por ID
+func (p *OrderProcessor) GetOrder(ctx context.Context, id string) (*entities.Order, error) {
+	order, err := p.orderRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, repositories.ErrOrderNotFound
+	}
+
+	return order, nil
+}
+
+// ListOrders obtiene una lista de pedidos
+func (p *OrderProcessor) ListOrders(ctx context.Context, limit, offset int) ([]*entities.Order, error) {
+	return p.orderRepo.FindAll(ctx, limit, offset)
+}
+
+// ListUserOrders obtiene los pedidos de un usuario
+func (p *OrderProcessor) ListUserOrders(ctx context.Context, userID string, limit, offset int) ([]*entities.Order, error) {
+	return p.orderRepo.FindByUserID(ctx, userID, limit, offset)
+}
+
+// transact ejecuta fn en una transacción de la unidad de trabajo, si hay una
+// Si fn falla no se retorna el pedido, ya que sus escrituras se deshicieron
+func (p *OrderProcessor) transact(ctx context.Context, fn func(ctx context.Context) (*entities.Order, error)) (*entities.Order, error) {
+	if p.uow == nil {
+		return fn(ctx)
+	}
+
+	var order *entities.Order
+	err := repositories.RunInTransaction(ctx, p.uow, func(ctx context.Context) error {
+		var err error
+		order, err = fn(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// releaseReservations libera las reservas tomadas para un pedido que no se completa
+// y retorna los errores de las que no se pudieron liberar, que expiran por sí solas
+// Dentro de una transacción no hace nada: el rollback ya las deshace
+func (p *OrderProcessor) releaseReservations(ctx context.Context, lines []OrderLine, reservations []*entities.StockReservation) error {
+	if repositories.InTransaction(ctx) {
+		return nil
+	}
+
+	var errs []error
+	for i, reservation := range reservations {
+		if _, err := p.productService.Release(ctx, lines[i].ProductID, reservation.ID); err != nil {
+			errs = append(errs, fmt.Errorf("releasing reservation %s of product %s: %w", reservation.ID, lines[i].ProductID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restock devuelve el stock ya descontado de un pedido que no se completa y
+// retorna los errores de los productos que no se pudieron reponer
+// Dentro de una transacción no hace nada: el rollback ya lo deshace
+func (p *OrderProcessor) restock(ctx context.Context, items []entities.OrderItem) error {
+	if repositories.InTransaction(ctx) {
+		return nil
+	}
+
+	var errs []error
+	for _, item := range items {
+		if _, err := p.productService.AddStock(ctx, item.ProductID, item.Quantity); err != nil {
+			errs = append(errs, fmt.Errorf("restocking product %s: %w", item.ProductID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
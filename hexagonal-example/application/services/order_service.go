@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"hexagonal-example/domain/entities"
+)
+
+// OrderService es el servicio principal que orquesta los servicios granulares de pedidos
+type OrderService struct {
+	validator *OrderValidator
+	processor *OrderProcessor
+	publisher *OrderEventPublisher
+
+	// onPublishError recibe los errores al publicar eventos
+	onPublishError PublishErrorHandler
+}
+
+// NewOrderService crea una nueva instancia del servicio de pedidos
+func NewOrderService(validator *OrderValidator, processor *OrderProcessor, publisher *OrderEventPublisher) *OrderService {
+	return &OrderService{
+		validator:      validator,
+		processor:      processor,
+		publisher:      publisher,
+		onPublishError: LogPublishError,
+	}
+}
+
+// WithPublishErrorHandler configura qué se hace con los errores al publicar eventos
+// Por defecto se registran con LogPublishError
+func (s *OrderService) WithPublishErrorHandler(handler PublishErrorHandler) *OrderService {
+	s.onPublishError = handler
+	s.publisher.onPublishError = handler
+	return s
+}
+
+// CreateOrder realiza un pedido con validación, procesamiento y publicación de eventos
+func (s *OrderService) CreateOrder(ctx context.Context, id, userID string, lines []OrderLine) (*entities.Order, error) {
+	// 1. Validar los datos de entrada
+	if err := s.validator.ValidateCreateOrder(id, userID, lines); err != nil {
+		return nil, err
+	}
+
+	// 2. Procesar el pedido (usuario activo y descuento de stock)
+	order, err := s.processor.CreateOrder(ctx, id, userID, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Publicar evento de pedido creado
+	if err := s.publisher.PublishOrderCreated(ctx, order); err != nil {
+		s.onPublishError(ctx, err)
+	}
+
+	return order, nil
+}
+
+// CancelOrder cancela un pedido devolviendo su stock
+func (s *OrderService) CancelOrder(ctx context.Context, id string) (*entities.Order, error) {
+	// 1. Validar el ID
+	if err := s.validator.ValidateOrderID(id); err != nil {
+		return nil, err
+	}
+
+	// 2. Procesar la cancelación
+	order, err := s.processor.CancelOrder(ctx, id)
+	if order == nil {
+		return nil, err
+	}
+
+	// 3. Publicar evento de pedido cancelado (el pedido queda cancelado aunque
+	// no se haya podido devolver todo el stock)
+	if err := s.publisher.PublishOrderCancelled(ctx, order); err != nil {
+		s.onPublishError(ctx, err)
+	}
+
+	return order, err
+}
+
+// GetOrder obtiene un pedido por ID
+func (s *OrderService) GetOrder(ctx context.Context, id string) (*entities.Order, error) {
+	return s.processor.GetOrder(ctx, id)
+}
+
+// ListOrders obtiene una lista de pedidos
+func (s *OrderService) ListOrders(ctx context.Context, limit, offset int) ([]*entities.Order, error) {
+	return s.processor.ListOrders(ctx, limit, offset)
+}
+
+// ListUserOrders obtiene los pedidos de un usuario
+func (s *OrderService) ListUserOrders(ctx context.Context, userID string, limit, offset int) ([]*entities.Order, error) {
+	return s.processor.ListUserOrders(ctx, userID, limit, offset)
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"strings"
+)
+
+// maxOrderItems es el número máximo de líneas por pedido
+const maxOrderItems = 100
+
+// OrderLine representa una línea solicitada al crear un pedido
+// El precio no forma parte de la petición: se toma del producto al realizar el pedido
+type OrderLine struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// OrderValidator se encarga únicamente de la validación de datos de pedidos
+type OrderValidator struct{}
+
+// NewOrderValidator crea una nueva instancia del validador de pedidos
+func NewOrderValidator() *OrderValidator {
+	return &OrderValidator{}
+}
+
+// ValidateCreateOrder valida los datos para crear un nuevo pedido
+func (v *OrderValidator) ValidateCreateOrder(id, userID string, lines []OrderLine) error {
+	// Validar ID
+	if err := v.ValidateOrderID(id); err != nil {
+		return err
+	}
+
+	// Validar usuario
+	if strings.TrimSpace(userID) == "" {
+		return newValidationError("order user ID cannot be empty")
+	}
+
+	// Validar líneas
+	if len(lines) == 0 {
+		return newValidationError("order must have at least one item")
+	}
+	if len(lines) > maxOrderItems {
+		return newValidationError("order cannot have more than 100 items")
+	}
+
+	seen := make(map[string]bool, len(lines))
+	for _, line := range lines {
+		if strings.TrimSpace(line.ProductID) == "" {
+			return newValidationError("order item product ID cannot be empty")
+		}
+		if line.Quantity <= 0 {
+			return newValidationError("order item quantity must be positive")
+		}
+		if line.Quantity > 1000000 {
+			return newValidationError("order item quantity cannot exceed 1,000,000")
+		}
+		if seen[line.ProductID] {
+			return newValidationError("order cannot contain the same product twice")
+		}
+		seen[line.ProductID] = true
+	}
+
+	return nil
+}
+
+// ValidateOrderID valida el ID del pedido
+func (v *OrderValidator) ValidateOrderID(id string) error {
+	if strings.TrimSpace(id) == "" {
+		return newValidationError("order ID cannot be empty")
+	}
+	if len(id) < 3 {
+		return newValidationError("order ID must be at least 3 characters long")
+	}
+	if len(id) > 50 {
+		return newValidationError("order ID cannot exceed 50 characters")
+	}
+	return nil
+}
@@ -2,81 +2,156 @@ package services
 
 import (
 	"context"
+	"errors"
+	"time"
 	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
 	"hexagonal-example/infrastructure/events"
 )
 
 // ProductEventPublisher se encarga únicamente de publicar eventos relacionados con productos
 type ProductEventPublisher struct {
 	eventBus events.EventBus
+
+	// onPublishError recibe los errores de las publicaciones aplazadas hasta el commit
+	onPublishError PublishErrorHandler
+
+	// outbox indica que los eventos se guardan en el outbox del repositorio junto
+	// al producto y los publica un events.OutboxRelay, en lugar de publicarse aquí
+	outbox bool
 }
 
 // NewProductEventPublisher crea una nueva instancia del publicador de eventos de producto
 func NewProductEventPublisher(eventBus events.EventBus) *ProductEventPublisher {
 	return &ProductEventPublisher{
-		eventBus: eventBus,
+		eventBus:       eventBus,
+		onPublishError: LogPublishError,
+	}
+}
+
+// WithOutbox hace que los eventos se guarden junto al producto en lugar de publicarse
+// tras guardarlo: el servicio los registra con Record antes de cada escritura y
+// PublishEvents no hace nada. El repositorio debe implementar OutboxRepository
+func (p *ProductEventPublisher) WithOutbox() *ProductEventPublisher {
+	p.outbox = true
+	return p
+}
+
+// Record retorna un contexto con el que las escrituras del repositorio guardan en el
+// outbox los eventos de dominio pendientes del producto guardado
+// Sin outbox retorna ctx sin cambios y los eventos se publican después con PublishEvents
+func (p *ProductEventPublisher) Record(ctx context.Context) context.Context {
+	if !p.outbox {
+		return ctx
 	}
+	return repositories.WithProductOutbox(ctx, func(product *entities.Product) ([]*repositories.OutboxMessage, error) {
+		var messages []*repositories.OutboxMessage
+		for _, domainEvent := range product.Events() {
+			message, err := events.NewOutboxMessage(ctx, productEvent(product, domainEvent))
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, message)
+		}
+		return messages, nil
+	})
 }
 
-// PublishProductCreated publica un evento cuando se crea un producto
-func (p *ProductEventPublisher) PublishProductCreated(ctx context.Context, product *entities.Product) error {
-	event := events.ProductCreatedEvent{
+// PublishEvents publica los eventos de dominio que registró el producto, una vez guardado
+// Con outbox no hace nada: los eventos ya se guardaron junto al producto
+func (p *ProductEventPublisher) PublishEvents(ctx context.Context, product *entities.Product, domainEvents []entities.DomainEvent) error {
+	if p.outbox {
+		return nil
+	}
+	var errs []error
+	for _, domainEvent := range domainEvents {
+		if err := publishEvent(ctx, p.eventBus, productEvent(product, domainEvent), p.onPublishError); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// productEvent construye el evento de integración de un evento de dominio del producto
+func productEvent(product *entities.Product, domainEvent entities.DomainEvent) events.Event {
+	switch e := domainEvent.(type) {
+	case entities.ProductCreated:
+		return productCreated(product)
+	case entities.StockChanged:
+		return stockUpdated(product, e.OldStock, e.NewStock)
+	case entities.ProductDeactivated:
+		return productDeactivated(product)
+	case entities.ProductActivated:
+		return productActivated(product)
+	case entities.ProductDeleted:
+		return productDeleted(product, e.DeletedAt)
+	default:
+		return productUpdated(product)
+	}
+}
+
+// productCreated construye el evento de producto creado
+func productCreated(product *entities.Product) events.Event {
+	return events.ProductCreatedEvent{
 		ProductID:  product.ID,
 		Name:       product.Name,
 		Category:   product.Category,
 		Price:      product.Price,
+		Currency:   product.Price.Currency(),
 		Stock:      product.Stock,
 		CreatedAt:  product.CreatedAt,
 	}
-
-	return p.eventBus.Publish(ctx, "product.created", event)
 }
 
-// PublishProductUpdated publica un evento cuando se actualiza un producto
-func (p *ProductEventPublisher) PublishProductUpdated(ctx context.Context, product *entities.Product) error {
-	event := events.ProductUpdatedEvent{
+// productUpdated construye el evento de producto actualizado
+func productUpdated(product *entities.Product) events.Event {
+	return events.ProductUpdatedEvent{
 		ProductID:  product.ID,
 		Name:       product.Name,
 		Category:   product.Category,
 		Price:      product.Price,
+		Currency:   product.Price.Currency(),
 		Stock:      product.Stock,
 		UpdatedAt:  product.UpdatedAt,
 	}
-
-	return p.eventBus.Publish(ctx, "product.updated", event)
 }
 
-// PublishStockUpdated publica un evento cuando se actualiza el stock de un producto
-func (p *ProductEventPublisher) PublishStockUpdated(ctx context.Context, product *entities.Product, oldStock int) error {
-	event := events.StockUpdatedEvent{
-		ProductID:  product.ID,
-		Name:       product.Name,
-		OldStock:   oldStock,
-		NewStock:   product.Stock,
-		UpdatedAt:  product.UpdatedAt,
+// stockUpdated construye el evento de stock actualizado
+func stockUpdated(product *entities.Product, oldStock, newStock int) events.Event {
+	return events.StockUpdatedEvent{
+		ProductID:      product.ID,
+		Name:           product.Name,
+		OldStock:       oldStock,
+		NewStock:       newStock,
+		ReservedStock:  product.ReservedStock(),
+		AvailableStock: product.AvailableStock(),
+		UpdatedAt:      product.UpdatedAt,
 	}
-
-	return p.eventBus.Publish(ctx, "product.stock.updated", event)
 }
 
-// PublishProductDeactivated publica un evento cuando se desactiva un producto
-func (p *ProductEventPublisher) PublishProductDeactivated(ctx context.Context, product *entities.Product) error {
-	event := events.ProductDeactivatedEvent{
+// productDeactivated construye el evento de producto desactivado
+func productDeactivated(product *entities.Product) events.Event {
+	return events.ProductDeactivatedEvent{
 		ProductID:      product.ID,
 		Name:           product.Name,
 		DeactivatedAt:  product.UpdatedAt,
 	}
-
-	return p.eventBus.Publish(ctx, "product.deactivated", event)
 }
 
-// PublishProductActivated publica un evento cuando se activa un producto
-func (p *ProductEventPublisher) PublishProductActivated(ctx context.Context, product *entities.Product) error {
-	event := events.ProductActivatedEvent{
+// productActivated construye el evento de producto activado
+func productActivated(product *entities.Product) events.Event {
+	return events.ProductActivatedEvent{
 		ProductID:    product.ID,
 		Name:         product.Name,
 		ActivatedAt:  product.UpdatedAt,
 	}
+}
 
-	return p.eventBus.Publish(ctx, "product.activated", event)
+// productDeleted construye el evento de producto eliminado
+func productDeleted(product *entities.Product, deletedAt time.Time) events.Event {
+	return events.ProductDeletedEvent{
+		ProductID: product.ID,
+		Name:      product.Name,
+		DeletedAt: deletedAt,
+	}
 }
\ No newline at end of file
@@ -10,6 +10,9 @@ import (
 type ProductManagementService struct {
 	productService *ProductService
 	productRepo    repositories.ProductRepository
+
+	// uow hace atómicas las operaciones en lote; sin él cada elemento se guarda por separado
+	uow repositories.UnitOfWork
 }
 
 // NewProductManagementService crea una nueva instancia del servicio de gestión de productos
@@ -20,44 +23,27 @@ func NewProductManagementService(productService *ProductService, productRepo rep
 	}
 }
 
+// WithUnitOfWork hace que las operaciones en lote se ejecuten en una transacción de uow
+// El repositorio de productos debe pertenecer al mismo adaptador que uow
+func (s *ProductManagementService) WithUnitOfWork(uow repositories.UnitOfWork) *ProductManagementService {
+	s.uow = uow
+	return s
+}
+
 // BulkCreateProducts crea múltiples productos en una operación
+// Con WithUnitOfWork, si algún producto falla no se crea ninguno
 func (s *ProductManagementService) BulkCreateProducts(ctx context.Context, products []CreateProductRequest) ([]*entities.Product, []error) {
-	var createdProducts []*entities.Product
-	var errors []error
-
-	for i, req := range products {
-		product, err := s.productService.CreateProduct(ctx, req.ID, req.Name, req.Description, req.Category, req.Price, req.Stock)
-		if err != nil {
-			errors = append(errors, &BulkOperationError{
-				Index:   i,
-				Message: err.Error(),
-			})
-		} else {
-			createdProducts = append(createdProducts, product)
-		}
-	}
-
-	return createdProducts, errors
+	return runBulk(ctx, s.uow, products, func(ctx context.Context, req CreateProductRequest) (*entities.Product, error) {
+		return s.productService.CreateProduct(ctx, req.ID, req.Name, req.Description, req.Category, req.Price, req.Stock)
+	})
 }
 
 // BulkUpdateStock actualiza el stock de múltiples productos
+// Con WithUnitOfWork, si alguna actualización falla no se aplica ninguna
 func (s *ProductManagementService) BulkUpdateStock(ctx context.Context, stockUpdates []StockUpdateRequest) ([]*entities.Product, []error) {
-	var updatedProducts []*entities.Product
-	var errors []error
-
-	for i, req := range stockUpdates {
-		product, err := s.productService.UpdateStock(ctx, req.ProductID, req.NewStock)
-		if err != nil {
-			errors = append(errors, &BulkOperationError{
-				Index:   i,
-				Message: err.Error(),
-			})
-		} else {
-			updatedProducts = append(updatedProducts, product)
-		}
-	}
-
-	return updatedProducts, errors
+	return runBulk(ctx, s.uow, stockUpdates, func(ctx context.Context, req StockUpdateRequest) (*entities.Product, error) {
+		return s.productService.UpdateStock(ctx, req.ProductID, req.NewStock)
+	})
 }
 
 // GetProductStatistics obtiene estadísticas de productos
@@ -91,24 +77,14 @@ func (s *ProductManagementService) GetCategoryStatistics(ctx context.Context) (m
 	return make(map[string]int), nil
 }
 
-// SearchProducts busca productos por diferentes criterios
-func (s *ProductManagementService) SearchProducts(ctx context.Context, criteria ProductSearchCriteria) ([]*entities.Product, error) {
-	// Implementar lógica de búsqueda más compleja
-	if criteria.Category != "" {
-		return s.productService.ListProductsByCategory(ctx, criteria.Category, criteria.Limit, criteria.Offset)
-	}
-
-	if criteria.MinPrice > 0 || criteria.MaxPrice > 0 {
-		minPrice := criteria.MinPrice
-		maxPrice := criteria.MaxPrice
-		if maxPrice == 0 {
-			maxPrice = 1000000 // Valor alto por defecto
-		}
-		return s.productService.ListProductsByPriceRange(ctx, minPrice, maxPrice, criteria.Limit, criteria.Offset)
+// SearchProducts busca los productos que cumplen a la vez todos los criterios indicados
+// Sin criterios retorna todos los productos, página a página
+func (s *ProductManagementService) SearchProducts(ctx context.Context, criteria ProductSearchCriteria) (repositories.Page[*entities.Product], error) {
+	spec, err := criteria.specification()
+	if err != nil {
+		return repositories.Page[*entities.Product]{}, err
 	}
-
-	// Si no hay criterios específicos, retornar todos los productos
-	return s.productService.ListProducts(ctx, criteria.Limit, criteria.Offset)
+	return s.productService.ListProductsPage(ctx, spec, criteria.page())
 }
 
 // CreateProductRequest representa una solicitud para crear un producto
@@ -117,7 +93,7 @@ type CreateProductRequest struct {
 	Name        string
 	Description string
 	Category    string
-	Price       float64
+	Price       entities.Money
 	Stock       int
 }
 
@@ -129,16 +105,97 @@ type StockUpdateRequest struct {
 
 // ProductStatistics contiene estadísticas de productos
 type ProductStatistics struct {
-	TotalProducts       int
-	AvailableProducts   int
-	UnavailableProducts int
+	TotalProducts       int `json:"total_products"`
+	AvailableProducts   int `json:"available_products"`
+	UnavailableProducts int `json:"unavailable_products"`
+}
+
+// ProductSearchResult es un producto encontrado por una búsqueda de texto
+type ProductSearchResult struct {
+	Product *entities.Product `json:"product"`
+
+	// Score es la relevancia del producto para la búsqueda; mayor es mejor
+	Score float64 `json:"score"`
 }
 
 // ProductSearchCriteria define criterios de búsqueda para productos
+// Los criterios con su valor cero no filtran, y los indicados se combinan con AND
+// Un precio cero no limita el rango; los dos límites deben usar la misma moneda
 type ProductSearchCriteria struct {
 	Category string
-	MinPrice float64
-	MaxPrice float64
-	Limit    int
-	Offset   int
+	MinPrice entities.Money
+	MaxPrice entities.Money
+
+	// Name busca el texto dentro del nombre, sin distinguir mayúsculas
+	Name string
+
+	// Active filtra por estado de activación si no es nil
+	Active *bool
+
+	// Available limita la búsqueda a los productos disponibles (activos y con stock)
+	Available bool
+
+	// MinStock es el stock físico mínimo
+	MinStock int
+
+	// Where es una especificación adicional para criterios que no cubren los
+	// campos anteriores, como alternativas con repositories.Or o repositories.Not
+	Where repositories.Specification
+
+	// Sort es el orden de los resultados; el valor cero ordena por ID
+	Sort repositories.SortOrder
+
+	// Limit es el tamaño de la página; con 0 se retornan todos los resultados
+	Limit int
+
+	// Cursor es el NextCursor de la página anterior, o vacío para la primera
+	Cursor string
+}
+
+// page retorna la petición de página de la búsqueda
+func (c ProductSearchCriteria) page() repositories.PageRequest {
+	return repositories.PageRequest{Sort: c.Sort, Limit: c.Limit, Cursor: c.Cursor}
+}
+
+// specification combina los criterios de búsqueda en una especificación
+func (c ProductSearchCriteria) specification() (repositories.Specification, error) {
+	specs := []repositories.Specification{c.Where}
+	if c.Category != "" {
+		specs = append(specs, repositories.InCategory(c.Category))
+	}
+	if !c.MinPrice.IsZero() || !c.MaxPrice.IsZero() {
+		minPrice, maxPrice, err := priceRange(c.MinPrice, c.MaxPrice)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, repositories.PriceBetween(minPrice, maxPrice))
+	}
+	if c.Name != "" {
+		specs = append(specs, repositories.NameContains(c.Name))
+	}
+	if c.Active != nil {
+		specs = append(specs, repositories.Where(repositories.FieldActive, repositories.OpEqual, *c.Active))
+	}
+	if c.Available {
+		specs = append(specs, repositories.IsAvailable())
+	}
+	if c.MinStock > 0 {
+		specs = append(specs, repositories.StockAtLeast(c.MinStock))
+	}
+	return repositories.And(specs...), nil
+}
+
+// priceRange completa los límites de precio de una búsqueda
+// El mínimo por defecto es cero y el máximo el precio máximo de un producto
+func priceRange(minPrice, maxPrice entities.Money) (entities.Money, entities.Money, error) {
+	switch {
+	case maxPrice.IsZero():
+		maxPrice = maxProductPrice(minPrice.Currency())
+	case minPrice.IsZero():
+		minPrice, _ = entities.NewMoney(0, maxPrice.Currency())
+	}
+	if minPrice.Currency() != maxPrice.Currency() {
+		return entities.Money{}, entities.Money{}, newValidationError("price range bounds must use the same currency")
+	}
+	return minPrice, maxPrice, nil
 }
\ No newline at end of file
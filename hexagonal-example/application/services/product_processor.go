@@ -2,14 +2,26 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"hexagonal-example/domain/entities"
 	"hexagonal-example/domain/repositories"
+	"time"
 )
 
+// errNothingToRelease indica que otra operación ya limpió las reservas expiradas
+var errNothingToRelease = errors.New("no expired reservations")
+
 // ProductProcessor se encarga del procesamiento de la lógica de negocio de productos
 type ProductProcessor struct {
 	productRepo repositories.ProductRepository
+
+	// conflictRetries es el número de reintentos ante conflictos de versión
+	conflictRetries int
+
+	// searchIndex resuelve las búsquedas de texto; puede ser nil
+	searchIndex repositories.ProductSearchIndex
 }
 
 // NewProductProcessor crea una nueva instancia del procesador de productos
@@ -19,15 +31,29 @@ func NewProductProcessor(productRepo repositories.ProductRepository) *ProductPro
 	}
 }
 
+// WithConflictRetries configura cuántas veces se reintenta una modificación
+// cuando otro proceso ha guardado el producto entre la lectura y la escritura
+// Con 0 (valor por defecto) el conflicto se retorna directamente al llamador
+func (p *ProductProcessor) WithConflictRetries(retries int) *ProductProcessor {
+	p.conflictRetries = retries
+	return p
+}
+
+// WithSearchIndex configura el índice con el que se resuelven las búsquedas de texto
+func (p *ProductProcessor) WithSearchIndex(index repositories.ProductSearchIndex) *ProductProcessor {
+	p.searchIndex = index
+	return p
+}
+
 // CreateProduct crea un nuevo producto en el sistema
-func (p *ProductProcessor) CreateProduct(ctx context.Context, id, name, description, category string, price float64, stock int) (*entities.Product, error) {
+func (p *ProductProcessor) CreateProduct(ctx context.Context, id, name, description, category string, price entities.Money, stock int) (*entities.Product, error) {
 	// Verificar si el producto ya existe
 	exists, err := p.productRepo.Exists(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, errors.New("product already exists")
+		return nil, repositories.ErrProductAlreadyExists
 	}
 
 	// Crear la entidad de producto
@@ -45,138 +71,179 @@ func (p *ProductProcessor) CreateProduct(ctx context.Context, id, name, descript
 }
 
 // UpdateProduct actualiza un producto existente
-func (p *ProductProcessor) UpdateProduct(ctx context.Context, id string, name, description, category *string, price *float64, stock *int) (*entities.Product, error) {
-	// Buscar el producto existente
-	product, err := p.productRepo.FindByID(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-	if product == nil {
-		return nil, errors.New("product not found")
-	}
-
-	// Actualizar campos si se proporcionan
-	if name != nil {
-		product.Name = *name
-	}
-	if description != nil {
-		product.Description = *description
-	}
-	if category != nil {
-		product.Category = *category
-	}
-	if price != nil {
-		if err := product.UpdatePrice(*price); err != nil {
-			return nil, err
+func (p *ProductProcessor) UpdateProduct(ctx context.Context, id string, name, description, category *string, price *entities.Money, stock *int) (*entities.Product, error) {
+	return p.update(ctx, id, func(product *entities.Product) error {
+		// Actualizar campos si se proporcionan
+		if name != nil || description != nil || category != nil {
+			newName, newDescription, newCategory := product.Name, product.Description, product.Category
+			if name != nil {
+				newName = *name
+			}
+			if description != nil {
+				newDescription = *description
+			}
+			if category != nil {
+				newCategory = *category
+			}
+			if err := product.UpdateDetails(newName, newDescription, newCategory); err != nil {
+				return err
+			}
 		}
-	}
-	if stock != nil {
-		if err := product.UpdateStock(*stock); err != nil {
-			return nil, err
+		if price != nil {
+			if err := product.UpdatePrice(*price); err != nil {
+				return err
+			}
 		}
-	}
-
-	// Guardar los cambios
-	if err := p.productRepo.Save(ctx, product); err != nil {
-		return nil, err
-	}
-
-	return product, nil
+		if stock != nil {
+			if *stock >= 0 && *stock < product.ReservedStock() {
+				return repositories.ErrStockBelowReserved
+			}
+			if err := product.UpdateStock(*stock); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // UpdateStock actualiza el stock de un producto
 func (p *ProductProcessor) UpdateStock(ctx context.Context, id string, newStock int) (*entities.Product, error) {
-	product, err := p.productRepo.FindByID(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-	if product == nil {
-		return nil, errors.New("product not found")
-	}
-
-	if err := product.UpdateStock(newStock); err != nil {
-		return nil, err
-	}
-
-	if err := p.productRepo.Save(ctx, product); err != nil {
-		return nil, err
-	}
-
-	return product, nil
+	return p.update(ctx, id, func(product *entities.Product) error {
+		if newStock >= 0 && newStock < product.ReservedStock() {
+			return repositories.ErrStockBelowReserved
+		}
+		return product.UpdateStock(newStock)
+	})
 }
 
 // AddStock añade stock a un producto
 func (p *ProductProcessor) AddStock(ctx context.Context, id string, quantity int) (*entities.Product, error) {
-	product, err := p.productRepo.FindByID(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-	if product == nil {
-		return nil, errors.New("product not found")
-	}
-
-	if err := product.AddStock(quantity); err != nil {
-		return nil, err
-	}
-
-	if err := p.productRepo.Save(ctx, product); err != nil {
-		return nil, err
-	}
-
-	return product, nil
+	return p.update(ctx, id, func(product *entities.Product) error {
+		return product.AddStock(quantity)
+	})
 }
 
 // RemoveStock reduce el stock de un producto
 func (p *ProductProcessor) RemoveStock(ctx context.Context, id string, quantity int) (*entities.Product, error) {
-	product, err := p.productRepo.FindByID(ctx, id)
+	return p.update(ctx, id, func(product *entities.Product) error {
+		if product.AvailableStock() < quantity {
+			return repositories.ErrInsufficientStock
+		}
+		return product.RemoveStock(quantity)
+	})
+}
+
+// Reserve aparta stock de forma atómica para una reserva que expira tras ttl
+func (p *ProductProcessor) Reserve(ctx context.Context, id string, quantity int, ttl time.Duration) (*entities.StockReservation, *entities.Product, error) {
+	reservationID, err := newReservationID()
 	if err != nil {
-		return nil, err
-	}
-	if product == nil {
-		return nil, errors.New("product not found")
+		return nil, nil, err
 	}
+	expiresAt := time.Now().Add(ttl)
 
-	if err := product.RemoveStock(quantity); err != nil {
-		return nil, err
+	product, err := p.productRepo.Update(ctx, id, func(product *entities.Product) error {
+		product.ReleaseExpiredReservations(time.Now())
+		if !product.IsActive {
+			return repositories.ErrProductNotAvailable
+		}
+		if product.AvailableStock() < quantity {
+			return repositories.ErrInsufficientStock
+		}
+		return product.Reserve(reservationID, quantity, expiresAt)
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if err := p.productRepo.Save(ctx, product); err != nil {
-		return nil, err
-	}
+	return product.Reservation(reservationID), product, nil
+}
 
-	return product, nil
+// ConfirmReservation descuenta de forma atómica el stock reservado
+func (p *ProductProcessor) ConfirmReservation(ctx context.Context, id, reservationID string) (*entities.Product, error) {
+	return p.productRepo.Update(ctx, id, func(product *entities.Product) error {
+		product.ReleaseExpiredReservations(time.Now())
+		if product.Reservation(reservationID) == nil {
+			return repositories.ErrReservationNotFound
+		}
+		return product.ConfirmReservation(reservationID)
+	})
 }
 
-// DeactivateProduct desactiva un producto
-func (p *ProductProcessor) DeactivateProduct(ctx context.Context, id string) (*entities.Product, error) {
-	product, err := p.productRepo.FindByID(ctx, id)
+// ReleaseReservation cancela de forma atómica una reserva
+func (p *ProductProcessor) ReleaseReservation(ctx context.Context, id, reservationID string) (*entities.Product, error) {
+	return p.productRepo.Update(ctx, id, func(product *entities.Product) error {
+		product.ReleaseExpiredReservations(time.Now())
+		if product.Reservation(reservationID) == nil {
+			return repositories.ErrReservationNotFound
+		}
+		return product.ReleaseReservation(reservationID)
+	})
+}
+
+// ReleaseExpiredReservations limpia las reservas expiradas de todos los productos
+// y retorna los productos modificados
+func (p *ProductProcessor) ReleaseExpiredReservations(ctx context.Context) ([]*entities.Product, error) {
+	total, err := p.productRepo.Count(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if product == nil {
-		return nil, errors.New("product not found")
+	products, err := p.productRepo.FindAll(ctx, total, 0)
+	if err != nil {
+		return nil, err
 	}
 
-	product.Deactivate()
-	if err := p.productRepo.Save(ctx, product); err != nil {
-		return nil, err
+	now := time.Now()
+	var released []*entities.Product
+	for _, product := range products {
+		if !hasExpiredReservations(product, now) {
+			continue
+		}
+
+		updated, err := p.productRepo.Update(ctx, product.ID, func(product *entities.Product) error {
+			if product.ReleaseExpiredReservations(now) == 0 {
+				return errNothingToRelease
+			}
+			return nil
+		})
+		if errors.Is(err, errNothingToRelease) || errors.Is(err, repositories.ErrProductNotFound) {
+			continue
+		}
+		if err != nil {
+			return released, err
+		}
+		released = append(released, updated)
 	}
+	return released, nil
+}
 
-	return product, nil
+// DeactivateProduct desactiva un producto
+func (p *ProductProcessor) DeactivateProduct(ctx context.Context, id string) (*entities.Product, error) {
+	return p.update(ctx, id, func(product *entities.Product) error {
+		product.Deactivate()
+		return nil
+	})
 }
 
 // ActivateProduct activa un producto
 func (p *ProductProcessor) ActivateProduct(ctx context.Context, id string) (*entities.Product, error) {
+	return p.update(ctx, id, func(product *entities.Product) error {
+		product.Activate()
+		return nil
+	})
+}
+
+// DeleteProduct elimina un producto del sistema
+func (p *ProductProcessor) DeleteProduct(ctx context.Context, id string) (*entities.Product, error) {
 	product, err := p.productRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if product == nil {
-		return nil, errors.New("product not found")
+		return nil, repositories.ErrProductNotFound
 	}
+	product.MarkDeleted()
 
-	product.Activate()
-	if err := p.productRepo.Save(ctx, product); err != nil {
+	if err := p.productRepo.Delete(ctx, id); err != nil {
 		return nil, err
 	}
 
@@ -190,7 +257,7 @@ func (p *ProductProcessor) GetProduct(ctx context.Context, id string) (*entities
 		return nil, err
 	}
 	if product == nil {
-		return nil, errors.New("product not found")
+		return nil, repositories.ErrProductNotFound
 	}
 
 	return product, nil
@@ -212,6 +279,90 @@ func (p *ProductProcessor) ListProductsByCategory(ctx context.Context, category
 }
 
 // ListProductsByPriceRange obtiene productos en un rango de precios
-func (p *ProductProcessor) ListProductsByPriceRange(ctx context.Context, minPrice, maxPrice float64, limit, offset int) ([]*entities.Product, error) {
+func (p *ProductProcessor) ListProductsByPriceRange(ctx context.Context, minPrice, maxPrice entities.Money, limit, offset int) ([]*entities.Product, error) {
 	return p.productRepo.FindByPriceRange(ctx, minPrice, maxPrice, limit, offset)
-}
\ No newline at end of file
+}
+
+// ListMatchingProducts obtiene los productos que cumplen una especificación
+func (p *ProductProcessor) ListMatchingProducts(ctx context.Context, spec repositories.Specification, limit, offset int) ([]*entities.Product, error) {
+	return p.productRepo.FindMatching(ctx, spec, limit, offset)
+}
+
+// SearchText busca productos por texto en el índice de búsqueda
+// Los productos se leen del repositorio; los que el índice todavía no sabe que se
+// eliminaron se omiten
+func (p *ProductProcessor) SearchText(ctx context.Context, query string, limit int) ([]ProductSearchResult, error) {
+	if p.searchIndex == nil {
+		return nil, repositories.ErrSearchUnavailable
+	}
+	hits, err := p.searchIndex.Search(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ProductSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		product, err := p.productRepo.FindByID(ctx, hit.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if product != nil {
+			results = append(results, ProductSearchResult{Product: product, Score: hit.Score})
+		}
+	}
+	return results, nil
+}
+
+// ListProductsPage obtiene una página de los productos que cumplen una especificación
+func (p *ProductProcessor) ListProductsPage(ctx context.Context, spec repositories.Specification, request repositories.PageRequest) (repositories.Page[*entities.Product], error) {
+	return p.productRepo.FindPage(ctx, spec, request)
+}
+
+// update lee el producto, aplica la modificación y lo guarda
+// Si otro proceso lo guardó entretanto, el repositorio rechaza la versión obsoleta
+// y la operación completa se reintenta sobre el estado actual
+func (p *ProductProcessor) update(ctx context.Context, id string, mutate func(*entities.Product) error) (*entities.Product, error) {
+	var product *entities.Product
+	err := retryOnConflict(p.conflictRetries, repositories.ErrProductVersionConflict, func() error {
+		current, err := p.productRepo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return repositories.ErrProductNotFound
+		}
+
+		if err := mutate(current); err != nil {
+			return err
+		}
+
+		if err := p.productRepo.Save(ctx, current); err != nil {
+			return err
+		}
+		product = current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// hasExpiredReservations indica si el producto tiene reservas expiradas sin limpiar
+func hasExpiredReservations(product *entities.Product, now time.Time) bool {
+	for _, reservation := range product.Reservations {
+		if reservation.IsExpired(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// newReservationID genera un identificador aleatorio para una reserva
+func newReservationID() (string, error) {
+	var buf [12]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return "res_" + hex.EncodeToString(buf[:]), nil
+}
@@ -3,6 +3,17 @@ package services
 import (
 	"context"
 	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+	"log"
+	"time"
+)
+
+const (
+	// DefaultReservationTTL es la duración de una reserva cuando el adaptador no indica otra
+	DefaultReservationTTL = 15 * time.Minute
+
+	// MaxReservationTTL es la duración máxima permitida para una reserva
+	MaxReservationTTL = 24 * time.Hour
 )
 
 // ProductService es el servicio principal que orquesta los servicios granulares de productos
@@ -10,6 +21,9 @@ type ProductService struct {
 	validator  *ProductValidator
 	processor  *ProductProcessor
 	publisher  *ProductEventPublisher
+
+	// onPublishError recibe los errores al publicar eventos
+	onPublishError PublishErrorHandler
 }
 
 // NewProductService crea una nueva instancia del servicio de producto
@@ -18,47 +32,52 @@ func NewProductService(validator *ProductValidator, processor *ProductProcessor,
 		validator: validator,
 		processor: processor,
 		publisher: publisher,
+		onPublishError: LogPublishError,
 	}
 }
 
+// WithPublishErrorHandler configura qué se hace con los errores al publicar eventos
+// Por defecto se registran con LogPublishError
+func (s *ProductService) WithPublishErrorHandler(handler PublishErrorHandler) *ProductService {
+	s.onPublishError = handler
+	s.publisher.onPublishError = handler
+	return s
+}
+
 // CreateProduct crea un nuevo producto con validación, procesamiento y publicación de eventos
-func (s *ProductService) CreateProduct(ctx context.Context, id, name, description, category string, price float64, stock int) (*entities.Product, error) {
+func (s *ProductService) CreateProduct(ctx context.Context, id, name, description, category string, price entities.Money, stock int) (*entities.Product, error) {
 	// 1. Validar los datos de entrada
 	if err := s.validator.ValidateCreateProduct(id, name, description, category, price, stock); err != nil {
 		return nil, err
 	}
 
 	// 2. Procesar la creación del producto
-	product, err := s.processor.CreateProduct(ctx, id, name, description, category, price, stock)
+	product, err := s.processor.CreateProduct(s.publisher.Record(ctx), id, name, description, category, price, stock)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Publicar evento de producto creado
-	if err := s.publisher.PublishProductCreated(ctx, product); err != nil {
-		// log.Printf("Failed to publish product created event: %v", err)
-	}
+	// 3. Publicar los eventos registrados por el producto (producto creado)
+	s.publishEvents(ctx, product)
 
 	return product, nil
 }
 
 // UpdateProduct actualiza un producto existente
-func (s *ProductService) UpdateProduct(ctx context.Context, id string, name, description, category *string, price *float64, stock *int) (*entities.Product, error) {
+func (s *ProductService) UpdateProduct(ctx context.Context, id string, name, description, category *string, price *entities.Money, stock *int) (*entities.Product, error) {
 	// 1. Validar los datos de entrada
 	if err := s.validator.ValidateUpdateProduct(id, name, description, category, price, stock); err != nil {
 		return nil, err
 	}
 
 	// 2. Procesar la actualización del producto
-	product, err := s.processor.UpdateProduct(ctx, id, name, description, category, price, stock)
+	product, err := s.processor.UpdateProduct(s.publisher.Record(ctx), id, name, description, category, price, stock)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Publicar evento de producto actualizado
-	if err := s.publisher.PublishProductUpdated(ctx, product); err != nil {
-		// log.Printf("Failed to publish product updated event: %v", err)
-	}
+	// 3. Publicar los eventos registrados por el producto (actualizado y, si cambió, stock)
+	s.publishEvents(ctx, product)
 
 	return product, nil
 }
@@ -70,85 +89,152 @@ func (s *ProductService) UpdateStock(ctx context.Context, id string, newStock in
 		return nil, err
 	}
 
-	// 2. Obtener el stock anterior para el evento
-	oldProduct, err := s.processor.GetProduct(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-	oldStock := oldProduct.Stock
-
-	// 3. Procesar la actualización del stock
-	product, err := s.processor.UpdateStock(ctx, id, newStock)
+	// 2. Procesar la actualización del stock
+	product, err := s.processor.UpdateStock(s.publisher.Record(ctx), id, newStock)
 	if err != nil {
 		return nil, err
 	}
 
-	// 4. Publicar evento de stock actualizado
-	if err := s.publisher.PublishStockUpdated(ctx, product, oldStock); err != nil {
-		// log.Printf("Failed to publish stock updated event: %v", err)
-	}
+	// 3. Publicar los eventos registrados por el producto (stock actualizado)
+	s.publishEvents(ctx, product)
 
 	return product, nil
 }
 
 // AddStock añade stock a un producto
 func (s *ProductService) AddStock(ctx context.Context, id string, quantity int) (*entities.Product, error) {
-	// 1. Obtener el producto actual
-	product, err := s.processor.GetProduct(ctx, id)
-	if err != nil {
+	// 1. Validar la cantidad
+	if err := s.validator.ValidateStockQuantity(id, quantity); err != nil {
 		return nil, err
 	}
-	oldStock := product.Stock
 
 	// 2. Procesar la adición de stock
-	product, err = s.processor.AddStock(ctx, id, quantity)
+	product, err := s.processor.AddStock(s.publisher.Record(ctx), id, quantity)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Publicar evento de stock actualizado
-	if err := s.publisher.PublishStockUpdated(ctx, product, oldStock); err != nil {
-		// log.Printf("Failed to publish stock updated event: %v", err)
-	}
+	// 3. Publicar los eventos registrados por el producto (stock actualizado)
+	s.publishEvents(ctx, product)
 
 	return product, nil
 }
 
 // RemoveStock reduce el stock de un producto
 func (s *ProductService) RemoveStock(ctx context.Context, id string, quantity int) (*entities.Product, error) {
-	// 1. Obtener el producto actual
-	product, err := s.processor.GetProduct(ctx, id)
-	if err != nil {
+	// 1. Validar la cantidad
+	if err := s.validator.ValidateStockQuantity(id, quantity); err != nil {
 		return nil, err
 	}
-	oldStock := product.Stock
 
 	// 2. Procesar la reducción de stock
-	product, err = s.processor.RemoveStock(ctx, id, quantity)
+	product, err := s.processor.RemoveStock(s.publisher.Record(ctx), id, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Publicar los eventos registrados por el producto (stock actualizado)
+	s.publishEvents(ctx, product)
+
+	return product, nil
+}
+
+// Reserve reserva stock de un producto durante ttl
+// El stock reservado deja de estar disponible hasta que se confirme, se libere o expire
+func (s *ProductService) Reserve(ctx context.Context, productID string, quantity int, ttl time.Duration) (*entities.StockReservation, error) {
+	// 1. Validar los datos de la reserva
+	if err := s.validator.ValidateReservation(productID, quantity, ttl); err != nil {
+		return nil, err
+	}
+
+	// 2. Procesar la reserva de forma atómica
+	reservation, product, err := s.processor.Reserve(s.publisher.Record(ctx), productID, quantity, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Publicar los eventos registrados por el producto (cambia el stock disponible)
+	s.publishEvents(ctx, product)
+
+	return reservation, nil
+}
+
+// Confirm confirma una reserva descontando definitivamente su stock
+func (s *ProductService) Confirm(ctx context.Context, productID, reservationID string) (*entities.Product, error) {
+	// 1. Validar los identificadores
+	if err := s.validator.ValidateReservationID(productID, reservationID); err != nil {
+		return nil, err
+	}
+
+	// 2. Procesar la confirmación
+	product, err := s.processor.ConfirmReservation(s.publisher.Record(ctx), productID, reservationID)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Publicar evento de stock actualizado
-	if err := s.publisher.PublishStockUpdated(ctx, product, oldStock); err != nil {
-		// log.Printf("Failed to publish stock updated event: %v", err)
+	// 3. Publicar los eventos registrados por el producto (stock actualizado)
+	s.publishEvents(ctx, product)
+
+	return product, nil
+}
+
+// Release libera una reserva devolviendo su stock al disponible
+func (s *ProductService) Release(ctx context.Context, productID, reservationID string) (*entities.Product, error) {
+	// 1. Validar los identificadores
+	if err := s.validator.ValidateReservationID(productID, reservationID); err != nil {
+		return nil, err
+	}
+
+	// 2. Procesar la liberación
+	product, err := s.processor.ReleaseReservation(s.publisher.Record(ctx), productID, reservationID)
+	if err != nil {
+		return nil, err
 	}
 
+	// 3. Publicar los eventos registrados por el producto (stock actualizado)
+	s.publishEvents(ctx, product)
+
 	return product, nil
 }
 
+// ReleaseExpiredReservations devuelve al disponible el stock de las reservas expiradas
+// Retorna el número de productos afectados
+func (s *ProductService) ReleaseExpiredReservations(ctx context.Context) (int, error) {
+	products, err := s.processor.ReleaseExpiredReservations(s.publisher.Record(ctx))
+	for _, product := range products {
+		s.publishEvents(ctx, product)
+	}
+	return len(products), err
+}
+
+// RunReservationExpiry libera periódicamente las reservas expiradas hasta que ctx termine
+// Está pensado para ejecutarse en su propia goroutine junto a los adaptadores de entrada
+func (s *ProductService) RunReservationExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ReleaseExpiredReservations(ctx); err != nil {
+				log.Printf("Failed to release expired reservations: %v", err)
+			}
+		}
+	}
+}
+
 // DeactivateProduct desactiva un producto
 func (s *ProductService) DeactivateProduct(ctx context.Context, id string) (*entities.Product, error) {
 	// 1. Procesar la desactivación del producto
-	product, err := s.processor.DeactivateProduct(ctx, id)
+	product, err := s.processor.DeactivateProduct(s.publisher.Record(ctx), id)
 	if err != nil {
 		return nil, err
 	}
 
-	// 2. Publicar evento de producto desactivado
-	if err := s.publisher.PublishProductDeactivated(ctx, product); err != nil {
-		// log.Printf("Failed to publish product deactivated event: %v", err)
-	}
+	// 2. Publicar los eventos registrados por el producto (producto desactivado)
+	s.publishEvents(ctx, product)
 
 	return product, nil
 }
@@ -156,19 +242,31 @@ func (s *ProductService) DeactivateProduct(ctx context.Context, id string) (*ent
 // ActivateProduct activa un producto
 func (s *ProductService) ActivateProduct(ctx context.Context, id string) (*entities.Product, error) {
 	// 1. Procesar la activación del producto
-	product, err := s.processor.ActivateProduct(ctx, id)
+	product, err := s.processor.ActivateProduct(s.publisher.Record(ctx), id)
 	if err != nil {
 		return nil, err
 	}
 
-	// 2. Publicar evento de producto activado
-	if err := s.publisher.PublishProductActivated(ctx, product); err != nil {
-		// log.Printf("Failed to publish product activated event: %v", err)
-	}
+	// 2. Publicar los eventos registrados por el producto (producto activado)
+	s.publishEvents(ctx, product)
 
 	return product, nil
 }
 
+// DeleteProduct elimina un producto
+func (s *ProductService) DeleteProduct(ctx context.Context, id string) error {
+	// 1. Procesar la eliminación del producto
+	product, err := s.processor.DeleteProduct(s.publisher.Record(ctx), id)
+	if err != nil {
+		return err
+	}
+
+	// 2. Publicar los eventos registrados por el producto (producto eliminado)
+	s.publishEvents(ctx, product)
+
+	return nil
+}
+
 // GetProduct obtiene un producto por ID
 func (s *ProductService) GetProduct(ctx context.Context, id string) (*entities.Product, error) {
 	return s.processor.GetProduct(ctx, id)
@@ -190,6 +288,32 @@ func (s *ProductService) ListProductsByCategory(ctx context.Context, category st
 }
 
 // ListProductsByPriceRange obtiene productos en un rango de precios
-func (s *ProductService) ListProductsByPriceRange(ctx context.Context, minPrice, maxPrice float64, limit, offset int) ([]*entities.Product, error) {
+func (s *ProductService) ListProductsByPriceRange(ctx context.Context, minPrice, maxPrice entities.Money, limit, offset int) ([]*entities.Product, error) {
 	return s.processor.ListProductsByPriceRange(ctx, minPrice, maxPrice, limit, offset)
+}
+
+// ListMatchingProducts obtiene los productos que cumplen una especificación
+func (s *ProductService) ListMatchingProducts(ctx context.Context, spec repositories.Specification, limit, offset int) ([]*entities.Product, error) {
+	return s.processor.ListMatchingProducts(ctx, spec, limit, offset)
+}
+
+// SearchText busca productos cuyo nombre, descripción o categoría contienen el texto
+// Ignora mayúsculas y acentos, admite prefijos y erratas y ordena los resultados
+// por relevancia. Retorna repositories.ErrSearchUnavailable si no hay índice
+func (s *ProductService) SearchText(ctx context.Context, query string, limit int) ([]ProductSearchResult, error) {
+	return s.processor.SearchText(ctx, query, limit)
+}
+
+// ListProductsPage obtiene una página de los productos que cumplen una especificación
+// La página siguiente se pide con el NextCursor de la anterior y el mismo orden
+func (s *ProductService) ListProductsPage(ctx context.Context, spec repositories.Specification, request repositories.PageRequest) (repositories.Page[*entities.Product], error) {
+	return s.processor.ListProductsPage(ctx, spec, request)
+}
+
+// publishEvents retira los eventos de dominio del producto ya guardado y los publica
+// Los errores de publicación no deshacen la operación: se entregan a onPublishError
+func (s *ProductService) publishEvents(ctx context.Context, product *entities.Product) {
+	if err := s.publisher.PublishEvents(ctx, product, product.PullEvents()); err != nil {
+		s.onPublishError(ctx, err)
+	}
 }
\ No newline at end of file
@@ -1,8 +1,10 @@
 package services
 
 import (
-	"errors"
 	"strings"
+	"time"
+
+	"hexagonal-example/domain/entities"
 )
 
 // ProductValidator se encarga únicamente de la validación de datos de producto
@@ -14,7 +16,7 @@ func NewProductValidator() *ProductValidator {
 }
 
 // ValidateCreateProduct valida los datos para crear un nuevo producto
-func (v *ProductValidator) ValidateCreateProduct(id, name, description, category string, price float64, stock int) error {
+func (v *ProductValidator) ValidateCreateProduct(id, name, description, category string, price entities.Money, stock int) error {
 	// Validar ID
 	if err := v.validateID(id); err != nil {
 		return err
@@ -49,7 +51,7 @@ func (v *ProductValidator) ValidateCreateProduct(id, name, description, category
 }
 
 // ValidateUpdateProduct valida los datos para actualizar un producto
-func (v *ProductValidator) ValidateUpdateProduct(id string, name, description, category *string, price *float64, stock *int) error {
+func (v *ProductValidator) ValidateUpdateProduct(id string, name, description, category *string, price *entities.Money, stock *int) error {
 	// El ID siempre debe ser válido
 	if err := v.validateID(id); err != nil {
 		return err
@@ -89,16 +91,55 @@ func (v *ProductValidator) ValidateUpdateProduct(id string, name, description, c
 	return nil
 }
 
+// ValidateStockQuantity valida la cantidad para añadir o retirar stock
+func (v *ProductValidator) ValidateStockQuantity(id string, quantity int) error {
+	if err := v.validateID(id); err != nil {
+		return err
+	}
+	if quantity <= 0 {
+		return newValidationError("quantity must be positive")
+	}
+	if quantity > 1000000 {
+		return newValidationError("quantity cannot exceed 1,000,000")
+	}
+	return nil
+}
+
+// ValidateReservation valida los datos para reservar stock
+func (v *ProductValidator) ValidateReservation(id string, quantity int, ttl time.Duration) error {
+	if err := v.ValidateStockQuantity(id, quantity); err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return newValidationError("reservation TTL must be positive")
+	}
+	if ttl > MaxReservationTTL {
+		return newValidationError("reservation TTL cannot exceed 24 hours")
+	}
+	return nil
+}
+
+// ValidateReservationID valida los identificadores de una reserva existente
+func (v *ProductValidator) ValidateReservationID(id, reservationID string) error {
+	if err := v.validateID(id); err != nil {
+		return err
+	}
+	if strings.TrimSpace(reservationID) == "" {
+		return newValidationError("reservation ID cannot be empty")
+	}
+	return nil
+}
+
 // validateID valida el ID del producto
 func (v *ProductValidator) validateID(id string) error {
 	if strings.TrimSpace(id) == "" {
-		return errors.New("product ID cannot be empty")
+		return newValidationError("product ID cannot be empty")
 	}
 	if len(id) < 3 {
-		return errors.New("product ID must be at least 3 characters long")
+		return newValidationError("product ID must be at least 3 characters long")
 	}
 	if len(id) > 50 {
-		return errors.New("product ID cannot exceed 50 characters")
+		return newValidationError("product ID cannot exceed 50 characters")
 	}
 	return nil
 }
@@ -106,13 +147,13 @@ func (v *ProductValidator) validateID(id string) error {
 // validateName valida el nombre del producto
 func (v *ProductValidator) validateName(name string) error {
 	if strings.TrimSpace(name) == "" {
-		return errors.New("product name cannot be empty")
+		return newValidationError("product name cannot be empty")
 	}
 	if len(name) < 2 {
-		return errors.New("product name must be at least 2 characters long")
+		return newValidationError("product name must be at least 2 characters long")
 	}
 	if len(name) > 200 {
-		return errors.New("product name cannot exceed 200 characters")
+		return newValidationError("product name cannot exceed 200 characters")
 	}
 	return nil
 }
@@ -120,7 +161,7 @@ func (v *ProductValidator) validateName(name string) error {
 // validateDescription valida la descripción del producto
 func (v *ProductValidator) validateDescription(description string) error {
 	if len(description) > 1000 {
-		return errors.New("product description cannot exceed 1000 characters")
+		return newValidationError("product description cannot exceed 1000 characters")
 	}
 	return nil
 }
@@ -128,24 +169,24 @@ func (v *ProductValidator) validateDescription(description string) error {
 // validateCategory valida la categoría del producto
 func (v *ProductValidator) validateCategory(category string) error {
 	if strings.TrimSpace(category) == "" {
-		return errors.New("product category cannot be empty")
+		return newValidationError("product category cannot be empty")
 	}
 	if len(category) < 2 {
-		return errors.New("product category must be at least 2 characters long")
+		return newValidationError("product category must be at least 2 characters long")
 	}
 	if len(category) > 100 {
-		return errors.New("product category cannot exceed 100 characters")
+		return newValidationError("product category cannot exceed 100 characters")
 	}
 	return nil
 }
 
 // validatePrice valida el precio del producto
-func (v *ProductValidator) validatePrice(price float64) error {
-	if price < 0 {
-		return errors.New("product price cannot be negative")
+func (v *ProductValidator) validatePrice(price entities.Money) error {
+	if price.IsNegative() {
+		return newValidationError("product price cannot be negative")
 	}
-	if price > 1000000 {
-		return errors.New("product price cannot exceed 1,000,000")
+	if exceeds, _ := price.Compare(maxProductPrice(price.Currency())); exceeds > 0 {
+		return newValidationError("product price cannot exceed 1,000,000")
 	}
 	return nil
 }
@@ -153,10 +194,15 @@ func (v *ProductValidator) validatePrice(price float64) error {
 // validateStock valida el stock del producto
 func (v *ProductValidator) validateStock(stock int) error {
 	if stock < 0 {
-		return errors.New("product stock cannot be negative")
+		return newValidationError("product stock cannot be negative")
 	}
 	if stock > 1000000 {
-		return errors.New("product stock cannot exceed 1,000,000")
+		return newValidationError("product stock cannot exceed 1,000,000")
 	}
 	return nil
-}
\ No newline at end of file
+}
+// maxProductPrice retorna el precio máximo permitido (1.000.000 unidades) en la moneda dada
+func maxProductPrice(currency string) entities.Money {
+	price, _ := entities.MoneyFromMajor(1000000, currency)
+	return price
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"hexagonal-example/domain/repositories"
+	"hexagonal-example/infrastructure/events"
+)
+
+// PublishErrorHandler recibe los errores al publicar un evento después de que la
+// operación ya se completó: la operación no se deshace, pero el error no se pierde
+type PublishErrorHandler func(ctx context.Context, err error)
+
+// LogPublishError es el PublishErrorHandler por defecto: registra el error en el log
+func LogPublishError(ctx context.Context, err error) {
+	log.Printf("failed to publish event: %v", err)
+}
+
+// publishEvent publica un evento en eventBus
+// Dentro de una transacción (ver repositories.UnitOfWork) la publicación se aplaza
+// hasta el commit y no ocurre si la transacción se deshace; como para entonces la
+// operación ya retornó, los errores de esa publicación se entregan a onError
+func publishEvent(ctx context.Context, eventBus events.EventBus, event events.Event, onError PublishErrorHandler) error {
+	deferred := repositories.AfterCommit(ctx, func(ctx context.Context) {
+		if err := events.Publish(ctx, eventBus, event); err != nil {
+			onError(ctx, err)
+		}
+	})
+	if deferred {
+		return nil
+	}
+	return events.Publish(ctx, eventBus, event)
+}
@@ -0,0 +1,16 @@
+package services
+
+import "errors"
+
+// retryOnConflict ejecuta la operación y la reintenta mientras el repositorio
+// reporte un conflicto de versión, hasta un máximo de retries reintentos
+// La operación debe releer la entidad en cada intento para partir del estado actual
+// Si se agotan los reintentos se retorna el error de conflicto al llamador
+func retryOnConflict(retries int, conflict error, operation func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := operation()
+		if err == nil || !errors.Is(err, conflict) || attempt >= retries {
+			return err
+		}
+	}
+}
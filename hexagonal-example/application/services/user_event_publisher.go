@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"hexagonal-example/domain/entities"
 	"hexagonal-example/infrastructure/events"
 )
@@ -11,57 +12,64 @@ import (
 // afectado por problemas en la publicación de eventos
 type UserEventPublisher struct {
 	eventBus events.EventBus
+
+	// onPublishError recibe los errores de las publicaciones aplazadas hasta el commit
+	onPublishError PublishErrorHandler
 }
 
 // NewUserEventPublisher crea una nueva instancia del publicador de eventos de usuario
 func NewUserEventPublisher(eventBus events.EventBus) *UserEventPublisher {
 	return &UserEventPublisher{
-		eventBus: eventBus,
-	}
-}
-
-// PublishUserCreated publica un evento cuando se crea un usuario
-func (p *UserEventPublisher) PublishUserCreated(ctx context.Context, user *entities.User) error {
-	event := events.UserCreatedEvent{
-		UserID:    user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
+		eventBus:       eventBus,
+		onPublishError: LogPublishError,
 	}
-
-	return p.eventBus.Publish(ctx, "user.created", event)
 }
 
-// PublishUserUpdated publica un evento cuando se actualiza un usuario
-func (p *UserEventPublisher) PublishUserUpdated(ctx context.Context, user *entities.User) error {
-	event := events.UserUpdatedEvent{
-		UserID:    user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		UpdatedAt: user.UpdatedAt,
+// PublishEvents publica los eventos de dominio que registró el usuario, una vez guardado
+func (p *UserEventPublisher) PublishEvents(ctx context.Context, user *entities.User, domainEvents []entities.DomainEvent) error {
+	var errs []error
+	for _, domainEvent := range domainEvents {
+		if err := publishEvent(ctx, p.eventBus, userEvent(user, domainEvent), p.onPublishError); err != nil {
+			errs = append(errs, err)
+		}
 	}
-
-	return p.eventBus.Publish(ctx, "user.updated", event)
+	return errors.Join(errs...)
 }
 
-// PublishUserDeactivated publica un evento cuando se desactiva un usuario
-func (p *UserEventPublisher) PublishUserDeactivated(ctx context.Context, user *entities.User) error {
-	event := events.UserDeactivatedEvent{
-		UserID:    user.ID,
-		Email:     user.Email,
-		DeactivatedAt: user.UpdatedAt,
+// userEvent construye el evento de integración de un evento de dominio del usuario
+func userEvent(user *entities.User, domainEvent entities.DomainEvent) events.Event {
+	switch e := domainEvent.(type) {
+	case entities.UserCreated:
+		return events.UserCreatedEvent{
+			UserID:    user.ID,
+			Email:     user.Email,
+			Name:      user.Name,
+			CreatedAt: user.CreatedAt,
+		}
+	case entities.UserDeactivated:
+		return events.UserDeactivatedEvent{
+			UserID:        user.ID,
+			Email:         user.Email,
+			DeactivatedAt: user.UpdatedAt,
+		}
+	case entities.UserActivated:
+		return events.UserActivatedEvent{
+			UserID:      user.ID,
+			Email:       user.Email,
+			ActivatedAt: user.UpdatedAt,
+		}
+	case entities.UserDeleted:
+		return events.UserDeletedEvent{
+			UserID:    user.ID,
+			Email:     user.Email,
+			DeletedAt: e.DeletedAt,
+		}
+	default:
+		return events.UserUpdatedEvent{
+			UserID:    user.ID,
+			Email:     user.Email,
+			Name:      user.Name,
+			UpdatedAt: user.UpdatedAt,
+		}
 	}
-
-	return p.eventBus.Publish(ctx, "user.deactivated", event)
-}
-
-// PublishUserActivated publica un evento cuando se activa un usuario
-func (p *UserEventPublisher) PublishUserActivated(ctx context.Context, user *entities.User) error {
-	event := events.UserActivatedEvent{
-		UserID:    user.ID,
-		Email:     user.Email,
-		ActivatedAt: user.UpdatedAt,
-	}
-
-	return p.eventBus.Publish(ctx, "user.activated", event)
 }
\ No newline at end of file
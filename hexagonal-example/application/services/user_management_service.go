@@ -13,6 +13,9 @@ import (
 type UserManagementService struct {
 	userService *UserService
 	userRepo    repositories.UserRepository
+
+	// uow hace atómicas las operaciones en lote; sin él cada elemento se guarda por separado
+	uow repositories.UnitOfWork
 }
 
 // NewUserManagementService crea una nueva instancia del servicio de gestión de usuarios
@@ -23,44 +26,25 @@ func NewUserManagementService(userService *UserService, userRepo repositories.Us
 	}
 }
 
+// WithUnitOfWork hace que las operaciones en lote se ejecuten en una transacción de uow
+// El repositorio de usuarios debe pertenecer al mismo adaptador que uow
+func (s *UserManagementService) WithUnitOfWork(uow repositories.UnitOfWork) *UserManagementService {
+	s.uow = uow
+	return s
+}
+
 // BulkCreateUsers crea múltiples usuarios en una operación
+// Con WithUnitOfWork, si algún usuario falla no se crea ninguno
 func (s *UserManagementService) BulkCreateUsers(ctx context.Context, users []CreateUserRequest) ([]*entities.User, []error) {
-	var createdUsers []*entities.User
-	var errors []error
-
-	for i, req := range users {
-		user, err := s.userService.CreateUser(ctx, req.ID, req.Email, req.Name)
-		if err != nil {
-			errors = append(errors, &BulkOperationError{
-				Index:   i,
-				Message: err.Error(),
-			})
-		} else {
-			createdUsers = append(createdUsers, user)
-		}
-	}
-
-	return createdUsers, errors
+	return runBulk(ctx, s.uow, users, func(ctx context.Context, req CreateUserRequest) (*entities.User, error) {
+		return s.userService.CreateUser(ctx, req.ID, req.Email, req.Name)
+	})
 }
 
 // BulkDeactivateUsers desactiva múltiples usuarios
+// Con WithUnitOfWork, si alguna desactivación falla no se aplica ninguna
 func (s *UserManagementService) BulkDeactivateUsers(ctx context.Context, userIDs []string) ([]*entities.User, []error) {
-	var deactivatedUsers []*entities.User
-	var errors []error
-
-	for i, userID := range userIDs {
-		user, err := s.userService.DeactivateUser(ctx, userID)
-		if err != nil {
-			errors = append(errors, &BulkOperationError{
-				Index:   i,
-				Message: err.Error(),
-			})
-		} else {
-			deactivatedUsers = append(deactivatedUsers, user)
-		}
-	}
-
-	return deactivatedUsers, errors
+	return runBulk(ctx, s.uow, userIDs, s.userService.DeactivateUser)
 }
 
 // GetUserStatistics obtiene estadísticas de usuarios
@@ -87,20 +71,10 @@ func (s *UserManagementService) GetUserStatistics(ctx context.Context) (*UserSta
 	}, nil
 }
 
-// SearchUsers busca usuarios por diferentes criterios
-func (s *UserManagementService) SearchUsers(ctx context.Context, criteria SearchCriteria) ([]*entities.User, error) {
-	// Implementar lógica de búsqueda más compleja
-	// Por simplicidad, aquí solo implementamos búsqueda por email
-	if criteria.Email != "" {
-		user, err := s.userService.GetUserByEmail(ctx, criteria.Email)
-		if err != nil {
-			return nil, err
-		}
-		return []*entities.User{user}, nil
-	}
-
-	// Si no hay criterios específicos, retornar todos los usuarios
-	return s.userService.ListUsers(ctx, criteria.Limit, criteria.Offset)
+// SearchUsers busca los usuarios que cumplen a la vez todos los criterios indicados
+// Sin criterios retorna todos los usuarios, página a página
+func (s *UserManagementService) SearchUsers(ctx context.Context, criteria SearchCriteria) (repositories.Page[*entities.User], error) {
+	return s.userService.ListUsersPage(ctx, criteria.specification(), criteria.page())
 }
 
 // CreateUserRequest representa una solicitud para crear un usuario
@@ -112,16 +86,54 @@ type CreateUserRequest struct {
 
 // UserStatistics contiene estadísticas de usuarios
 type UserStatistics struct {
-	TotalUsers    int
-	ActiveUsers   int
-	InactiveUsers int
+	TotalUsers    int `json:"total_users"`
+	ActiveUsers   int `json:"active_users"`
+	InactiveUsers int `json:"inactive_users"`
 }
 
 // SearchCriteria define criterios de búsqueda para usuarios
+// Los criterios con su valor cero no filtran, y los indicados se combinan con AND
 type SearchCriteria struct {
-	Email  string
-	Limit  int
-	Offset int
+	// Email busca el usuario con ese email, sin distinguir mayúsculas
+	Email string
+
+	// Name busca el texto dentro del nombre, sin distinguir mayúsculas
+	Name string
+
+	// Active filtra por estado de activación si no es nil
+	Active *bool
+
+	// Where es una especificación adicional que se combina con los criterios anteriores
+	Where repositories.Specification
+
+	// Sort es el orden de los resultados; el valor cero ordena por ID
+	Sort repositories.SortOrder
+
+	// Limit es el tamaño de la página; con 0 se retornan todos los resultados
+	Limit int
+
+	// Cursor es el NextCursor de la página anterior, o vacío para la primera
+	Cursor string
+}
+
+// page retorna la petición de página de la búsqueda
+func (c SearchCriteria) page() repositories.PageRequest {
+	return repositories.PageRequest{Sort: c.Sort, Limit: c.Limit, Cursor: c.Cursor}
+}
+
+// specification combina los criterios de búsqueda en una especificación
+func (c SearchCriteria) specification() repositories.Specification {
+	specs := []repositories.Specification{c.Where}
+	if c.Email != "" {
+		specs = append(specs, repositories.Where(repositories.FieldEmail, repositories.OpEqual, entities.NormalizeEmail(c.Email)))
+	}
+	if c.Name != "" {
+		specs = append(specs, repositories.NameContains(c.Name))
+	}
+	if c.Active != nil {
+		specs = append(specs, repositories.Where(repositories.FieldActive, repositories.OpEqual, *c.Active))
+	}
+	return repositories.And(specs...)
 }
 
 // BulkOperationError representa un error en una operación en lote
@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"errors"
 	"hexagonal-example/domain/entities"
 	"hexagonal-example/domain/repositories"
 )
@@ -12,6 +11,12 @@ import (
 // sin preocuparse por la validación (que hace UserValidator) ni la publicación de eventos
 type UserProcessor struct {
 	userRepo repositories.UserRepository
+
+	// conflictRetries es el número de reintentos ante conflictos de versión
+	conflictRetries int
+
+	// emailPolicy normaliza los emails antes de guardarlos y buscarlos
+	emailPolicy entities.EmailPolicy
 }
 
 // NewUserProcessor crea una nueva instancia del procesador de usuarios
@@ -21,33 +26,33 @@ func NewUserProcessor(userRepo repositories.UserRepository) *UserProcessor {
 	}
 }
 
-// CreateUser crea un nuevo usuario en el sistema
-func (p *UserProcessor) CreateUser(ctx context.Context, id, email, name string) (*entities.User, error) {
-	// Verificar si el usuario ya existe
-	exists, err := p.userRepo.Exists(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-	if exists {
-		return nil, errors.New("user already exists")
-	}
+// WithConflictRetries configura cuántas veces se reintenta una modificación
+// cuando otro proceso ha guardado el usuario entre la lectura y la escritura
+// Con 0 (valor por defecto) el conflicto se retorna directamente al llamador
+func (p *UserProcessor) WithConflictRetries(retries int) *UserProcessor {
+	p.conflictRetries = retries
+	return p
+}
 
-	// Verificar si el email ya está en uso
-	existingUser, err := p.userRepo.FindByEmail(ctx, email)
-	if err != nil {
-		return nil, err
-	}
-	if existingUser != nil {
-		return nil, errors.New("email already in use")
-	}
+// WithEmailPolicy configura cómo se normalizan los emails
+// Por defecto solo se ignoran los espacios y las mayúsculas
+func (p *UserProcessor) WithEmailPolicy(policy entities.EmailPolicy) *UserProcessor {
+	p.emailPolicy = policy
+	return p
+}
 
+// CreateUser crea un nuevo usuario en el sistema
+func (p *UserProcessor) CreateUser(ctx context.Context, id, email, name string) (*entities.User, error) {
 	// Crear la entidad de usuario
-	user, err := entities.NewUser(id, email, name)
+	user, err := entities.NewUser(id, p.emailPolicy.Normalize(email), name)
 	if err != nil {
 		return nil, err
 	}
 
 	// Guardar en el repositorio
+	// Save rechaza de forma atómica un ID existente (ErrUserAlreadyExists) o un
+	// email en uso (ErrEmailAlreadyInUse); comprobarlo antes dejaría una carrera
+	// entre la comprobación y la escritura
 	if err := p.userRepo.Save(ctx, user); err != nil {
 		return nil, err
 	}
@@ -57,76 +62,53 @@ func (p *UserProcessor) CreateUser(ctx context.Context, id, email, name string)
 
 // UpdateUser actualiza un usuario existente
 func (p *UserProcessor) UpdateUser(ctx context.Context, id string, email, name *string) (*entities.User, error) {
-	// Buscar el usuario existente
-	user, err := p.userRepo.FindByID(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-	if user == nil {
-		return nil, errors.New("user not found")
-	}
-
-	// Actualizar email si se proporciona
-	if email != nil {
-		// Verificar si el nuevo email ya está en uso por otro usuario
-		existingUser, err := p.userRepo.FindByEmail(ctx, *email)
-		if err != nil {
-			return nil, err
-		}
-		if existingUser != nil && existingUser.ID != id {
-			return nil, errors.New("email already in use by another user")
+	return p.update(ctx, id, func(user *entities.User) error {
+		// Actualizar email si se proporciona
+		// Si otro usuario lo tiene, Save retorna ErrEmailAlreadyInUse
+		if email != nil {
+			if err := user.UpdateEmail(p.emailPolicy.Normalize(*email)); err != nil {
+				return err
+			}
 		}
 
-		if err := user.UpdateEmail(*email); err != nil {
-			return nil, err
+		// Actualizar nombre si se proporciona
+		if name != nil {
+			if err := user.UpdateName(*name); err != nil {
+				return err
+			}
 		}
-	}
-
-	// Actualizar nombre si se proporciona
-	if name != nil {
-		if err := user.UpdateName(*name); err != nil {
-			return nil, err
-		}
-	}
-
-	// Guardar los cambios
-	if err := p.userRepo.Save(ctx, user); err != nil {
-		return nil, err
-	}
-
-	return user, nil
+		return nil
+	})
 }
 
 // DeactivateUser desactiva un usuario
 func (p *UserProcessor) DeactivateUser(ctx context.Context, id string) (*entities.User, error) {
-	user, err := p.userRepo.FindByID(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-	if user == nil {
-		return nil, errors.New("user not found")
-	}
-
-	user.Deactivate()
-	if err := p.userRepo.Save(ctx, user); err != nil {
-		return nil, err
-	}
-
-	return user, nil
+	return p.update(ctx, id, func(user *entities.User) error {
+		user.Deactivate()
+		return nil
+	})
 }
 
 // ActivateUser activa un usuario
 func (p *UserProcessor) ActivateUser(ctx context.Context, id string) (*entities.User, error) {
+	return p.update(ctx, id, func(user *entities.User) error {
+		user.Activate()
+		return nil
+	})
+}
+
+// DeleteUser elimina un usuario del sistema
+func (p *UserProcessor) DeleteUser(ctx context.Context, id string) (*entities.User, error) {
 	user, err := p.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, repositories.ErrUserNotFound
 	}
+	user.MarkDeleted()
 
-	user.Activate()
-	if err := p.userRepo.Save(ctx, user); err != nil {
+	if err := p.userRepo.Delete(ctx, id); err != nil {
 		return nil, err
 	}
 
@@ -140,7 +122,7 @@ func (p *UserProcessor) GetUser(ctx context.Context, id string) (*entities.User,
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, repositories.ErrUserNotFound
 	}
 
 	return user, nil
@@ -148,12 +130,12 @@ func (p *UserProcessor) GetUser(ctx context.Context, id string) (*entities.User,
 
 // GetUserByEmail obtiene un usuario por email
 func (p *UserProcessor) GetUserByEmail(ctx context.Context, email string) (*entities.User, error) {
-	user, err := p.userRepo.FindByEmail(ctx, email)
+	user, err := p.userRepo.FindByEmail(ctx, p.emailPolicy.Normalize(email))
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, repositories.ErrUserNotFound
 	}
 
 	return user, nil
@@ -167,4 +149,44 @@ func (p *UserProcessor) ListUsers(ctx context.Context, limit, offset int) ([]*en
 // ListActiveUsers obtiene una lista de usuarios activos
 func (p *UserProcessor) ListActiveUsers(ctx context.Context, limit, offset int) ([]*entities.User, error) {
 	return p.userRepo.FindActive(ctx, limit, offset)
+}
+
+// ListMatchingUsers obtiene los usuarios que cumplen una especificación
+func (p *UserProcessor) ListMatchingUsers(ctx context.Context, spec repositories.Specification, limit, offset int) ([]*entities.User, error) {
+	return p.userRepo.FindMatching(ctx, spec, limit, offset)
+}
+
+// ListUsersPage obtiene una página de los usuarios que cumplen una especificación
+func (p *UserProcessor) ListUsersPage(ctx context.Context, spec repositories.Specification, request repositories.PageRequest) (repositories.Page[*entities.User], error) {
+	return p.userRepo.FindPage(ctx, spec, request)
+}
+
+// update lee el usuario, aplica la modificación y lo guarda
+// Si otro proceso lo guardó entretanto, el repositorio rechaza la versión obsoleta
+// y la operación completa se reintenta sobre el estado actual
+func (p *UserProcessor) update(ctx context.Context, id string, mutate func(*entities.User) error) (*entities.User, error) {
+	var user *entities.User
+	err := retryOnConflict(p.conflictRetries, repositories.ErrUserVersionConflict, func() error {
+		current, err := p.userRepo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return repositories.ErrUserNotFound
+		}
+
+		if err := mutate(current); err != nil {
+			return err
+		}
+
+		if err := p.userRepo.Save(ctx, current); err != nil {
+			return err
+		}
+		user = current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
 }
\ No newline at end of file
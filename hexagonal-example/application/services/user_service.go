@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
 )
 
 // UserService es el servicio principal que orquesta los servicios granulares
@@ -12,6 +13,9 @@ type UserService struct {
 	validator  *UserValidator
 	processor  *UserProcessor
 	publisher  *UserEventPublisher
+
+	// onPublishError recibe los errores al publicar eventos
+	onPublishError PublishErrorHandler
 }
 
 // NewUserService crea una nueva instancia del servicio de usuario
@@ -21,9 +25,18 @@ func NewUserService(validator *UserValidator, processor *UserProcessor, publishe
 		validator: validator,
 		processor: processor,
 		publisher: publisher,
+		onPublishError: LogPublishError,
 	}
 }
 
+// WithPublishErrorHandler configura qué se hace con los errores al publicar eventos
+// Por defecto se registran con LogPublishError
+func (s *UserService) WithPublishErrorHandler(handler PublishErrorHandler) *UserService {
+	s.onPublishError = handler
+	s.publisher.onPublishError = handler
+	return s
+}
+
 // CreateUser crea un nuevo usuario con validación, procesamiento y publicación de eventos
 func (s *UserService) CreateUser(ctx context.Context, id, email, name string) (*entities.User, error) {
 	// 1. Validar los datos de entrada
@@ -37,13 +50,8 @@ func (s *UserService) CreateUser(ctx context.Context, id, email, name string) (*
 		return nil, err
 	}
 
-	// 3. Publicar evento de usuario creado
-	if err := s.publisher.PublishUserCreated(ctx, user); err != nil {
-		// Log del error pero no fallar la operación
-		// En un sistema real, podrías querer implementar un mecanismo de retry
-		// o guardar el evento en una cola para procesamiento posterior
-		// log.Printf("Failed to publish user created event: %v", err)
-	}
+	// 3. Publicar los eventos registrados por el usuario (usuario creado)
+	s.publishEvents(ctx, user)
 
 	return user, nil
 }
@@ -61,11 +69,8 @@ func (s *UserService) UpdateUser(ctx context.Context, id string, email, name *st
 		return nil, err
 	}
 
-	// 3. Publicar evento de usuario actualizado
-	if err := s.publisher.PublishUserUpdated(ctx, user); err != nil {
-		// Log del error pero no fallar la operación
-		// log.Printf("Failed to publish user updated event: %v", err)
-	}
+	// 3. Publicar los eventos registrados por el usuario (usuario actualizado)
+	s.publishEvents(ctx, user)
 
 	return user, nil
 }
@@ -78,10 +83,8 @@ func (s *UserService) DeactivateUser(ctx context.Context, id string) (*entities.
 		return nil, err
 	}
 
-	// 2. Publicar evento de usuario desactivado
-	if err := s.publisher.PublishUserDeactivated(ctx, user); err != nil {
-		// log.Printf("Failed to publish user deactivated event: %v", err)
-	}
+	// 2. Publicar los eventos registrados por el usuario (usuario desactivado)
+	s.publishEvents(ctx, user)
 
 	return user, nil
 }
@@ -94,14 +97,26 @@ func (s *UserService) ActivateUser(ctx context.Context, id string) (*entities.Us
 		return nil, err
 	}
 
-	// 2. Publicar evento de usuario activado
-	if err := s.publisher.PublishUserActivated(ctx, user); err != nil {
-		// log.Printf("Failed to publish user activated event: %v", err)
-	}
+	// 2. Publicar los eventos registrados por el usuario (usuario activado)
+	s.publishEvents(ctx, user)
 
 	return user, nil
 }
 
+// DeleteUser elimina un usuario
+func (s *UserService) DeleteUser(ctx context.Context, id string) error {
+	// 1. Procesar la eliminación del usuario
+	user, err := s.processor.DeleteUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// 2. Publicar los eventos registrados por el usuario (usuario eliminado)
+	s.publishEvents(ctx, user)
+
+	return nil
+}
+
 // GetUser obtiene un usuario por ID
 func (s *UserService) GetUser(ctx context.Context, id string) (*entities.User, error) {
 	return s.processor.GetUser(ctx, id)
@@ -120,4 +135,23 @@ func (s *UserService) ListUsers(ctx context.Context, limit, offset int) ([]*enti
 // ListActiveUsers obtiene una lista de usuarios activos
 func (s *UserService) ListActiveUsers(ctx context.Context, limit, offset int) ([]*entities.User, error) {
 	return s.processor.ListActiveUsers(ctx, limit, offset)
+}
+
+// ListMatchingUsers obtiene los usuarios que cumplen una especificación
+func (s *UserService) ListMatchingUsers(ctx context.Context, spec repositories.Specification, limit, offset int) ([]*entities.User, error) {
+	return s.processor.ListMatchingUsers(ctx, spec, limit, offset)
+}
+
+// ListUsersPage obtiene una página de los usuarios que cumplen una especificación
+// La página siguiente se pide con el NextCursor de la anterior y el mismo orden
+func (s *UserService) ListUsersPage(ctx context.Context, spec repositories.Specification, request repositories.PageRequest) (repositories.Page[*entities.User], error) {
+	return s.processor.ListUsersPage(ctx, spec, request)
+}
+
+// publishEvents retira los eventos de dominio del usuario ya guardado y los publica
+// Los errores de publicación no deshacen la operación: se entregan a onPublishError
+func (s *UserService) publishEvents(ctx context.Context, user *entities.User) {
+	if err := s.publisher.PublishEvents(ctx, user, user.PullEvents()); err != nil {
+		s.onPublishError(ctx, err)
+	}
 }
\ No newline at end of file
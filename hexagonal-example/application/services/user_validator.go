@@ -1,7 +1,6 @@
 package services
 
 import (
-	"errors"
 	"regexp"
 	"strings"
 )
@@ -70,27 +69,29 @@ func (v *UserValidator) ValidateUpdateUser(id string, email, name *string) error
 // validateID valida el ID del usuario
 func (v *UserValidator) validateID(id string) error {
 	if strings.TrimSpace(id) == "" {
-		return errors.New("user ID cannot be empty")
+		return newValidationError("user ID cannot be empty")
 	}
 	if len(id) < 3 {
-		return errors.New("user ID must be at least 3 characters long")
+		return newValidationError("user ID must be at least 3 characters long")
 	}
 	if len(id) > 50 {
-		return errors.New("user ID cannot exceed 50 characters")
+		return newValidationError("user ID cannot exceed 50 characters")
 	}
 	return nil
 }
 
 // validateEmail valida el email del usuario
+// Los espacios alrededor se ignoran: el email se guarda normalizado
 func (v *UserValidator) validateEmail(email string) error {
-	if strings.TrimSpace(email) == "" {
-		return errors.New("email cannot be empty")
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return newValidationError("email cannot be empty")
 	}
 	if len(email) > 255 {
-		return errors.New("email cannot exceed 255 characters")
+		return newValidationError("email cannot exceed 255 characters")
 	}
 	if !v.emailRegex.MatchString(email) {
-		return errors.New("invalid email format")
+		return newValidationError("invalid email format")
 	}
 	return nil
 }
@@ -98,13 +99,13 @@ func (v *UserValidator) validateEmail(email string) error {
 // validateName valida el nombre del usuario
 func (v *UserValidator) validateName(name string) error {
 	if strings.TrimSpace(name) == "" {
-		return errors.New("name cannot be empty")
+		return newValidationError("name cannot be empty")
 	}
 	if len(name) < 2 {
-		return errors.New("name must be at least 2 characters long")
+		return newValidationError("name must be at least 2 characters long")
 	}
 	if len(name) > 100 {
-		return errors.New("name cannot exceed 100 characters")
+		return newValidationError("name cannot exceed 100 characters")
 	}
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,16 @@
+//go:build cgo
+
+package main
+
+import (
+	"database/sql"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Registra el driver de SQLite del adaptador sql con los dos nombres que acepta
+// sqldb.DialectFor: go-sqlite3 se registra como "sqlite3" y aquí se añade "sqlite"
+// Necesita cgo: sin él el binario no registra ningún driver y sqldb.Open lo indica
+func init() {
+	sql.Register("sqlite", &sqlite3.SQLiteDriver{})
+}
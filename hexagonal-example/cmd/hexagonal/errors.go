@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"hexagonal-example/application/services"
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// Códigos de salida de la CLI
+const (
+	exitOK         = 0
+	exitInternal   = 1
+	exitUsage      = 2
+	exitValidation = 3
+	exitNotFound   = 4
+	exitConflict   = 5
+)
+
+// usageError indica un uso incorrecto de la CLI (argumentos o flags inválidos)
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+// usageErrorf crea un usageError con formato
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// exitCode traduce un error de la aplicación al código de salida correspondiente
+func exitCode(err error) int {
+	var usageErr *usageError
+	var validationErr *services.ValidationError
+
+	switch {
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.As(err, &validationErr), errors.Is(err, entities.ErrCurrencyMismatch):
+		return exitValidation
+	case errors.Is(err, repositories.ErrUserNotFound),
+		errors.Is(err, repositories.ErrProductNotFound),
+		errors.Is(err, repositories.ErrReservationNotFound),
+		errors.Is(err, repositories.ErrOrderNotFound):
+		return exitNotFound
+	case errors.Is(err, repositories.ErrUserAlreadyExists),
+		errors.Is(err, repositories.ErrProductAlreadyExists),
+		errors.Is(err, repositories.ErrEmailAlreadyInUse),
+		errors.Is(err, repositories.ErrInsufficientStock),
+		errors.Is(err, repositories.ErrStockBelowReserved),
+		errors.Is(err, repositories.ErrProductNotAvailable),
+		errors.Is(err, repositories.ErrUserVersionConflict),
+		errors.Is(err, repositories.ErrProductVersionConflict),
+		errors.Is(err, repositories.ErrUserNotActive),
+		errors.Is(err, repositories.ErrOrderAlreadyExists),
+		errors.Is(err, repositories.ErrOrderAlreadyCancelled),
+		errors.Is(err, repositories.ErrOrderVersionConflict):
+		return exitConflict
+	default:
+		return exitInternal
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"strconv"
+
+	"hexagonal-example/domain/entities"
+)
+
+// newFlagSet crea un FlagSet que no termina el proceso ante errores
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseArgs parsea los flags permitiendo argumentos posicionales intercalados
+// (por ejemplo "user update ID --name X") y retorna los argumentos posicionales
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, &usageError{message: err.Error()}
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// requireArgs verifica el número exacto de argumentos posicionales
+func requireArgs(command string, positional []string, names ...string) error {
+	if len(positional) != len(names) {
+		usage := command
+		for _, name := range names {
+			usage += " " + name
+		}
+		return usageErrorf("uso: %s", usage)
+	}
+	return nil
+}
+
+// requireFlags verifica que los flags obligatorios se hayan indicado
+func requireFlags(fs *flag.FlagSet, names ...string) error {
+	set := visited(fs)
+	for _, name := range names {
+		if !set[name] {
+			return usageErrorf("falta el flag obligatorio --%s", name)
+		}
+	}
+	return nil
+}
+
+// visited retorna los nombres de los flags indicados explícitamente
+func visited(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// parseQuantity convierte un argumento posicional en una cantidad entera
+func parseQuantity(raw string) (int, error) {
+	quantity, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, usageErrorf("cantidad inválida: %s", raw)
+	}
+	return quantity, nil
+}
+
+// parseMoney convierte un importe decimal en un Money de la moneda indicada
+func parseMoney(raw, currency string) (entities.Money, error) {
+	money, err := entities.ParseMoney(raw, currency)
+	if err != nil {
+		return entities.Money{}, usageErrorf("importe inválido: %v", err)
+	}
+	return money, nil
+}
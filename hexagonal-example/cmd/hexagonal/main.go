@@ -0,0 +1,150 @@
+// Command hexagonal es la interfaz de línea de comandos de la aplicación
+// Es un adaptador primario (puerto de entrada) que traduce subcomandos
+// a llamadas a los servicios obtenidos del contenedor de dependencias
+//
+// Uso:
+//
+//	hexagonal [-config fichero.json] [-o table|json] <comando> <subcomando> [flags]
+//
+// Comandos:
+//
+//	user create|update|get|list|activate|deactivate|delete
+//	product create|update|get|list|search|find|activate|deactivate|delete
+//	product stock add|remove <id> <cantidad>
+//	product reserve <id> <cantidad>
+//	product reservation confirm|release <id> <reserva>
+//	order create|get|list|cancel
+//	stats
+//	serve
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"hexagonal-example/infrastructure/config"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run ejecuta la CLI con los argumentos dados y retorna el código de salida
+func run(args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("hexagonal", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	output := global.String("o", formatTable, "formato de salida: table o json")
+	configPath := global.String("config", "", "fichero de configuración JSON de adaptadores (las variables HEXAGONAL_* lo sobrescriben)")
+	global.Usage = func() { printUsage(stderr) }
+
+	if err := global.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *output != formatTable && *output != formatJSON {
+		fmt.Fprintf(stderr, "formato de salida desconocido: %s\n", *output)
+		return exitUsage
+	}
+
+	rest := global.Args()
+	if len(rest) == 0 {
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	container, err := config.NewContainerFromConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitInternal
+	}
+	defer container.Close()
+
+	cli := &app{
+		container: container,
+		printer:   &printer{out: stdout, format: *output},
+		stderr:    stderr,
+	}
+	return cli.execute(rest)
+}
+
+// execute despacha un comando ya separado de los flags globales y retorna el
+// código de salida
+func (a *app) execute(args []string) int {
+	var err error
+	switch args[0] {
+	case "user":
+		err = a.runUser(args[1:])
+	case "product":
+		err = a.runProduct(args[1:])
+	case "order":
+		err = a.runOrder(args[1:])
+	case "stats":
+		err = a.runStats(args[1:])
+	case "serve":
+		err = a.runServe(args[1:])
+	case "help":
+		printUsage(a.printer.out)
+		return exitOK
+	default:
+		err = usageErrorf("comando desconocido: %s", args[0])
+	}
+
+	if err != nil {
+		fmt.Fprintf(a.stderr, "error: %v\n", err)
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+// app agrupa las dependencias compartidas por todos los subcomandos
+type app struct {
+	container *config.Container
+	printer   *printer
+	stderr    io.Writer
+}
+
+// printUsage imprime la ayuda general de la CLI
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `Uso: hexagonal [-config fichero.json] [-o table|json] <comando> [argumentos]
+
+Usuarios:
+  user create --id ID --email EMAIL --name NOMBRE
+  user update ID [--email EMAIL] [--name NOMBRE]
+  user get ID
+  user list [--active] [--limit N] [--offset N]
+  user activate ID
+  user deactivate ID
+  user delete ID
+
+Productos:
+  product create --id ID --name NOMBRE --category CAT --price PRECIO [--stock N] [--description TEXTO]
+  product update ID [--name ...] [--description ...] [--category ...] [--price ...] [--stock ...]
+  product get ID
+  product list [--available] [--category CAT] [--limit N] [--offset N]
+  product search [--category CAT] [--min-price P] [--max-price P] [--name TEXTO] [--available] [--min-stock N] [--limit N] [--sort CAMPO[:desc]] [--cursor C]
+  product find TEXTO [--limit N]
+  product stock add ID CANTIDAD
+  product stock remove ID CANTIDAD
+  product reserve ID CANTIDAD [--ttl 15m]
+  product reservation confirm ID RESERVA
+  product reservation release ID RESERVA
+  product activate ID
+  product deactivate ID
+  product delete ID
+
+Pedidos:
+  order create --id ID --user USUARIO PRODUCTO:CANTIDAD [PRODUCTO:CANTIDAD...]
+  order get ID
+  order list [--user USUARIO] [--limit N] [--offset N]
+  order cancel ID
+
+Otros:
+  stats                 estadísticas de usuarios y productos
+  serve [--addr :8080] [--reservation-expiry 30s]
+                        expone la API HTTP
+
+Códigos de salida: 0 ok, 1 error interno, 2 uso incorrecto,
+3 error de validación, 4 no encontrado, 5 conflicto
+`)
+}
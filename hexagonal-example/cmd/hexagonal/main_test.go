@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+	"hexagonal-example/infrastructure/config"
+)
+
+// TestCommands ejecuta cada comando sobre un contenedor en memoria, tras los
+// comandos de preparación del caso, y comprueba la salida y el código de salida
+func TestCommands(t *testing.T) {
+	withUser := [][]string{{"user", "create", "--id", "user-1", "--email", "ana@example.com", "--name", "Ana"}}
+	withProduct := [][]string{{"product", "create", "--id", "prod-1", "--name", "Teclado", "--category", "perifericos", "--price", "19.99", "--stock", "5"}}
+
+	tests := []struct {
+		name   string
+		format string
+		setup  [][]string
+		args   []string
+		code   int
+		stdout []string
+		stderr string
+	}{
+		{
+			name:   "crear usuario",
+			args:   withUser[0],
+			code:   exitOK,
+			stdout: []string{"ID", "EMAIL", "user-1", "ana@example.com", "Ana"},
+		},
+		{
+			name:   "obtener usuario en json",
+			format: formatJSON,
+			setup:  withUser,
+			args:   []string{"user", "get", "user-1"},
+			code:   exitOK,
+			stdout: []string{`"id": "user-1"`, `"email": "ana@example.com"`},
+		},
+		{
+			name:   "flags intercalados con argumentos",
+			setup:  withUser,
+			args:   []string{"user", "update", "--name", "Ana María", "user-1"},
+			code:   exitOK,
+			stdout: []string{"Ana María"},
+		},
+		{
+			name:   "listar usuarios vacío en json",
+			format: formatJSON,
+			args:   []string{"user", "list"},
+			code:   exitOK,
+			stdout: []string{"[]"},
+		},
+		{
+			name:   "eliminar usuario",
+			setup:  withUser,
+			args:   []string{"user", "delete", "user-1"},
+			code:   exitOK,
+			stdout: []string{"usuario user-1 eliminado"},
+		},
+		{
+			name:   "mensaje en json",
+			format: formatJSON,
+			setup:  withUser,
+			args:   []string{"user", "delete", "user-1"},
+			code:   exitOK,
+			stdout: []string{`"message": "usuario user-1 eliminado"`},
+		},
+		{
+			name:   "crear producto",
+			args:   withProduct[0],
+			code:   exitOK,
+			stdout: []string{"CATEGORÍA", "prod-1", "Teclado", "19.99"},
+		},
+		{
+			name:   "añadir stock",
+			setup:  withProduct,
+			args:   []string{"product", "stock", "add", "prod-1", "3"},
+			code:   exitOK,
+			stdout: []string{"prod-1"},
+		},
+		{
+			name:   "crear pedido",
+			setup:  append(append([][]string{}, withUser...), withProduct...),
+			args:   []string{"order", "create", "--id", "order-1", "--user", "user-1", "prod-1:2"},
+			code:   exitOK,
+			stdout: []string{"order-1", "user-1", "PRODUCTO", "prod-1"},
+		},
+		{
+			name:   "estadísticas",
+			setup:  withUser,
+			args:   []string{"stats"},
+			code:   exitOK,
+			stdout: []string{"usuarios totales", "1"},
+		},
+		{
+			name:   "ayuda",
+			args:   []string{"help"},
+			code:   exitOK,
+			stdout: []string{"Uso: hexagonal"},
+		},
+		{
+			name:   "comando desconocido",
+			args:   []string{"invoice"},
+			code:   exitUsage,
+			stderr: "comando desconocido: invoice",
+		},
+		{
+			name:   "subcomando desconocido",
+			args:   []string{"user", "rename"},
+			code:   exitUsage,
+			stderr: "subcomando de usuario desconocido: rename",
+		},
+		{
+			name:   "falta un flag obligatorio",
+			args:   []string{"user", "create", "--id", "user-1", "--name", "Ana"},
+			code:   exitUsage,
+			stderr: "falta el flag obligatorio --email",
+		},
+		{
+			name:   "flag desconocido",
+			args:   []string{"user", "list", "--color"},
+			code:   exitUsage,
+			stderr: "flag provided but not defined",
+		},
+		{
+			name:   "cantidad inválida",
+			setup:  withProduct,
+			args:   []string{"product", "stock", "add", "prod-1", "tres"},
+			code:   exitUsage,
+			stderr: "cantidad inválida: tres",
+		},
+		{
+			name:   "error de validación",
+			args:   []string{"user", "create", "--id", "user-1", "--email", "no-es-un-email", "--name", "Ana"},
+			code:   exitValidation,
+			stderr: "invalid email format",
+		},
+		{
+			name:   "no encontrado",
+			args:   []string{"product", "get", "prod-9"},
+			code:   exitNotFound,
+			stderr: "product not found",
+		},
+		{
+			name:   "conflicto",
+			setup:  withUser,
+			args:   withUser[0],
+			code:   exitConflict,
+			stderr: "user already exists",
+		},
+		{
+			name:   "stock insuficiente",
+			setup:  withProduct,
+			args:   []string{"product", "stock", "remove", "prod-1", "50"},
+			code:   exitConflict,
+			stderr: "insufficient stock",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := config.NewContainer()
+			defer container.Close()
+
+			format := tt.format
+			if format == "" {
+				format = formatTable
+			}
+			var stdout, stderr bytes.Buffer
+			cli := &app{
+				container: container,
+				printer:   &printer{out: &stdout, format: format},
+				stderr:    &stderr,
+			}
+
+			for _, args := range tt.setup {
+				if code := cli.execute(args); code != exitOK {
+					t.Fatalf("preparación %v terminó con código %d: %s", args, code, stderr.String())
+				}
+			}
+			stdout.Reset()
+			stderr.Reset()
+
+			if code := cli.execute(tt.args); code != tt.code {
+				t.Errorf("código de salida %d, se esperaba %d (stderr: %s)", code, tt.code, stderr.String())
+			}
+			for _, want := range tt.stdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("la salida no contiene %q:\n%s", want, stdout.String())
+				}
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("el error no contiene %q: %s", tt.stderr, stderr.String())
+			}
+		})
+	}
+}
+
+// TestGlobalFlags comprueba los flags globales, que se procesan antes de crear
+// el contenedor
+func TestGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{name: "sin comando", args: nil, code: exitUsage, stderr: "Uso: hexagonal"},
+		{name: "formato desconocido", args: []string{"-o", "xml", "user", "list"}, code: exitUsage, stderr: "formato de salida desconocido: xml"},
+		{name: "flag global desconocido", args: []string{"-v", "user", "list"}, code: exitUsage, stderr: "flag provided but not defined"},
+		{name: "formato json", args: []string{"-o", "json", "product", "list"}, code: exitOK, stdout: "[]"},
+		{name: "configuración inexistente", args: []string{"-config", "no-existe.json", "user", "list"}, code: exitInternal, stderr: "reading config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != tt.code {
+				t.Errorf("código de salida %d, se esperaba %d (stderr: %s)", code, tt.code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.stdout) {
+				t.Errorf("la salida no contiene %q:\n%s", tt.stdout, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("el error no contiene %q: %s", tt.stderr, stderr.String())
+			}
+		})
+	}
+}
+
+// TestExitCodes comprueba la traducción de los errores de la aplicación, también
+// envueltos, a códigos de salida
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{usageErrorf("uso"), exitUsage},
+		{fmt.Errorf("sumando importes: %w", entities.ErrCurrencyMismatch), exitValidation},
+		{fmt.Errorf("buscando: %w", repositories.ErrProductNotFound), exitNotFound},
+		{repositories.ErrInsufficientStock, exitConflict},
+		{errors.New("fallo interno"), exitInternal},
+	}
+	for _, tt := range tests {
+		if code := exitCode(tt.err); code != tt.code {
+			t.Errorf("%v: código de salida %d, se esperaba %d", tt.err, code, tt.code)
+		}
+	}
+}
+
+// TestSQLAdapter comprueba que el binario registra el driver de SQLite con los
+// nombres que acepta el adaptador sql
+func TestSQLAdapter(t *testing.T) {
+	for _, driver := range []string{"sqlite", "sqlite3"} {
+		t.Run(driver, func(t *testing.T) {
+			t.Setenv(config.EnvRepositoryAdapter, "sql")
+			t.Setenv(config.EnvSQLDriver, driver)
+			t.Setenv(config.EnvSQLDSN, filepath.Join(t.TempDir(), "hexagonal.db"))
+
+			var stdout, stderr bytes.Buffer
+			args := []string{"user", "create", "--id", "user-1", "--email", "ana@example.com", "--name", "Ana"}
+			if code := run(args, &stdout, &stderr); code != exitOK {
+				t.Fatalf("código de salida %d, se esperaba %d (stderr: %s)", code, exitOK, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), "user-1") {
+				t.Errorf("la salida no contiene %q:\n%s", "user-1", stdout.String())
+			}
+		})
+	}
+}
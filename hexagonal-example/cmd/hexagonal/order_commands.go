@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"hexagonal-example/application/services"
+)
+
+// runOrder despacha los subcomandos de pedido
+func (a *app) runOrder(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("uso: order create|get|list|cancel")
+	}
+
+	switch args[0] {
+	case "create":
+		return a.orderCreate(args[1:])
+	case "get":
+		return a.orderGet(args[1:])
+	case "list":
+		return a.orderList(args[1:])
+	case "cancel":
+		return a.orderCancel(args[1:])
+	default:
+		return usageErrorf("subcomando de pedido desconocido: %s", args[0])
+	}
+}
+
+// orderCreate implementa "order create --id ID --user USUARIO PRODUCTO:CANTIDAD..."
+func (a *app) orderCreate(args []string) error {
+	fs := newFlagSet("order create", a.stderr)
+	id := fs.String("id", "", "ID del pedido")
+	userID := fs.String("user", "", "ID del usuario")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "id", "user"); err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return usageErrorf("uso: order create --id ID --user USUARIO PRODUCTO:CANTIDAD...")
+	}
+
+	lines := make([]services.OrderLine, 0, len(positional))
+	for _, raw := range positional {
+		line, err := parseOrderLine(raw)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, line)
+	}
+
+	order, err := a.container.GetOrderService().CreateOrder(context.Background(), *id, *userID, lines)
+	if err != nil {
+		return err
+	}
+	return a.printer.printOrder(order)
+}
+
+// orderGet implementa "order get"
+func (a *app) orderGet(args []string) error {
+	positional, err := parseArgs(newFlagSet("order get", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("order get", positional, "ID"); err != nil {
+		return err
+	}
+
+	order, err := a.container.GetOrderService().GetOrder(context.Background(), positional[0])
+	if err != nil {
+		return err
+	}
+	return a.printer.printOrder(order)
+}
+
+// orderList implementa "order list"
+func (a *app) orderList(args []string) error {
+	fs := newFlagSet("order list", a.stderr)
+	userID := fs.String("user", "", "listar solo los pedidos de este usuario")
+	limit := fs.Int("limit", 20, "número máximo de resultados")
+	offset := fs.Int("offset", 0, "número de resultados a omitir")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("order list", positional); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	orderService := a.container.GetOrderService()
+	if *userID != "" {
+		orders, err := orderService.ListUserOrders(ctx, *userID, *limit, *offset)
+		if err != nil {
+			return err
+		}
+		return a.printer.printOrders(orders)
+	}
+
+	orders, err := orderService.ListOrders(ctx, *limit, *offset)
+	if err != nil {
+		return err
+	}
+	return a.printer.printOrders(orders)
+}
+
+// orderCancel implementa "order cancel"
+func (a *app) orderCancel(args []string) error {
+	positional, err := parseArgs(newFlagSet("order cancel", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("order cancel", positional, "ID"); err != nil {
+		return err
+	}
+
+	order, err := a.container.GetOrderService().CancelOrder(context.Background(), positional[0])
+	if err != nil {
+		return err
+	}
+	return a.printer.printOrder(order)
+}
+
+// parseOrderLine convierte un argumento PRODUCTO:CANTIDAD en una línea de pedido
+func parseOrderLine(raw string) (services.OrderLine, error) {
+	productID, rawQuantity, ok := strings.Cut(raw, ":")
+	if !ok {
+		return services.OrderLine{}, usageErrorf("línea de pedido inválida (se espera PRODUCTO:CANTIDAD): %s", raw)
+	}
+	quantity, err := parseQuantity(rawQuantity)
+	if err != nil {
+		return services.OrderLine{}, err
+	}
+	return services.OrderLine{ProductID: productID, Quantity: quantity}, nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"hexagonal-example/application/services"
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// Formatos de salida soportados
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// printer escribe los resultados de los comandos en el formato elegido
+type printer struct {
+	out    io.Writer
+	format string
+}
+
+// printUsers imprime una lista de usuarios
+func (p *printer) printUsers(users []*entities.User) error {
+	if p.format == formatJSON {
+		if users == nil {
+			users = []*entities.User{}
+		}
+		return p.printJSON(users)
+	}
+
+	tw := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tEMAIL\tNOMBRE\tACTIVO\tCREADO")
+	for _, user := range users {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\n",
+			user.ID, user.Email, user.Name, user.IsActive, user.CreatedAt.Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+// printUser imprime un único usuario
+func (p *printer) printUser(user *entities.User) error {
+	if p.format == formatJSON {
+		return p.printJSON(user)
+	}
+	return p.printUsers([]*entities.User{user})
+}
+
+// printProducts imprime una lista de productos
+func (p *printer) printProducts(products []*entities.Product) error {
+	if p.format == formatJSON {
+		if products == nil {
+			products = []*entities.Product{}
+		}
+		return p.printJSON(products)
+	}
+
+	tw := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNOMBRE\tCATEGORÍA\tPRECIO\tSTOCK\tRESERVADO\tACTIVO")
+	for _, product := range products {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%t\n",
+			product.ID, product.Name, product.Category, product.Price, product.Stock, product.ReservedStock(), product.IsActive)
+	}
+	return tw.Flush()
+}
+
+// printProductPage imprime una página de productos y, en formato tabla, el total
+// y el cursor de la página siguiente
+func (p *printer) printProductPage(page repositories.Page[*entities.Product]) error {
+	if p.format == formatJSON {
+		return p.printJSON(page)
+	}
+	if err := p.printProducts(page.Items); err != nil {
+		return err
+	}
+	fmt.Fprintf(p.out, "\n%d de %d productos\n", len(page.Items), page.Total)
+	if page.NextCursor != "" {
+		fmt.Fprintf(p.out, "siguiente página: --cursor %s\n", page.NextCursor)
+	}
+	return nil
+}
+
+// printSearchResults imprime los productos de una búsqueda de texto con su relevancia
+func (p *printer) printSearchResults(results []services.ProductSearchResult) error {
+	if p.format == formatJSON {
+		return p.printJSON(results)
+	}
+
+	tw := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RELEVANCIA\tID\tNOMBRE\tCATEGORÍA\tPRECIO\tDISPONIBLE")
+	for _, result := range results {
+		product := result.Product
+		fmt.Fprintf(tw, "%.2f\t%s\t%s\t%s\t%s\t%d\n",
+			result.Score, product.ID, product.Name, product.Category, product.Price, product.AvailableStock())
+	}
+	return tw.Flush()
+}
+
+// printProduct imprime un único producto
+func (p *printer) printProduct(product *entities.Product) error {
+	if p.format == formatJSON {
+		return p.printJSON(product)
+	}
+	return p.printProducts([]*entities.Product{product})
+}
+
+// printReservation imprime una reserva de stock
+func (p *printer) printReservation(reservation *entities.StockReservation) error {
+	if p.format == formatJSON {
+		return p.printJSON(reservation)
+	}
+
+	tw := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RESERVA\tCANTIDAD\tEXPIRA")
+	fmt.Fprintf(tw, "%s\t%d\t%s\n", reservation.ID, reservation.Quantity, reservation.ExpiresAt.Format(time.RFC3339))
+	return tw.Flush()
+}
+
+// printOrders imprime una lista de pedidos
+func (p *printer) printOrders(orders []*entities.Order) error {
+	if p.format == formatJSON {
+		if orders == nil {
+			orders = []*entities.Order{}
+		}
+		return p.printJSON(orders)
+	}
+
+	tw := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tUSUARIO\tLÍNEAS\tTOTAL\tESTADO")
+	for _, order := range orders {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n",
+			order.ID, order.UserID, len(order.Items), order.Total(), order.Status)
+	}
+	return tw.Flush()
+}
+
+// printOrder imprime un único pedido con sus líneas
+func (p *printer) printOrder(order *entities.Order) error {
+	if p.format == formatJSON {
+		return p.printJSON(order)
+	}
+	if err := p.printOrders([]*entities.Order{order}); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(p.out)
+	tw := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PRODUCTO\tCANTIDAD\tPRECIO\tSUBTOTAL")
+	for _, item := range order.Items {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", item.ProductID, item.Quantity, item.UnitPrice, item.Subtotal())
+	}
+	return tw.Flush()
+}
+
+// printStatistics imprime las estadísticas de usuarios y productos
+func (p *printer) printStatistics(userStats *services.UserStatistics, productStats *services.ProductStatistics) error {
+	if p.format == formatJSON {
+		return p.printJSON(struct {
+			Users    *services.UserStatistics    `json:"users"`
+			Products *services.ProductStatistics `json:"products"`
+		}{userStats, productStats})
+	}
+
+	tw := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MÉTRICA\tVALOR")
+	fmt.Fprintf(tw, "usuarios totales\t%d\n", userStats.TotalUsers)
+	fmt.Fprintf(tw, "usuarios activos\t%d\n", userStats.ActiveUsers)
+	fmt.Fprintf(tw, "usuarios inactivos\t%d\n", userStats.InactiveUsers)
+	fmt.Fprintf(tw, "productos totales\t%d\n", productStats.TotalProducts)
+	fmt.Fprintf(tw, "productos disponibles\t%d\n", productStats.AvailableProducts)
+	fmt.Fprintf(tw, "productos no disponibles\t%d\n", productStats.UnavailableProducts)
+	return tw.Flush()
+}
+
+// printMessage imprime un mensaje de confirmación
+func (p *printer) printMessage(message string) error {
+	if p.format == formatJSON {
+		return p.printJSON(map[string]string{"message": message})
+	}
+	_, err := fmt.Fprintln(p.out, message)
+	return err
+}
+
+// printJSON serializa el valor como JSON indentado
+func (p *printer) printJSON(value interface{}) error {
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"hexagonal-example/application/services"
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// runProduct despacha los subcomandos de producto
+func (a *app) runProduct(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("uso: product create|update|get|list|search|find|stock|reserve|reservation|activate|deactivate|delete")
+	}
+
+	switch args[0] {
+	case "create":
+		return a.productCreate(args[1:])
+	case "update":
+		return a.productUpdate(args[1:])
+	case "get":
+		return a.productGet(args[1:])
+	case "list":
+		return a.productList(args[1:])
+	case "search":
+		return a.productSearch(args[1:])
+	case "find":
+		return a.productFind(args[1:])
+	case "stock":
+		return a.productStock(args[1:])
+	case "reserve":
+		return a.productReserve(args[1:])
+	case "reservation":
+		return a.productReservation(args[1:])
+	case "activate":
+		return a.productActivate(args[1:])
+	case "deactivate":
+		return a.productDeactivate(args[1:])
+	case "delete":
+		return a.productDelete(args[1:])
+	default:
+		return usageErrorf("subcomando de producto desconocido: %s", args[0])
+	}
+}
+
+// productCreate implementa "product create"
+func (a *app) productCreate(args []string) error {
+	fs := newFlagSet("product create", a.stderr)
+	id := fs.String("id", "", "ID del producto")
+	name := fs.String("name", "", "nombre del producto")
+	description := fs.String("description", "", "descripción del producto")
+	category := fs.String("category", "", "categoría del producto")
+	price := fs.String("price", "", "precio del producto (decimal exacto, p. ej. 19.99)")
+	currency := fs.String("currency", entities.DefaultCurrency, "moneda ISO 4217 del precio")
+	stock := fs.Int("stock", 0, "stock inicial")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("product create", positional); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "id", "name", "category", "price"); err != nil {
+		return err
+	}
+
+	parsedPrice, err := parseMoney(*price, *currency)
+	if err != nil {
+		return err
+	}
+
+	product, err := a.container.GetProductService().CreateProduct(context.Background(), *id, *name, *description, *category, parsedPrice, *stock)
+	if err != nil {
+		return err
+	}
+	return a.printer.printProduct(product)
+}
+
+// productUpdate implementa "product update"
+func (a *app) productUpdate(args []string) error {
+	fs := newFlagSet("product update", a.stderr)
+	name := fs.String("name", "", "nuevo nombre")
+	description := fs.String("description", "", "nueva descripción")
+	category := fs.String("category", "", "nueva categoría")
+	price := fs.String("price", "", "nuevo precio (decimal exacto)")
+	currency := fs.String("currency", entities.DefaultCurrency, "moneda ISO 4217 del nuevo precio")
+	stock := fs.Int("stock", 0, "nuevo stock")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("product update", positional, "ID"); err != nil {
+		return err
+	}
+
+	// Solo se actualizan los campos indicados explícitamente
+	set := visited(fs)
+	if len(set) == 0 {
+		return usageErrorf("indica al menos un campo a actualizar")
+	}
+	var namePtr, descriptionPtr, categoryPtr *string
+	var pricePtr *entities.Money
+	var stockPtr *int
+	if set["name"] {
+		namePtr = name
+	}
+	if set["description"] {
+		descriptionPtr = description
+	}
+	if set["category"] {
+		categoryPtr = category
+	}
+	if set["price"] {
+		parsedPrice, err := parseMoney(*price, *currency)
+		if err != nil {
+			return err
+		}
+		pricePtr = &parsedPrice
+	} else if set["currency"] {
+		return usageErrorf("--currency solo puede indicarse junto a --price")
+	}
+	if set["stock"] {
+		stockPtr = stock
+	}
+
+	product, err := a.container.GetProductService().UpdateProduct(context.Background(), positional[0], namePtr, descriptionPtr, categoryPtr, pricePtr, stockPtr)
+	if err != nil {
+		return err
+	}
+	return a.printer.printProduct(product)
+}
+
+// productGet implementa "product get"
+func (a *app) productGet(args []string) error {
+	positional, err := parseArgs(newFlagSet("product get", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("product get", positional, "ID"); err != nil {
+		return err
+	}
+
+	product, err := a.container.GetProductService().GetProduct(context.Background(), positional[0])
+	if err != nil {
+		return err
+	}
+	return a.printer.printProduct(product)
+}
+
+// productList implementa "product list"
+func (a *app) productList(args []string) error {
+	fs := newFlagSet("product list", a.stderr)
+	available := fs.Bool("available", false, "listar solo productos disponibles")
+	category := fs.String("category", "", "filtrar por categoría")
+	limit := fs.Int("limit", 20, "número máximo de resultados")
+	offset := fs.Int("offset", 0, "número de resultados a omitir")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("product list", positional); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	productService := a.container.GetProductService()
+
+	var products []*entities.Product
+	switch {
+	case *category != "":
+		products, err = productService.ListProductsByCategory(ctx, *category, *limit, *offset)
+	case *available:
+		products, err = productService.ListAvailableProducts(ctx, *limit, *offset)
+	default:
+		products, err = productService.ListProducts(ctx, *limit, *offset)
+	}
+	if err != nil {
+		return err
+	}
+	return a.printer.printProducts(products)
+}
+
+// productSearch implementa "product search"
+func (a *app) productSearch(args []string) error {
+	fs := newFlagSet("product search", a.stderr)
+	category := fs.String("category", "", "filtrar por categoría")
+	minPrice := fs.String("min-price", "0", "precio mínimo")
+	maxPrice := fs.String("max-price", "0", "precio máximo")
+	currency := fs.String("currency", entities.DefaultCurrency, "moneda ISO 4217 del rango de precios")
+	name := fs.String("name", "", "texto que debe contener el nombre")
+	available := fs.Bool("available", false, "solo productos disponibles")
+	minStock := fs.Int("min-stock", 0, "stock mínimo")
+	limit := fs.Int("limit", 20, "número máximo de resultados")
+	sort := fs.String("sort", "", "orden: id, name, price o created_at, con sufijo :asc o :desc")
+	cursor := fs.String("cursor", "", "cursor de la página siguiente de una búsqueda anterior")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("product search", positional); err != nil {
+		return err
+	}
+
+	order, err := repositories.ParseSortOrder(*sort)
+	if err != nil {
+		return err
+	}
+
+	parsedMin, err := parseMoney(*minPrice, *currency)
+	if err != nil {
+		return err
+	}
+	parsedMax, err := parseMoney(*maxPrice, *currency)
+	if err != nil {
+		return err
+	}
+
+	criteria := services.ProductSearchCriteria{
+		Category:  *category,
+		MinPrice:  parsedMin,
+		MaxPrice:  parsedMax,
+		Name:      *name,
+		Available: *available,
+		MinStock:  *minStock,
+		Sort:      order,
+		Limit:     *limit,
+		Cursor:    *cursor,
+	}
+
+	page, err := a.container.GetProductManagementService().SearchProducts(context.Background(), criteria)
+	if err != nil {
+		return err
+	}
+	return a.printer.printProductPage(page)
+}
+
+// productFind implementa "product find TEXTO"
+func (a *app) productFind(args []string) error {
+	fs := newFlagSet("product find", a.stderr)
+	limit := fs.Int("limit", 20, "número máximo de resultados")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("product find", positional, "TEXTO"); err != nil {
+		return err
+	}
+
+	results, err := a.container.GetProductService().SearchText(context.Background(), positional[0], *limit)
+	if err != nil {
+		return err
+	}
+	return a.printer.printSearchResults(results)
+}
+
+// productStock implementa "product stock add|remove ID CANTIDAD"
+func (a *app) productStock(args []string) error {
+	positional, err := parseArgs(newFlagSet("product stock", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("product stock", positional, "add|remove", "ID", "CANTIDAD"); err != nil {
+		return err
+	}
+
+	quantity, err := parseQuantity(positional[2])
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	productService := a.container.GetProductService()
+
+	var product *entities.Product
+	switch positional[0] {
+	case "add":
+		product, err = productService.AddStock(ctx, positional[1], quantity)
+	case "remove":
+		product, err = productService.RemoveStock(ctx, positional[1], quantity)
+	default:
+		return usageErrorf("operación de stock desconocida: %s", positional[0])
+	}
+	if err != nil {
+		return err
+	}
+	return a.printer.printProduct(product)
+}
+
+// productReserve implementa "product reserve ID CANTIDAD [--ttl DURACIÓN]"
+func (a *app) productReserve(args []string) error {
+	fs := newFlagSet("product reserve", a.stderr)
+	ttl := fs.Duration("ttl", services.DefaultReservationTTL, "duración de la reserva")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("product reserve", positional, "ID", "CANTIDAD"); err != nil {
+		return err
+	}
+
+	quantity, err := parseQuantity(positional[1])
+	if err != nil {
+		return err
+	}
+
+	reservation, err := a.container.GetProductService().Reserve(context.Background(), positional[0], quantity, *ttl)
+	if err != nil {
+		return err
+	}
+	return a.printer.printReservation(reservation)
+}
+
+// productReservation implementa "product reservation confirm|release ID RESERVA"
+func (a *app) productReservation(args []string) error {
+	positional, err := parseArgs(newFlagSet("product reservation", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("product reservation", positional, "confirm|release", "ID", "RESERVA"); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	productService := a.container.GetProductService()
+
+	var product *entities.Product
+	switch positional[0] {
+	case "confirm":
+		product, err = productService.Confirm(ctx, positional[1], positional[2])
+	case "release":
+		product, err = productService.Release(ctx, positional[1], positional[2])
+	default:
+		return usageErrorf("operación de reserva desconocida: %s", positional[0])
+	}
+	if err != nil {
+		return err
+	}
+	return a.printer.printProduct(product)
+}
+
+// productActivate implementa "product activate"
+func (a *app) productActivate(args []string) error {
+	positional, err := parseArgs(newFlagSet("product activate", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("product activate", positional, "ID"); err != nil {
+		return err
+	}
+
+	product, err := a.container.GetProductService().ActivateProduct(context.Background(), positional[0])
+	if err != nil {
+		return err
+	}
+	return a.printer.printProduct(product)
+}
+
+// productDeactivate implementa "product deactivate"
+func (a *app) productDeactivate(args []string) error {
+	positional, err := parseArgs(newFlagSet("product deactivate", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("product deactivate", positional, "ID"); err != nil {
+		return err
+	}
+
+	product, err := a.container.GetProductService().DeactivateProduct(context.Background(), positional[0])
+	if err != nil {
+		return err
+	}
+	return a.printer.printProduct(product)
+}
+
+// productDelete implementa "product delete"
+func (a *app) productDelete(args []string) error {
+	positional, err := parseArgs(newFlagSet("product delete", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("product delete", positional, "ID"); err != nil {
+		return err
+	}
+
+	if err := a.container.GetProductService().DeleteProduct(context.Background(), positional[0]); err != nil {
+		return err
+	}
+	return a.printer.printMessage(fmt.Sprintf("producto %s eliminado", positional[0]))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// runStats implementa "stats"
+func (a *app) runStats(args []string) error {
+	positional, err := parseArgs(newFlagSet("stats", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("stats", positional); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	userStats, err := a.container.GetUserManagementService().GetUserStatistics(ctx)
+	if err != nil {
+		return err
+	}
+	productStats, err := a.container.GetProductManagementService().GetProductStatistics(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.printStatistics(userStats, productStats)
+}
+
+// runServe implementa "serve": expone la API HTTP con el mismo contenedor
+func (a *app) runServe(args []string) error {
+	fs := newFlagSet("serve", a.stderr)
+	addr := fs.String("addr", ":8080", "dirección de escucha")
+	expiryInterval := fs.Duration("reservation-expiry", 30*time.Second, "frecuencia de liberación de reservas expiradas")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("serve", positional); err != nil {
+		return err
+	}
+
+	a.container.StartReservationExpiry(*expiryInterval)
+
+	fmt.Fprintf(a.stderr, "API HTTP escuchando en %s\n", *addr)
+	return http.ListenAndServe(*addr, a.container.GetHTTPHandler())
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// runUser despacha los subcomandos de usuario
+func (a *app) runUser(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("uso: user create|update|get|list|activate|deactivate|delete")
+	}
+
+	switch args[0] {
+	case "create":
+		return a.userCreate(args[1:])
+	case "update":
+		return a.userUpdate(args[1:])
+	case "get":
+		return a.userGet(args[1:])
+	case "list":
+		return a.userList(args[1:])
+	case "activate":
+		return a.userActivate(args[1:])
+	case "deactivate":
+		return a.userDeactivate(args[1:])
+	case "delete":
+		return a.userDelete(args[1:])
+	default:
+		return usageErrorf("subcomando de usuario desconocido: %s", args[0])
+	}
+}
+
+// userCreate implementa "user create"
+func (a *app) userCreate(args []string) error {
+	fs := newFlagSet("user create", a.stderr)
+	id := fs.String("id", "", "ID del usuario")
+	email := fs.String("email", "", "email del usuario")
+	name := fs.String("name", "", "nombre del usuario")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("user create", positional); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "id", "email", "name"); err != nil {
+		return err
+	}
+
+	user, err := a.container.GetUserService().CreateUser(context.Background(), *id, *email, *name)
+	if err != nil {
+		return err
+	}
+	return a.printer.printUser(user)
+}
+
+// userUpdate implementa "user update"
+func (a *app) userUpdate(args []string) error {
+	fs := newFlagSet("user update", a.stderr)
+	email := fs.String("email", "", "nuevo email")
+	name := fs.String("name", "", "nuevo nombre")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("user update", positional, "ID"); err != nil {
+		return err
+	}
+
+	// Solo se actualizan los campos indicados explícitamente
+	set := visited(fs)
+	var emailPtr, namePtr *string
+	if set["email"] {
+		emailPtr = email
+	}
+	if set["name"] {
+		namePtr = name
+	}
+	if emailPtr == nil && namePtr == nil {
+		return usageErrorf("indica al menos --email o --name")
+	}
+
+	user, err := a.container.GetUserService().UpdateUser(context.Background(), positional[0], emailPtr, namePtr)
+	if err != nil {
+		return err
+	}
+	return a.printer.printUser(user)
+}
+
+// userGet implementa "user get"
+func (a *app) userGet(args []string) error {
+	positional, err := parseArgs(newFlagSet("user get", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("user get", positional, "ID"); err != nil {
+		return err
+	}
+
+	user, err := a.container.GetUserService().GetUser(context.Background(), positional[0])
+	if err != nil {
+		return err
+	}
+	return a.printer.printUser(user)
+}
+
+// userList implementa "user list"
+func (a *app) userList(args []string) error {
+	fs := newFlagSet("user list", a.stderr)
+	active := fs.Bool("active", false, "listar solo usuarios activos")
+	limit := fs.Int("limit", 20, "número máximo de resultados")
+	offset := fs.Int("offset", 0, "número de resultados a omitir")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("user list", positional); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	userService := a.container.GetUserService()
+	if *active {
+		users, err := userService.ListActiveUsers(ctx, *limit, *offset)
+		if err != nil {
+			return err
+		}
+		return a.printer.printUsers(users)
+	}
+
+	users, err := userService.ListUsers(ctx, *limit, *offset)
+	if err != nil {
+		return err
+	}
+	return a.printer.printUsers(users)
+}
+
+// userActivate implementa "user activate"
+func (a *app) userActivate(args []string) error {
+	positional, err := parseArgs(newFlagSet("user activate", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("user activate", positional, "ID"); err != nil {
+		return err
+	}
+
+	user, err := a.container.GetUserService().ActivateUser(context.Background(), positional[0])
+	if err != nil {
+		return err
+	}
+	return a.printer.printUser(user)
+}
+
+// userDeactivate implementa "user deactivate"
+func (a *app) userDeactivate(args []string) error {
+	positional, err := parseArgs(newFlagSet("user deactivate", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("user deactivate", positional, "ID"); err != nil {
+		return err
+	}
+
+	user, err := a.container.GetUserService().DeactivateUser(context.Background(), positional[0])
+	if err != nil {
+		return err
+	}
+	return a.printer.printUser(user)
+}
+
+// userDelete implementa "user delete"
+func (a *app) userDelete(args []string) error {
+	positional, err := parseArgs(newFlagSet("user delete", a.stderr), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs("user delete", positional, "ID"); err != nil {
+		return err
+	}
+
+	if err := a.container.GetUserService().DeleteUser(context.Background(), positional[0]); err != nil {
+		return err
+	}
+	return a.printer.printMessage(fmt.Sprintf("usuario %s eliminado", positional[0]))
+}
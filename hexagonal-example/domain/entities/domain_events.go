@@ -0,0 +1,126 @@
+package entities
+
+import "time"
+
+// DomainEvent es un cambio de estado que una entidad registra en sus propios métodos
+// La capa de aplicación recoge los eventos con PullEvents después de guardar la
+// entidad y los publica, de modo que solo se publica lo que realmente cambió
+type DomainEvent interface {
+	// EventName identifica el tipo de cambio, por ejemplo "product.stock_changed"
+	EventName() string
+}
+
+// domainEvents acumula los eventos registrados por una entidad hasta que se recogen
+// Las copias de la entidad hechas con Clone no comparten ni heredan los eventos
+type domainEvents struct {
+	pending []DomainEvent
+}
+
+// record registra un evento
+func (d *domainEvents) record(event DomainEvent) {
+	d.pending = append(d.pending, event)
+}
+
+// recordOnce registra un evento salvo que ya haya uno pendiente del mismo tipo
+// Se usa para los eventos que no llevan datos propios, donde uno basta
+func (d *domainEvents) recordOnce(event DomainEvent) {
+	for _, pending := range d.pending {
+		if pending.EventName() == event.EventName() {
+			return
+		}
+	}
+	d.record(event)
+}
+
+// Events retorna los eventos pendientes sin retirarlos
+func (d *domainEvents) Events() []DomainEvent {
+	return append([]DomainEvent(nil), d.pending...)
+}
+
+// PullEvents retorna los eventos pendientes y los retira de la entidad
+func (d *domainEvents) PullEvents() []DomainEvent {
+	events := d.pending
+	d.pending = nil
+	return events
+}
+
+// Eventos de dominio de los productos
+// El estado que acompaña al evento (nombre, precio...) se toma del producto guardado
+
+// ProductCreated se registra al crear un producto con NewProduct
+type ProductCreated struct {
+	ProductID string
+}
+
+// ProductUpdated se registra al cambiar los datos o el precio de un producto
+// Un mismo guardado registra como mucho uno
+type ProductUpdated struct {
+	ProductID string
+}
+
+// StockChanged se registra al cambiar el stock físico o el reservado
+// En las reservas OldStock y NewStock coinciden: cambia el stock disponible
+// Un mismo guardado registra como mucho uno, desde el stock inicial hasta el final
+type StockChanged struct {
+	ProductID string
+	OldStock  int
+	NewStock  int
+}
+
+// ProductDeactivated se registra al desactivar un producto
+type ProductDeactivated struct {
+	ProductID string
+}
+
+// ProductActivated se registra al activar un producto
+type ProductActivated struct {
+	ProductID string
+}
+
+// ProductDeleted se registra al marcar un producto como eliminado
+type ProductDeleted struct {
+	ProductID string
+	DeletedAt time.Time
+}
+
+func (ProductCreated) EventName() string     { return "product.created" }
+func (ProductUpdated) EventName() string     { return "product.updated" }
+func (StockChanged) EventName() string       { return "product.stock_changed" }
+func (ProductDeactivated) EventName() string { return "product.deactivated" }
+func (ProductActivated) EventName() string   { return "product.activated" }
+func (ProductDeleted) EventName() string     { return "product.deleted" }
+
+// Eventos de dominio de los usuarios
+
+// UserCreated se registra al crear un usuario con NewUser
+type UserCreated struct {
+	UserID string
+}
+
+// UserUpdated se registra al cambiar el email o el nombre de un usuario
+// Un mismo guardado registra como mucho uno
+type UserUpdated struct {
+	UserID string
+}
+
+// UserDeactivated se registra al desactivar un usuario
+type UserDeactivated struct {
+	UserID string
+}
+
+// UserActivated se registra al activar un usuario
+type UserActivated struct {
+	UserID string
+}
+
+// UserDeleted se registra al marcar un usuario como eliminado
+type UserDeleted struct {
+	UserID    string
+	DeletedAt time.Time
+}
+
+func (UserCreated) EventName() string     { return "user.created" }
+func (UserUpdated) EventName() string     { return "user.updated" }
+func (UserDeactivated) EventName() string { return "user.deactivated" }
+func (UserActivated) EventName() string   { return "user.activated" }
+func (UserDeleted) EventName() string     { return "user.deleted" }
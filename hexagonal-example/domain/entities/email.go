@@ -0,0 +1,36 @@
+package entities
+
+import "strings"
+
+// EmailPolicy describe cómo se normaliza un email antes de guardarlo y compararlo
+// Dos emails con la misma forma normalizada son el mismo email: no pueden
+// pertenecer a usuarios distintos
+type EmailPolicy struct {
+	// StripPlusTag elimina la etiqueta de la parte local ("juan+news@example.com"
+	// pasa a "juan@example.com"), para los proveedores que entregan ambas
+	// direcciones en el mismo buzón
+	StripPlusTag bool
+}
+
+// Normalize retorna la forma normalizada de un email: sin espacios alrededor, en
+// minúsculas y, según la política, sin etiqueta "+"
+func (p EmailPolicy) Normalize(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !p.StripPlusTag {
+		return email
+	}
+	local, domain, found := strings.Cut(email, "@")
+	if !found {
+		return email
+	}
+	if tag := strings.IndexByte(local, '+'); tag > 0 {
+		local = local[:tag]
+	}
+	return local + "@" + domain
+}
+
+// NormalizeEmail normaliza un email con la política por defecto (espacios y mayúsculas)
+// Los usuarios guardan siempre el email normalizado
+func NormalizeEmail(email string) string {
+	return EmailPolicy{}.Normalize(email)
+}
@@ -0,0 +1,335 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency es la moneda asumida cuando un importe no indica ninguna
+// (por ejemplo en los JSON anteriores a la introducción de Money)
+const DefaultCurrency = "USD"
+
+// ErrCurrencyMismatch indica una operación entre importes de monedas distintas
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// currencyExponents contiene el número de decimales de las monedas ISO 4217
+// que no usan dos; el resto usa dos decimales
+var currencyExponents = map[string]int{
+	"BHD": 3, "CLP": 0, "ISK": 0, "JOD": 3, "JPY": 0,
+	"KRW": 0, "KWD": 3, "OMR": 3, "PYG": 0, "TND": 3,
+	"UGX": 0, "VND": 0,
+}
+
+// Money es un objeto valor que representa un importe monetario
+// Guarda el importe como un entero en unidades menores de la moneda (céntimos
+// para USD o EUR) para que sumas y multiplicaciones no acumulen errores de redondeo
+// El valor cero representa 0 en DefaultCurrency: esa moneda se guarda siempre
+// vacía, de modo que Money{} y NewMoney(0, DefaultCurrency) son iguales con ==
+type Money struct {
+	amount   int64
+	currency string
+}
+
+// NewMoney crea un importe a partir de unidades menores y un código ISO 4217
+func NewMoney(amount int64, currency string) (Money, error) {
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	return newMoney(amount, currency), nil
+}
+
+// newMoney crea un importe con un código ya normalizado, guardando DefaultCurrency vacía
+func newMoney(amount int64, currency string) Money {
+	if currency == DefaultCurrency {
+		currency = ""
+	}
+	return Money{amount: amount, currency: currency}
+}
+
+// ParseMoney crea un importe a partir de su representación decimal ("1299.99")
+// El texto se interpreta de forma exacta, sin pasar por float64; se rechazan
+// los importes con más decimales de los que admite la moneda
+func ParseMoney(value, currency string) (Money, error) {
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	text := strings.TrimSpace(value)
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(text, ".")
+	if whole == "" && fraction == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	exponent := currencyExponent(currency)
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimals for %s", value, exponent, currency)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	digits := whole + fraction
+	if digits == "" {
+		digits = "0"
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return Money{}, fmt.Errorf("invalid amount %q", value)
+		}
+	}
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: out of range", value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return newMoney(amount, currency), nil
+}
+
+// MustParseMoney es como ParseMoney pero entra en pánico si el importe no es válido
+// Pensado para importes literales en código y tests
+func MustParseMoney(value, currency string) Money {
+	money, err := ParseMoney(value, currency)
+	if err != nil {
+		panic(err)
+	}
+	return money
+}
+
+// MoneyFromMajor crea un importe a partir de unidades enteras de la moneda (dólares, euros)
+func MoneyFromMajor(units int64, currency string) (Money, error) {
+	money, err := NewMoney(0, currency)
+	if err != nil {
+		return Money{}, err
+	}
+	scale := money.scale()
+	if units > math.MaxInt64/scale || units < math.MinInt64/scale {
+		return Money{}, fmt.Errorf("amount %d %s out of range", units, money.currency)
+	}
+	money.amount = units * scale
+	return money, nil
+}
+
+// Amount retorna el importe en unidades menores de la moneda
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+// Currency retorna el código ISO 4217 de la moneda
+func (m Money) Currency() string {
+	if m.currency == "" {
+		return DefaultCurrency
+	}
+	return m.currency
+}
+
+// IsZero indica si el importe es cero
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+// IsNegative indica si el importe es negativo
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// Add suma dos importes de la misma moneda
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency() != other.Currency() {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{amount: m.amount + other.amount, currency: m.currency}, nil
+}
+
+// Multiply multiplica el importe por una cantidad entera (por ejemplo unidades de un producto)
+func (m Money) Multiply(quantity int) Money {
+	return Money{amount: m.amount * int64(quantity), currency: m.currency}
+}
+
+// Compare compara dos importes de la misma moneda: -1 si m es menor, 0 si son
+// iguales y 1 si m es mayor
+func (m Money) Compare(other Money) (int, error) {
+	if m.Currency() != other.Currency() {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.amount < other.amount:
+		return -1, nil
+	case m.amount > other.amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Between indica si el importe está en el rango [min, max] (ambos incluidos)
+// Los importes en una moneda distinta a la del rango nunca están dentro
+func (m Money) Between(min, max Money) bool {
+	if m.Currency() != min.Currency() || m.Currency() != max.Currency() {
+		return false
+	}
+	return m.amount >= min.amount && m.amount <= max.amount
+}
+
+// Decimal retorna el importe en unidades de la moneda con sus decimales exactos ("1299.99")
+func (m Money) Decimal() string {
+	exponent := currencyExponent(m.Currency())
+	sign := ""
+	amount := m.amount
+	if amount < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absAmount(amount), 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// Float64 retorna el importe como número decimal aproximado
+// Solo debe usarse para presentación o integraciones heredadas, nunca para cálculos
+func (m Money) Float64() float64 {
+	return float64(m.amount) / float64(m.scale())
+}
+
+// String retorna el importe con su moneda ("1299.99 USD")
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency()
+}
+
+// MarshalJSON serializa el importe con su moneda: {"amount": unidades menores,
+// "currency": "EUR"}. Los structs que guardan la moneda en un campo hermano
+// serializan el importe con EncodeMoneyJSON
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.amount, m.Currency()})
+}
+
+// UnmarshalJSON acepta tanto un número en unidades de la moneda (formato heredado,
+// se asume DefaultCurrency) como un objeto {"amount": unidades menores, "currency": "EUR"}
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var value struct {
+			Amount   int64  `json:"amount"`
+			Currency string `json:"currency"`
+		}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		money, err := NewMoney(value.Amount, value.Currency)
+		if err != nil {
+			return err
+		}
+		*m = money
+		return nil
+	}
+
+	money, err := decodeMoney(data, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
+
+// EncodeMoneyJSON serializa el importe como número en unidades de la moneda (12.5),
+// el mismo formato que tenían los precios float64
+// Es para los structs que, como Product, serializan la moneda en un campo hermano
+// del importe; DecodeMoneyJSON lo interpreta de nuevo
+func EncodeMoneyJSON(m Money) json.RawMessage {
+	return json.RawMessage(m.Decimal())
+}
+
+// DecodeMoneyJSON interpreta un importe JSON en la moneda indicada
+// Es para los structs de otras capas (por ejemplo los eventos) que, como Product,
+// serializan la moneda en un campo hermano del importe
+func DecodeMoneyJSON(data []byte, currency string) (Money, error) {
+	return decodeMoney(data, currency)
+}
+
+// decodeMoney interpreta un importe JSON en la moneda indicada
+// Lo usan las entidades que guardan la moneda en un campo hermano del importe
+func decodeMoney(data []byte, currency string) (Money, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return NewMoney(0, currency)
+	}
+	if data[0] == '{' {
+		var money Money
+		err := money.UnmarshalJSON(data)
+		return money, err
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return Money{}, err
+	}
+	text := number.String()
+	if strings.ContainsAny(text, "eE") {
+		// Los números en notación científica solo pueden venir de un float64 heredado
+		value, err := number.Float64()
+		if err != nil {
+			return Money{}, err
+		}
+		text = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return ParseMoney(text, currency)
+}
+
+// scale retorna el número de unidades menores que forman una unidad de la moneda
+func (m Money) scale() int64 {
+	scale := int64(1)
+	for i := 0; i < currencyExponent(m.Currency()); i++ {
+		scale *= 10
+	}
+	return scale
+}
+
+// currencyExponent retorna el número de decimales de la moneda
+func currencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// normalizeCurrency valida un código ISO 4217 y lo retorna en mayúsculas
+// Un código vacío equivale a DefaultCurrency
+func normalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency, nil
+	}
+	if len(currency) != 3 {
+		return "", fmt.Errorf("invalid currency code %q", currency)
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return "", fmt.Errorf("invalid currency code %q", currency)
+		}
+	}
+	return currency, nil
+}
+
+// absAmount retorna el valor absoluto de un importe como uint64 (válido también para MinInt64)
+func absAmount(amount int64) uint64 {
+	if amount < 0 {
+		return uint64(-(amount + 1)) + 1
+	}
+	return uint64(amount)
+}
@@ -0,0 +1,184 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// OrderStatus representa el estado de un pedido
+type OrderStatus string
+
+const (
+	// OrderStatusPlaced indica un pedido confirmado cuyo stock ya se ha descontado
+	OrderStatusPlaced OrderStatus = "placed"
+
+	// OrderStatusCancelled indica un pedido cancelado cuyo stock se ha devuelto
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+// OrderItem representa una línea de un pedido
+// UnitPrice es el precio del producto en el momento de realizar el pedido
+type OrderItem struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice Money  `json:"unit_price"`
+}
+
+// Subtotal retorna el importe de la línea
+func (i OrderItem) Subtotal() Money {
+	return i.UnitPrice.Multiply(i.Quantity)
+}
+
+// Order representa la entidad de pedido en el dominio
+// Un pedido pertenece a un usuario y referencia productos por su ID
+type Order struct {
+	ID        string      `json:"id"`
+	UserID    string      `json:"user_id"`
+	Items     []OrderItem `json:"items"`
+	Status    OrderStatus `json:"status"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+
+	// Version es el número de veces que el pedido se ha persistido
+	// Los repositorios la usan para el control de concurrencia optimista
+	Version int `json:"version"`
+}
+
+// NewOrder crea una nueva instancia de Order con validaciones de dominio
+func NewOrder(id, userID string, items []OrderItem) (*Order, error) {
+	// Validaciones de dominio
+	if id == "" {
+		return nil, errors.New("order ID cannot be empty")
+	}
+	if userID == "" {
+		return nil, errors.New("order user ID cannot be empty")
+	}
+	if len(items) == 0 {
+		return nil, errors.New("order must have at least one item")
+	}
+
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if item.ProductID == "" {
+			return nil, errors.New("order item product ID cannot be empty")
+		}
+		if item.Quantity <= 0 {
+			return nil, errors.New("order item quantity must be positive")
+		}
+		if item.UnitPrice.IsNegative() {
+			return nil, errors.New("order item price cannot be negative")
+		}
+		if item.UnitPrice.Currency() != items[0].UnitPrice.Currency() {
+			return nil, errors.New("order items must share the same currency")
+		}
+		if seen[item.ProductID] {
+			return nil, errors.New("order cannot contain the same product twice")
+		}
+		seen[item.ProductID] = true
+	}
+
+	// Crear el pedido con valores por defecto
+	now := time.Now()
+	return &Order{
+		ID:        id,
+		UserID:    userID,
+		Items:     append([]OrderItem(nil), items...),
+		Status:    OrderStatusPlaced,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// Currency retorna la moneda del pedido, común a todas sus líneas
+func (o *Order) Currency() string {
+	if len(o.Items) == 0 {
+		return DefaultCurrency
+	}
+	return o.Items[0].UnitPrice.Currency()
+}
+
+// Total retorna el importe total del pedido
+func (o *Order) Total() Money {
+	total, _ := NewMoney(0, o.Currency())
+	for _, item := range o.Items {
+		// NewOrder garantiza que todas las líneas comparten moneda
+		total, _ = total.Add(item.Subtotal())
+	}
+	return total
+}
+
+// IsCancelled verifica si el pedido está cancelado
+func (o *Order) IsCancelled() bool {
+	return o.Status == OrderStatusCancelled
+}
+
+// Cancel cancela el pedido
+func (o *Order) Cancel() error {
+	if o.IsCancelled() {
+		return errors.New("order is already cancelled")
+	}
+
+	o.Status = OrderStatusCancelled
+	o.UpdatedAt = time.Now()
+	return nil
+}
+
+// Clone retorna una copia independiente del pedido, incluidas sus líneas
+func (o *Order) Clone() *Order {
+	clone := *o
+	clone.Items = append([]OrderItem(nil), o.Items...)
+	return &clone
+}
+
+// MarshalJSON añade la moneda y el total calculado a la representación JSON del pedido
+// Los importes de las líneas y el total son números en la moneda del pedido
+func (o Order) MarshalJSON() ([]byte, error) {
+	type order Order
+	type orderItem struct {
+		ProductID string          `json:"product_id"`
+		Quantity  int             `json:"quantity"`
+		UnitPrice json.RawMessage `json:"unit_price"`
+	}
+	items := make([]orderItem, 0, len(o.Items))
+	for _, item := range o.Items {
+		items = append(items, orderItem{item.ProductID, item.Quantity, EncodeMoneyJSON(item.UnitPrice)})
+	}
+	return json.Marshal(struct {
+		order
+		Items    []orderItem     `json:"items"`
+		Currency string          `json:"currency"`
+		Total    json.RawMessage `json:"total"`
+	}{order(o), items, o.Currency(), EncodeMoneyJSON(o.Total())})
+}
+
+// UnmarshalJSON interpreta los precios de las líneas en la moneda del pedido
+func (o *Order) UnmarshalJSON(data []byte) error {
+	type order Order
+	aux := struct {
+		*order
+		Items []struct {
+			ProductID string          `json:"product_id"`
+			Quantity  int             `json:"quantity"`
+			UnitPrice json.RawMessage `json:"unit_price"`
+		} `json:"items"`
+		Currency string `json:"currency"`
+	}{order: (*order)(o)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	o.Items = make([]OrderItem, 0, len(aux.Items))
+	for _, item := range aux.Items {
+		unitPrice, err := decodeMoney(item.UnitPrice, aux.Currency)
+		if err != nil {
+			return err
+		}
+		o.Items = append(o.Items, OrderItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: unitPrice,
+		})
+	}
+	return nil
+}
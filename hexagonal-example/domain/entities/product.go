@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"encoding/json"
 	"errors"
 	"time"
 )
@@ -11,16 +12,27 @@ type Product struct {
 	ID          string    `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
-	Price       float64   `json:"price"`
+	Price       Money     `json:"price"`
 	Stock       int       `json:"stock"`
 	Category    string    `json:"category"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	IsActive    bool      `json:"is_active"`
+
+	// Reservations contiene las reservas de stock pendientes de confirmar
+	// Stock es el stock físico; AvailableStock descuenta lo reservado
+	Reservations []StockReservation `json:"reservations,omitempty"`
+
+	// Version es el número de veces que el producto se ha persistido
+	// Los repositorios la usan para el control de concurrencia optimista
+	Version int `json:"version"`
+
+	// domainEvents guarda los eventos registrados por los métodos del producto
+	domainEvents
 }
 
 // NewProduct crea una nueva instancia de Product con validaciones de dominio
-func NewProduct(id, name, description, category string, price float64, stock int) (*Product, error) {
+func NewProduct(id, name, description, category string, price Money, stock int) (*Product, error) {
 	// Validaciones de dominio
 	if id == "" {
 		return nil, errors.New("product ID cannot be empty")
@@ -28,7 +40,7 @@ func NewProduct(id, name, description, category string, price float64, stock int
 	if name == "" {
 		return nil, errors.New("product name cannot be empty")
 	}
-	if price < 0 {
+	if price.IsNegative() {
 		return nil, errors.New("product price cannot be negative")
 	}
 	if stock < 0 {
@@ -37,7 +49,7 @@ func NewProduct(id, name, description, category string, price float64, stock int
 
 	// Crear el producto con valores por defecto
 	now := time.Now()
-	return &Product{
+	product := &Product{
 		ID:          id,
 		Name:        name,
 		Description: description,
@@ -47,17 +59,34 @@ func NewProduct(id, name, description, category string, price float64, stock int
 		CreatedAt:   now,
 		UpdatedAt:   now,
 		IsActive:    true, // Los productos se crean activos por defecto
-	}, nil
+	}
+	product.record(ProductCreated{ProductID: id})
+	return product, nil
+}
+
+// UpdateDetails actualiza el nombre, la descripción y la categoría del producto
+func (p *Product) UpdateDetails(name, description, category string) error {
+	if name == "" {
+		return errors.New("product name cannot be empty")
+	}
+
+	p.Name = name
+	p.Description = description
+	p.Category = category
+	p.UpdatedAt = time.Now()
+	p.recordOnce(ProductUpdated{ProductID: p.ID})
+	return nil
 }
 
 // UpdatePrice actualiza el precio del producto
-func (p *Product) UpdatePrice(newPrice float64) error {
-	if newPrice < 0 {
+func (p *Product) UpdatePrice(newPrice Money) error {
+	if newPrice.IsNegative() {
 		return errors.New("price cannot be negative")
 	}
 	
 	p.Price = newPrice
 	p.UpdatedAt = time.Now()
+	p.recordOnce(ProductUpdated{ProductID: p.ID})
 	return nil
 }
 
@@ -66,9 +95,11 @@ func (p *Product) UpdateStock(newStock int) error {
 	if newStock < 0 {
 		return errors.New("stock cannot be negative")
 	}
+	if newStock < p.ReservedStock() {
+		return errors.New("stock cannot be lower than reserved stock")
+	}
 	
-	p.Stock = newStock
-	p.UpdatedAt = time.Now()
+	p.changeStock(newStock)
 	return nil
 }
 
@@ -78,8 +109,7 @@ func (p *Product) AddStock(quantity int) error {
 		return errors.New("quantity must be positive")
 	}
 	
-	p.Stock += quantity
-	p.UpdatedAt = time.Now()
+	p.changeStock(p.Stock + quantity)
 	return nil
 }
 
@@ -88,12 +118,11 @@ func (p *Product) RemoveStock(quantity int) error {
 	if quantity <= 0 {
 		return errors.New("quantity must be positive")
 	}
-	if p.Stock < quantity {
+	if p.AvailableStock() < quantity {
 		return errors.New("insufficient stock")
 	}
 	
-	p.Stock -= quantity
-	p.UpdatedAt = time.Now()
+	p.changeStock(p.Stock - quantity)
 	return nil
 }
 
@@ -101,20 +130,104 @@ func (p *Product) RemoveStock(quantity int) error {
 func (p *Product) Deactivate() {
 	p.IsActive = false
 	p.UpdatedAt = time.Now()
+	p.record(ProductDeactivated{ProductID: p.ID})
 }
 
 // Activate activa un producto
 func (p *Product) Activate() {
 	p.IsActive = true
 	p.UpdatedAt = time.Now()
+	p.record(ProductActivated{ProductID: p.ID})
+}
+
+// MarkDeleted registra la eliminación del producto
+// El estado no cambia: es el repositorio quien lo elimina
+func (p *Product) MarkDeleted() {
+	p.record(ProductDeleted{ProductID: p.ID, DeletedAt: time.Now()})
+}
+
+// changeStock cambia el stock físico y registra el cambio
+func (p *Product) changeStock(newStock int) {
+	oldStock := p.Stock
+	p.Stock = newStock
+	p.UpdatedAt = time.Now()
+	p.recordStockChanged(oldStock, newStock)
 }
 
 // IsAvailable verifica si el producto está disponible para venta
+// El stock reservado no cuenta como disponible
 func (p *Product) IsAvailable() bool {
-	return p.IsActive && p.Stock > 0
+	return p.IsActive && p.AvailableStock() > 0
+}
+
+// recordStockChanged registra un cambio de stock
+// Si ya hay uno pendiente lo amplía, de modo que un mismo guardado registra como
+// mucho un StockChanged, desde el stock inicial hasta el final
+func (p *Product) recordStockChanged(oldStock, newStock int) {
+	for i, pending := range p.pending {
+		if changed, ok := pending.(StockChanged); ok {
+			changed.NewStock = newStock
+			p.pending[i] = changed
+			return
+		}
+	}
+	p.record(StockChanged{ProductID: p.ID, OldStock: oldStock, NewStock: newStock})
+}
+
+// Clone retorna una copia profunda del producto
+// Los repositorios la usan para que las copias entregadas no compartan
+// las reservas con el estado almacenado
+// La copia no lleva los eventos pendientes, que pertenecen a la instancia original
+func (p *Product) Clone() *Product {
+	clone := *p
+	clone.domainEvents = domainEvents{}
+	if p.Reservations != nil {
+		clone.Reservations = append([]StockReservation(nil), p.Reservations...)
+	}
+	return &clone
+}
+
+// MarshalJSON añade al JSON del producto la moneda del precio, el stock reservado
+// y el disponible; price sigue siendo un número para los clientes existentes
+func (p Product) MarshalJSON() ([]byte, error) {
+	type product Product
+	return json.Marshal(struct {
+		product
+		Price          json.RawMessage `json:"price"`
+		Currency       string          `json:"currency"`
+		ReservedStock  int             `json:"reserved_stock"`
+		AvailableStock int             `json:"available_stock"`
+	}{
+		product:        product(p),
+		Price:          EncodeMoneyJSON(p.Price),
+		Currency:       p.Price.Currency(),
+		ReservedStock:  p.ReservedStock(),
+		AvailableStock: p.AvailableStock(),
+	})
+}
+
+// UnmarshalJSON interpreta el precio en la moneda indicada en currency
+// Los JSON sin currency (anteriores a Money) se leen en DefaultCurrency
+func (p *Product) UnmarshalJSON(data []byte) error {
+	type product Product
+	aux := struct {
+		*product
+		Price    json.RawMessage `json:"price"`
+		Currency string          `json:"currency"`
+	}{product: (*product)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	price, err := decodeMoney(aux.Price, aux.Currency)
+	if err != nil {
+		return err
+	}
+	p.Price = price
+	return nil
 }
 
 // IsValid verifica si el producto es válido según las reglas de negocio
 func (p *Product) IsValid() bool {
-	return p.ID != "" && p.Name != "" && p.Price >= 0 && p.Stock >= 0
+	return p.ID != "" && p.Name != "" && !p.Price.IsNegative() && p.Stock >= 0
 }
\ No newline at end of file
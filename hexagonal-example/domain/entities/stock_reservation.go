@@ -0,0 +1,135 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// StockReservation representa una cantidad de stock apartada temporalmente
+// (por ejemplo durante un checkout) que no puede venderse a otro cliente
+// hasta que se confirme, se libere o expire
+type StockReservation struct {
+	ID        string    `json:"id"`
+	Quantity  int       `json:"quantity"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IsExpired indica si la reserva ha expirado en el instante dado
+func (r StockReservation) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// ReservedStock retorna el stock apartado por reservas vigentes
+// Las reservas expiradas no cuentan aunque todavía no se hayan limpiado
+func (p *Product) ReservedStock() int {
+	now := time.Now()
+	reserved := 0
+	for _, reservation := range p.Reservations {
+		if !reservation.IsExpired(now) {
+			reserved += reservation.Quantity
+		}
+	}
+	return reserved
+}
+
+// AvailableStock retorna el stock que todavía puede venderse o reservarse
+func (p *Product) AvailableStock() int {
+	return p.Stock - p.ReservedStock()
+}
+
+// Reservation busca una reserva vigente por su ID (nil si no existe o ha expirado)
+func (p *Product) Reservation(id string) *StockReservation {
+	now := time.Now()
+	for i := range p.Reservations {
+		if p.Reservations[i].ID == id && !p.Reservations[i].IsExpired(now) {
+			reservation := p.Reservations[i]
+			return &reservation
+		}
+	}
+	return nil
+}
+
+// Reserve aparta stock para una reserva que expira en expiresAt
+func (p *Product) Reserve(id string, quantity int, expiresAt time.Time) error {
+	if id == "" {
+		return errors.New("reservation ID cannot be empty")
+	}
+	if quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+	if !p.IsActive {
+		return errors.New("product is not active")
+	}
+	if p.AvailableStock() < quantity {
+		return errors.New("insufficient stock")
+	}
+	for _, reservation := range p.Reservations {
+		if reservation.ID == id {
+			return errors.New("reservation already exists")
+		}
+	}
+
+	// Copiar el slice para no compartir memoria con otras copias del producto
+	reservations := make([]StockReservation, 0, len(p.Reservations)+1)
+	reservations = append(reservations, p.Reservations...)
+	p.Reservations = append(reservations, StockReservation{
+		ID:        id,
+		Quantity:  quantity,
+		ExpiresAt: expiresAt,
+	})
+	p.UpdatedAt = time.Now()
+	p.recordStockChanged(p.Stock, p.Stock)
+	return nil
+}
+
+// ConfirmReservation convierte la reserva en una venta: descuenta el stock y la elimina
+func (p *Product) ConfirmReservation(id string) error {
+	reservation := p.Reservation(id)
+	if reservation == nil {
+		return errors.New("reservation not found")
+	}
+
+	p.removeReservations(func(r StockReservation) bool { return r.ID == id })
+	p.changeStock(p.Stock - reservation.Quantity)
+	return nil
+}
+
+// ReleaseReservation cancela la reserva devolviendo su stock al disponible
+func (p *Product) ReleaseReservation(id string) error {
+	if p.Reservation(id) == nil {
+		return errors.New("reservation not found")
+	}
+
+	p.removeReservations(func(r StockReservation) bool { return r.ID == id })
+	p.UpdatedAt = time.Now()
+	p.recordStockChanged(p.Stock, p.Stock)
+	return nil
+}
+
+// ReleaseExpiredReservations elimina las reservas expiradas y retorna cuántas había
+func (p *Product) ReleaseExpiredReservations(now time.Time) int {
+	released := p.removeReservations(func(r StockReservation) bool { return r.IsExpired(now) })
+	if released > 0 {
+		p.UpdatedAt = time.Now()
+		p.recordStockChanged(p.Stock, p.Stock)
+	}
+	return released
+}
+
+// removeReservations elimina las reservas que cumplen la condición sin modificar
+// el slice original, que puede estar compartido con otras copias del producto
+func (p *Product) removeReservations(match func(StockReservation) bool) int {
+	kept := make([]StockReservation, 0, len(p.Reservations))
+	for _, reservation := range p.Reservations {
+		if !match(reservation) {
+			kept = append(kept, reservation)
+		}
+	}
+
+	removed := len(p.Reservations) - len(kept)
+	if len(kept) == 0 {
+		kept = nil
+	}
+	p.Reservations = kept
+	return removed
+}
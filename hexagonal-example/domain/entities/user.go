@@ -14,11 +14,21 @@ type User struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	IsActive  bool      `json:"is_active"`
+
+	// Version es el número de veces que el usuario se ha persistido
+	// Los repositorios la usan para el control de concurrencia optimista
+	Version int `json:"version"`
+
+	// domainEvents guarda los eventos registrados por los métodos del usuario
+	domainEvents
 }
 
 // NewUser crea una nueva instancia de User con validaciones de dominio
 // Este es un constructor que encapsula la lógica de creación de usuarios
+// El email se guarda normalizado (ver NormalizeEmail)
 func NewUser(id, email, name string) (*User, error) {
+	email = NormalizeEmail(email)
+
 	// Validaciones de dominio
 	if id == "" {
 		return nil, errors.New("user ID cannot be empty")
@@ -32,25 +42,30 @@ func NewUser(id, email, name string) (*User, error) {
 
 	// Crear el usuario con valores por defecto
 	now := time.Now()
-	return &User{
+	user := &User{
 		ID:        id,
 		Email:     email,
 		Name:      name,
 		CreatedAt: now,
 		UpdatedAt: now,
 		IsActive:  true, // Los usuarios se crean activos por defecto
-	}, nil
+	}
+	user.record(UserCreated{UserID: id})
+	return user, nil
 }
 
 // UpdateEmail actualiza el email del usuario con validación
 // Método de dominio que encapsula la lógica de negocio
+// El email se guarda normalizado (ver NormalizeEmail)
 func (u *User) UpdateEmail(newEmail string) error {
+	newEmail = NormalizeEmail(newEmail)
 	if newEmail == "" {
 		return errors.New("email cannot be empty")
 	}
 	
 	u.Email = newEmail
 	u.UpdatedAt = time.Now()
+	u.recordOnce(UserUpdated{UserID: u.ID})
 	return nil
 }
 
@@ -62,6 +77,7 @@ func (u *User) UpdateName(newName string) error {
 	
 	u.Name = newName
 	u.UpdatedAt = time.Now()
+	u.recordOnce(UserUpdated{UserID: u.ID})
 	return nil
 }
 
@@ -69,12 +85,28 @@ func (u *User) UpdateName(newName string) error {
 func (u *User) Deactivate() {
 	u.IsActive = false
 	u.UpdatedAt = time.Now()
+	u.record(UserDeactivated{UserID: u.ID})
 }
 
 // Activate activa un usuario
 func (u *User) Activate() {
 	u.IsActive = true
 	u.UpdatedAt = time.Now()
+	u.record(UserActivated{UserID: u.ID})
+}
+
+// MarkDeleted registra la eliminación del usuario
+// El estado no cambia: es el repositorio quien lo elimina
+func (u *User) MarkDeleted() {
+	u.record(UserDeleted{UserID: u.ID, DeletedAt: time.Now()})
+}
+
+// Clone retorna una copia del usuario sin sus eventos pendientes
+// Los repositorios la usan para que el estado almacenado no guarde eventos ya publicados
+func (u *User) Clone() *User {
+	clone := *u
+	clone.domainEvents = domainEvents{}
+	return &clone
 }
 
 // IsValid verifica si el usuario es válido según las reglas de negocio
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"hexagonal-example/domain/entities"
+)
+
+// OrderRepository define la interfaz para el repositorio de pedidos
+// Al igual que el resto de puertos, vive en el dominio y la implementan
+// los adaptadores de infraestructura
+type OrderRepository interface {
+	// Save guarda un pedido en el repositorio
+	// Si el pedido ya existe, lo actualiza; si no, lo crea
+	//
+	// Control de concurrencia optimista: un pedido con Version 0 se crea
+	// (ErrOrderAlreadyExists si el ID ya existe); en otro caso la versión debe
+	// coincidir con la almacenada o se retorna ErrOrderVersionConflict
+	// Tras guardar, el repositorio incrementa order.Version
+	Save(ctx context.Context, order *entities.Order) error
+
+	// FindByID busca un pedido por su ID
+	// Retorna nil si no se encuentra
+	FindByID(ctx context.Context, id string) (*entities.Order, error)
+
+	// FindByUserID retorna los pedidos de un usuario
+	FindByUserID(ctx context.Context, userID string, limit, offset int) ([]*entities.Order, error)
+
+	// FindAll retorna todos los pedidos
+	FindAll(ctx context.Context, limit, offset int) ([]*entities.Order, error)
+
+	// Delete elimina un pedido del repositorio
+	Delete(ctx context.Context, id string) error
+
+	// Exists verifica si un pedido existe por ID
+	Exists(ctx context.Context, id string) (bool, error)
+
+	// Count retorna el número total de pedidos
+	Count(ctx context.Context) (int, error)
+}
+
+// OrderRepositoryError define errores específicos del repositorio de pedidos
+type OrderRepositoryError struct {
+	Message string
+	Err     error
+}
+
+func (e *OrderRepositoryError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *OrderRepositoryError) Unwrap() error {
+	return e.Err
+}
+
+// Errores comunes del repositorio de pedidos
+var (
+	ErrOrderNotFound         = &OrderRepositoryError{Message: "order not found"}
+	ErrOrderAlreadyExists    = &OrderRepositoryError{Message: "order already exists"}
+	ErrInvalidOrderData      = &OrderRepositoryError{Message: "invalid order data"}
+	ErrOrderVersionConflict  = &OrderRepositoryError{Message: "order was modified concurrently"}
+	ErrOrderAlreadyCancelled = &OrderRepositoryError{Message: "order is already cancelled"}
+)
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"hexagonal-example/domain/entities"
+)
+
+// ErrOutboxMessageNotFound se retorna al marcar un mensaje que no está pendiente
+var ErrOutboxMessageNotFound = errors.New("outbox message not found")
+
+// OutboxMessage es un evento pendiente de publicar
+// Se guarda en la misma escritura que el cambio que lo produce, de modo que
+// no existe el cambio sin su evento ni el evento sin su cambio
+type OutboxMessage struct {
+	// ID identifica el mensaje; coincide con el ID del evento que contiene
+	ID string `json:"id"`
+
+	// EventType y AggregateID describen el evento sin necesidad de decodificarlo
+	EventType   string `json:"event_type"`
+	AggregateID string `json:"aggregate_id,omitempty"`
+
+	// Payload es el evento serializado, listo para publicar
+	Payload json.RawMessage `json:"payload"`
+
+	// CreatedAt es el momento en que se registró el mensaje
+	CreatedAt time.Time `json:"created_at"`
+
+	// Attempts y LastError describen los intentos de publicación fallidos
+	Attempts  int    `json:"attempts,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// OutboxRepository da acceso a los mensajes pendientes para publicarlos
+// Lo implementan los repositorios que guardan mensajes junto a sus entidades
+type OutboxRepository interface {
+	// Pending retorna hasta limit mensajes pendientes en el orden en que se registraron
+	Pending(ctx context.Context, limit int) ([]*OutboxMessage, error)
+
+	// MarkSent indica que el mensaje se publicó; deja de estar pendiente
+	MarkSent(ctx context.Context, id string) error
+
+	// MarkFailed anota un intento de publicación fallido; el mensaje sigue pendiente
+	MarkFailed(ctx context.Context, id string, reason string) error
+}
+
+// ProductOutboxFunc construye los mensajes que se guardan junto a un producto
+// Recibe el producto tal como queda guardado, con sus eventos de dominio pendientes
+// (en Delete, el eliminado con ProductDeleted registrado)
+// Si retorna un error no se guarda nada
+type ProductOutboxFunc func(product *entities.Product) ([]*OutboxMessage, error)
+
+// productOutboxKey es la clave de contexto de ProductOutboxFunc
+type productOutboxKey struct{}
+
+// WithProductOutbox retorna un contexto con el que las escrituras del repositorio de
+// productos (Save, Update, Delete) guardan también los mensajes que construye build
+// La función se llama en cada escritura, después de aplicar los cambios
+func WithProductOutbox(ctx context.Context, build ProductOutboxFunc) context.Context {
+	return context.WithValue(ctx, productOutboxKey{}, build)
+}
+
+// ProductOutboxMessages construye los mensajes del contexto para un producto
+// Retorna nil si el contexto no tiene ProductOutboxFunc
+func ProductOutboxMessages(ctx context.Context, product *entities.Product) ([]*OutboxMessage, error) {
+	build, _ := ctx.Value(productOutboxKey{}).(ProductOutboxFunc)
+	if build == nil {
+		return nil, nil
+	}
+	return build(product)
+}
+
+// ProductDeleteOutboxMessages construye los mensajes del contexto para la eliminación
+// de un producto. Delete solo recibe el ID, así que el evento ProductDeleted se
+// registra en una copia del producto guardado
+func ProductDeleteOutboxMessages(ctx context.Context, product *entities.Product) ([]*OutboxMessage, error) {
+	deleted := product.Clone()
+	deleted.MarkDeleted()
+	return ProductOutboxMessages(ctx, deleted)
+}
@@ -0,0 +1,288 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hexagonal-example/domain/entities"
+)
+
+// Errores de la paginación con cursor
+var (
+	ErrInvalidPageRequest = errors.New("invalid page request")
+	ErrInvalidCursor      = errors.New("invalid cursor")
+)
+
+// SortField es el campo por el que se ordena una página
+type SortField string
+
+// Campos de ordenación
+// Los usuarios admiten todos salvo SortByPrice
+const (
+	SortByID        SortField = "id"
+	SortByName      SortField = "name"
+	SortByPrice     SortField = "price"
+	SortByCreatedAt SortField = "created_at"
+)
+
+// SortOrder es el orden de una página
+// Los elementos con el mismo valor en Field se ordenan por ID, de modo que el orden
+// es total y las páginas no se solapan. El precio se ordena por moneda y después
+// por importe
+type SortOrder struct {
+	Field      SortField
+	Descending bool
+}
+
+// ParseSortOrder interpreta un orden con el formato "campo" o "campo:asc|desc"
+// Una cadena vacía es el orden por ID ascendente
+func ParseSortOrder(value string) (SortOrder, error) {
+	if value == "" {
+		return SortOrder{Field: SortByID}, nil
+	}
+	field, direction, _ := strings.Cut(value, ":")
+	order := SortOrder{Field: SortField(field)}
+	switch direction {
+	case "", "asc":
+	case "desc":
+		order.Descending = true
+	default:
+		return SortOrder{}, fmt.Errorf("%w: unknown sort direction %q", ErrInvalidPageRequest, direction)
+	}
+	switch order.Field {
+	case SortByID, SortByName, SortByPrice, SortByCreatedAt:
+		return order, nil
+	}
+	return SortOrder{}, fmt.Errorf("%w: unknown sort field %q", ErrInvalidPageRequest, field)
+}
+
+// String retorna el orden con el formato que acepta ParseSortOrder
+func (o SortOrder) String() string {
+	field := o.Field
+	if field == "" {
+		field = SortByID
+	}
+	if o.Descending {
+		return string(field) + ":desc"
+	}
+	return string(field) + ":asc"
+}
+
+// PageRequest pide una página de resultados
+type PageRequest struct {
+	// Sort es el orden de los resultados; el valor cero ordena por ID
+	Sort SortOrder
+
+	// Limit es el número máximo de elementos de la página
+	// Con 0 la página contiene todos los elementos restantes
+	Limit int
+
+	// Cursor es el NextCursor de la página anterior, o vacío para la primera
+	// Un cursor solo es válido con el mismo orden con el que se obtuvo
+	Cursor string
+}
+
+// Page es una página de resultados
+type Page[T any] struct {
+	Items []T `json:"items"`
+
+	// NextCursor pide la página siguiente; está vacío en la última
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	// Total es el número de elementos que cumplen el criterio, en todas las páginas
+	Total int `json:"total"`
+}
+
+// CursorKey es la posición que guarda un cursor: el valor del campo de orden del
+// último elemento de una página y su ID para desempatar
+// Los adaptadores que no ordenan en memoria (SQL) la usan para continuar la consulta
+type CursorKey struct {
+	ID        string
+	Name      string
+	Currency  string
+	Amount    int64
+	CreatedAt time.Time
+}
+
+// ProductCursorKey retorna la posición de un producto
+func ProductCursorKey(product *entities.Product) CursorKey {
+	return CursorKey{
+		ID:        product.ID,
+		Name:      product.Name,
+		Currency:  product.Price.Currency(),
+		Amount:    product.Price.Amount(),
+		CreatedAt: product.CreatedAt,
+	}
+}
+
+// UserCursorKey retorna la posición de un usuario
+func UserCursorKey(user *entities.User) CursorKey {
+	return CursorKey{ID: user.ID, Name: user.Name, CreatedAt: user.CreatedAt}
+}
+
+// cursorData es el contenido codificado de un cursor
+type cursorData struct {
+	Sort      string    `json:"s"`
+	ID        string    `json:"i"`
+	Name      string    `json:"n,omitempty"`
+	Currency  string    `json:"c,omitempty"`
+	Amount    int64     `json:"a,omitempty"`
+	CreatedAt time.Time `json:"t,omitzero"`
+}
+
+// EncodeCursor codifica la posición tras la que empieza la página siguiente
+func EncodeCursor(order SortOrder, key CursorKey) string {
+	data, _ := json.Marshal(cursorData{
+		Sort:      order.String(),
+		ID:        key.ID,
+		Name:      key.Name,
+		Currency:  key.Currency,
+		Amount:    key.Amount,
+		CreatedAt: key.CreatedAt,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodifica el cursor de una petición
+// Retorna nil si la petición no tiene cursor y un error que envuelve
+// ErrInvalidCursor si el cursor está mal formado o es de otro orden
+func DecodeCursor(request PageRequest) (*CursorKey, error) {
+	if request.Cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(request.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var data cursorData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if data.Sort != request.Sort.String() {
+		return nil, fmt.Errorf("%w: cursor was issued for sort order %q", ErrInvalidCursor, data.Sort)
+	}
+	return &CursorKey{
+		ID:        data.ID,
+		Name:      data.Name,
+		Currency:  data.Currency,
+		Amount:    data.Amount,
+		CreatedAt: data.CreatedAt,
+	}, nil
+}
+
+// ValidateProductPageRequest comprueba que una petición de página de productos es válida
+func ValidateProductPageRequest(request PageRequest) error {
+	return validatePageRequest(request, true)
+}
+
+// ValidateUserPageRequest comprueba que una petición de página de usuarios es válida
+func ValidateUserPageRequest(request PageRequest) error {
+	return validatePageRequest(request, false)
+}
+
+// validatePageRequest comprueba el límite, el orden y el cursor de una petición
+func validatePageRequest(request PageRequest, priced bool) error {
+	if request.Limit < 0 {
+		return fmt.Errorf("%w: negative limit", ErrInvalidPageRequest)
+	}
+	switch request.Sort.Field {
+	case "", SortByID, SortByName, SortByCreatedAt:
+	case SortByPrice:
+		if !priced {
+			return fmt.Errorf("%w: cannot sort by %q", ErrInvalidPageRequest, request.Sort.Field)
+		}
+	default:
+		return fmt.Errorf("%w: unknown sort field %q", ErrInvalidPageRequest, request.Sort.Field)
+	}
+	_, err := DecodeCursor(request)
+	return err
+}
+
+// ProductPage ordena productos ya filtrados y extrae la página pedida
+// Es la paginación de los adaptadores que evalúan las consultas en memoria
+// Los elementos de la página son los mismos punteros recibidos
+func ProductPage(products []*entities.Product, request PageRequest) (Page[*entities.Product], error) {
+	if err := ValidateProductPageRequest(request); err != nil {
+		return Page[*entities.Product]{}, err
+	}
+	return page(products, request, ProductCursorKey)
+}
+
+// UserPage ordena usuarios ya filtrados y extrae la página pedida
+// Es la paginación de los adaptadores que evalúan las consultas en memoria
+// Los elementos de la página son los mismos punteros recibidos
+func UserPage(users []*entities.User, request PageRequest) (Page[*entities.User], error) {
+	if err := ValidateUserPageRequest(request); err != nil {
+		return Page[*entities.User]{}, err
+	}
+	return page(users, request, UserCursorKey)
+}
+
+// page ordena items según la petición y retorna los que siguen al cursor
+func page[T any](items []T, request PageRequest, keyOf func(T) CursorKey) (Page[T], error) {
+	after, err := DecodeCursor(request)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	sorted := append([]T(nil), items...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return CompareCursorKeys(request.Sort, keyOf(sorted[i]), keyOf(sorted[j])) < 0
+	})
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(sorted), func(i int) bool {
+			return CompareCursorKeys(request.Sort, keyOf(sorted[i]), *after) > 0
+		})
+	}
+	end := start + request.Limit
+	if request.Limit <= 0 || end > len(sorted) {
+		end = len(sorted)
+	}
+
+	result := Page[T]{Items: append(make([]T, 0, end-start), sorted[start:end]...), Total: len(sorted)}
+	if end < len(sorted) && end > start {
+		result.NextCursor = EncodeCursor(request.Sort, keyOf(sorted[end-1]))
+	}
+	return result, nil
+}
+
+// CompareCursorKeys compara dos posiciones según un orden: negativo si a va antes que b
+func CompareCursorKeys(order SortOrder, a, b CursorKey) int {
+	result := 0
+	switch order.Field {
+	case SortByName:
+		result = strings.Compare(a.Name, b.Name)
+	case SortByPrice:
+		result = strings.Compare(a.Currency, b.Currency)
+		if result == 0 {
+			result = compareInt64(a.Amount, b.Amount)
+		}
+	case SortByCreatedAt:
+		result = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if result == 0 {
+		result = strings.Compare(a.ID, b.ID)
+	}
+	if order.Descending {
+		return -result
+	}
+	return result
+}
+
+// compareInt64 compara dos enteros
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -9,8 +9,19 @@ import (
 // Sigue el mismo patrón que UserRepository pero para productos
 type ProductRepository interface {
 	// Save guarda un producto en el repositorio
+	//
+	// Control de concurrencia optimista: un producto con Version 0 se crea
+	// (ErrProductAlreadyExists si el ID ya existe); en otro caso la versión debe
+	// coincidir con la almacenada o se retorna ErrProductVersionConflict
+	// Tras guardar, el repositorio incrementa product.Version
 	Save(ctx context.Context, product *entities.Product) error
 
+	// Update aplica mutate sobre el producto y lo guarda de forma atómica:
+	// ninguna otra escritura del mismo producto puede intercalarse entre la lectura
+	// y el guardado. Si mutate retorna un error no se guarda nada
+	// Retorna ErrProductNotFound si el producto no existe
+	Update(ctx context.Context, id string, mutate func(*entities.Product) error) (*entities.Product, error)
+
 	// FindByID busca un producto por su ID
 	FindByID(ctx context.Context, id string) (*entities.Product, error)
 
@@ -27,7 +38,18 @@ type ProductRepository interface {
 	FindAvailable(ctx context.Context, limit, offset int) ([]*entities.Product, error)
 
 	// FindByPriceRange busca productos en un rango de precios
-	FindByPriceRange(ctx context.Context, minPrice, maxPrice float64, limit, offset int) ([]*entities.Product, error)
+	// Solo retorna productos cuyo precio está en la moneda del rango
+	FindByPriceRange(ctx context.Context, minPrice, maxPrice entities.Money, limit, offset int) ([]*entities.Product, error)
+
+	// FindMatching retorna los productos que cumplen spec, ordenados por ID
+	// Si spec no es válida para productos retorna un error que envuelve ErrInvalidSpecification
+	FindMatching(ctx context.Context, spec Specification, limit, offset int) ([]*entities.Product, error)
+
+	// FindPage retorna una página de los productos que cumplen spec (nil para todos)
+	// en el orden pedido, con el cursor de la siguiente y el total de coincidencias
+	// Retorna un error que envuelve ErrInvalidSpecification, ErrInvalidPageRequest o
+	// ErrInvalidCursor si la consulta no es válida
+	FindPage(ctx context.Context, spec Specification, request PageRequest) (Page[*entities.Product], error)
 
 	// Delete elimina un producto del repositorio
 	Delete(ctx context.Context, id string) error
@@ -65,4 +87,8 @@ var (
 	ErrProductAlreadyExists = &ProductRepositoryError{Message: "product already exists"}
 	ErrInvalidProductData   = &ProductRepositoryError{Message: "invalid product data"}
 	ErrInsufficientStock    = &ProductRepositoryError{Message: "insufficient stock"}
+	ErrStockBelowReserved   = &ProductRepositoryError{Message: "stock cannot be lower than reserved stock"}
+	ErrProductVersionConflict = &ProductRepositoryError{Message: "product was modified concurrently"}
+	ErrProductNotAvailable  = &ProductRepositoryError{Message: "product not available"}
+	ErrReservationNotFound  = &ProductRepositoryError{Message: "reservation not found"}
 )
\ No newline at end of file
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSearchUnavailable se retorna al buscar texto sin un índice de búsqueda configurado
+var ErrSearchUnavailable = errors.New("product search index not configured")
+
+// ProductSearchHit es un producto encontrado por una búsqueda de texto
+type ProductSearchHit struct {
+	ProductID string
+
+	// Score es la relevancia del producto para la búsqueda; mayor es mejor
+	Score float64
+}
+
+// ProductSearchIndex busca productos por el texto de su nombre, descripción y categoría
+// A diferencia de FindByName no exige coincidencia exacta: ignora mayúsculas y
+// acentos, admite prefijos y pequeñas erratas y ordena los resultados por relevancia
+type ProductSearchIndex interface {
+	// Search retorna hasta limit productos que contienen todos los términos de
+	// query, del más al menos relevante. Una búsqueda sin términos no retorna nada
+	Search(ctx context.Context, query string, limit int) ([]ProductSearchHit, error)
+}
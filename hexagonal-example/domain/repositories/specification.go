@@ -0,0 +1,380 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"hexagonal-example/domain/entities"
+)
+
+// ErrInvalidSpecification indica una especificación que el repositorio no puede
+// evaluar: un campo que la entidad no tiene, un operador que el campo no admite o
+// un valor de otro tipo
+var ErrInvalidSpecification = errors.New("invalid specification")
+
+// Field identifica el campo de una entidad sobre el que se evalúa una condición
+type Field string
+
+// Campos que admiten las especificaciones
+// Los productos admiten todos salvo FieldEmail; los usuarios, FieldID, FieldName,
+// FieldEmail y FieldActive
+const (
+	FieldID             Field = "id"
+	FieldName           Field = "name"
+	FieldCategory       Field = "category"
+	FieldPrice          Field = "price"
+	FieldStock          Field = "stock"
+	FieldAvailableStock Field = "available_stock"
+	FieldActive         Field = "active"
+	FieldEmail          Field = "email"
+)
+
+// Operator es la comparación que aplica una condición
+type Operator string
+
+// Operadores de las condiciones
+// Los campos de texto admiten todos; los numéricos y el precio, todos salvo
+// OpContains; FieldActive solo OpEqual
+const (
+	OpEqual          Operator = "eq"
+	OpGreaterOrEqual Operator = "gte"
+	OpLessOrEqual    Operator = "lte"
+
+	// OpContains busca el valor como subcadena, sin distinguir mayúsculas
+	OpContains Operator = "contains"
+)
+
+// Specification es un criterio de selección que cualquier adaptador de
+// repositorio sabe evaluar: los de memoria y ficheros la comprueban sobre cada
+// entidad y el de SQL la traduce a una cláusula WHERE
+//
+// Se construye con Where y se combina con And, Or y Not. Una especificación nil
+// selecciona todas las entidades
+type Specification interface {
+	isSpecification()
+}
+
+// Condition compara un campo de la entidad con un valor
+// El valor es string para los campos de texto, int para FieldStock y
+// FieldAvailableStock, bool para FieldActive y entities.Money para FieldPrice
+// Una condición de precio no se cumple si el precio está en otra moneda
+type Condition struct {
+	Field    Field
+	Operator Operator
+	Value    interface{}
+}
+
+// AndSpecification se cumple si se cumplen todas sus especificaciones (o no tiene ninguna)
+type AndSpecification struct {
+	Specs []Specification
+}
+
+// OrSpecification se cumple si se cumple alguna de sus especificaciones
+type OrSpecification struct {
+	Specs []Specification
+}
+
+// NotSpecification se cumple si no se cumple Spec
+type NotSpecification struct {
+	Spec Specification
+}
+
+func (Condition) isSpecification()        {}
+func (AndSpecification) isSpecification() {}
+func (OrSpecification) isSpecification()  {}
+func (NotSpecification) isSpecification() {}
+
+// Where crea una condición sobre un campo
+func Where(field Field, operator Operator, value interface{}) Specification {
+	return Condition{Field: field, Operator: operator, Value: value}
+}
+
+// And combina especificaciones que deben cumplirse todas
+// Las especificaciones nil se ignoran
+func And(specs ...Specification) Specification {
+	return AndSpecification{Specs: withoutNil(specs)}
+}
+
+// Or combina especificaciones de las que debe cumplirse al menos una
+// Las especificaciones nil se ignoran
+func Or(specs ...Specification) Specification {
+	return OrSpecification{Specs: withoutNil(specs)}
+}
+
+// Not niega una especificación
+func Not(spec Specification) Specification {
+	return NotSpecification{Spec: spec}
+}
+
+// InCategory selecciona los productos de una categoría
+func InCategory(category string) Specification {
+	return Where(FieldCategory, OpEqual, category)
+}
+
+// PriceBetween selecciona los productos con precio en [minPrice, maxPrice], en la
+// moneda de los límites
+func PriceBetween(minPrice, maxPrice entities.Money) Specification {
+	return And(Where(FieldPrice, OpGreaterOrEqual, minPrice), Where(FieldPrice, OpLessOrEqual, maxPrice))
+}
+
+// IsActive selecciona las entidades activas
+func IsActive() Specification {
+	return Where(FieldActive, OpEqual, true)
+}
+
+// IsAvailable selecciona los productos disponibles (activos y con stock sin reservar)
+// Es el criterio de FindAvailable
+func IsAvailable() Specification {
+	return And(IsActive(), Where(FieldAvailableStock, OpGreaterOrEqual, 1))
+}
+
+// StockAtLeast selecciona los productos con al menos quantity unidades de stock físico
+func StockAtLeast(quantity int) Specification {
+	return Where(FieldStock, OpGreaterOrEqual, quantity)
+}
+
+// NameContains selecciona las entidades cuyo nombre contiene text
+func NameContains(text string) Specification {
+	return Where(FieldName, OpContains, text)
+}
+
+// EmailContains selecciona los usuarios cuyo email contiene text
+func EmailContains(text string) Specification {
+	return Where(FieldEmail, OpContains, text)
+}
+
+// ValidateProductSpecification comprueba que una especificación se puede evaluar
+// sobre productos. Retorna un error que envuelve ErrInvalidSpecification si no
+func ValidateProductSpecification(spec Specification) error {
+	return validate(spec, productFields)
+}
+
+// ValidateUserSpecification comprueba que una especificación se puede evaluar
+// sobre usuarios. Retorna un error que envuelve ErrInvalidSpecification si no
+func ValidateUserSpecification(spec Specification) error {
+	return validate(spec, userFields)
+}
+
+// MatchProduct indica si un producto cumple una especificación
+// La especificación debe haberse validado con ValidateProductSpecification;
+// una condición no válida no se cumple
+func MatchProduct(spec Specification, product *entities.Product) bool {
+	return match(spec, func(field Field) interface{} {
+		switch field {
+		case FieldID:
+			return product.ID
+		case FieldName:
+			return product.Name
+		case FieldCategory:
+			return product.Category
+		case FieldPrice:
+			return product.Price
+		case FieldStock:
+			return product.Stock
+		case FieldAvailableStock:
+			return product.AvailableStock()
+		case FieldActive:
+			return product.IsActive
+		}
+		return nil
+	})
+}
+
+// MatchUser indica si un usuario cumple una especificación
+// La especificación debe haberse validado con ValidateUserSpecification;
+// una condición no válida no se cumple
+func MatchUser(spec Specification, user *entities.User) bool {
+	return match(spec, func(field Field) interface{} {
+		switch field {
+		case FieldID:
+			return user.ID
+		case FieldName:
+			return user.Name
+		case FieldEmail:
+			return user.Email
+		case FieldActive:
+			return user.IsActive
+		}
+		return nil
+	})
+}
+
+// fieldKind es el tipo de valor de un campo
+type fieldKind int
+
+const (
+	textField fieldKind = iota
+	intField
+	boolField
+	moneyField
+)
+
+// productFields y userFields son los campos que admite cada entidad
+var (
+	productFields = map[Field]fieldKind{
+		FieldID:             textField,
+		FieldName:           textField,
+		FieldCategory:       textField,
+		FieldPrice:          moneyField,
+		FieldStock:          intField,
+		FieldAvailableStock: intField,
+		FieldActive:         boolField,
+	}
+	userFields = map[Field]fieldKind{
+		FieldID:     textField,
+		FieldName:   textField,
+		FieldEmail:  textField,
+		FieldActive: boolField,
+	}
+)
+
+// validate comprueba cada condición de spec contra los campos de una entidad
+func validate(spec Specification, fields map[Field]fieldKind) error {
+	switch s := spec.(type) {
+	case nil:
+		return nil
+	case Condition:
+		kind, ok := fields[s.Field]
+		if !ok {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidSpecification, s.Field)
+		}
+		if !operatorAllowed(kind, s.Operator) {
+			return fmt.Errorf("%w: operator %q not supported on %q", ErrInvalidSpecification, s.Operator, s.Field)
+		}
+		if !valueAllowed(kind, s.Value) {
+			return fmt.Errorf("%w: unexpected value %v (%T) for %q", ErrInvalidSpecification, s.Value, s.Value, s.Field)
+		}
+		return nil
+	case AndSpecification:
+		return validateAll(s.Specs, fields)
+	case OrSpecification:
+		return validateAll(s.Specs, fields)
+	case NotSpecification:
+		return validate(s.Spec, fields)
+	default:
+		return fmt.Errorf("%w: unsupported specification %T", ErrInvalidSpecification, spec)
+	}
+}
+
+// validateAll valida una lista de especificaciones
+func validateAll(specs []Specification, fields map[Field]fieldKind) error {
+	for _, spec := range specs {
+		if err := validate(spec, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// operatorAllowed indica si un tipo de campo admite un operador
+func operatorAllowed(kind fieldKind, operator Operator) bool {
+	switch operator {
+	case OpEqual:
+		return true
+	case OpGreaterOrEqual, OpLessOrEqual:
+		return kind != boolField
+	case OpContains:
+		return kind == textField
+	}
+	return false
+}
+
+// valueAllowed indica si un valor es del tipo de un campo
+func valueAllowed(kind fieldKind, value interface{}) bool {
+	switch value.(type) {
+	case string:
+		return kind == textField
+	case int:
+		return kind == intField
+	case bool:
+		return kind == boolField
+	case entities.Money:
+		return kind == moneyField
+	}
+	return false
+}
+
+// match evalúa spec obteniendo el valor de cada campo con value
+func match(spec Specification, value func(Field) interface{}) bool {
+	switch s := spec.(type) {
+	case nil:
+		return true
+	case Condition:
+		return compare(value(s.Field), s.Operator, s.Value)
+	case AndSpecification:
+		for _, spec := range s.Specs {
+			if !match(spec, value) {
+				return false
+			}
+		}
+		return true
+	case OrSpecification:
+		for _, spec := range s.Specs {
+			if match(spec, value) {
+				return true
+			}
+		}
+		return false
+	case NotSpecification:
+		return !match(s.Spec, value)
+	}
+	return false
+}
+
+// compare aplica un operador al valor de un campo y al valor de la condición
+func compare(actual interface{}, operator Operator, expected interface{}) bool {
+	var order int
+	switch a := actual.(type) {
+	case string:
+		e, ok := expected.(string)
+		if !ok {
+			return false
+		}
+		if operator == OpContains {
+			return strings.Contains(strings.ToLower(a), strings.ToLower(e))
+		}
+		order = strings.Compare(a, e)
+	case int:
+		e, ok := expected.(int)
+		if !ok {
+			return false
+		}
+		order = a - e
+	case bool:
+		e, ok := expected.(bool)
+		return ok && operator == OpEqual && a == e
+	case entities.Money:
+		e, ok := expected.(entities.Money)
+		if !ok {
+			return false
+		}
+		var err error
+		if order, err = a.Compare(e); err != nil {
+			return false
+		}
+	default:
+		return false
+	}
+
+	switch operator {
+	case OpEqual:
+		return order == 0
+	case OpGreaterOrEqual:
+		return order >= 0
+	case OpLessOrEqual:
+		return order <= 0
+	}
+	return false
+}
+
+// withoutNil retorna las especificaciones que no son nil
+func withoutNil(specs []Specification) []Specification {
+	result := make([]Specification, 0, len(specs))
+	for _, spec := range specs {
+		if spec != nil {
+			result = append(result, spec)
+		}
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Errores de las transacciones
+var (
+	ErrTransactionInProgress = errors.New("a transaction is already in progress")
+	ErrNoTransaction         = errors.New("no transaction in progress")
+	ErrTransactionDone       = errors.New("transaction already committed or rolled back")
+	ErrRollbackConflict      = errors.New("entity was modified outside the transaction and could not be rolled back")
+	ErrForeignTransaction    = errors.New("context carries a transaction of another unit of work")
+)
+
+// UnitOfWork agrupa las escrituras de varios repositorios en una sola transacción
+// La transacción viaja en el contexto: Begin retorna un contexto que la lleva y
+// las escrituras hechas con él se confirman o se deshacen juntas
+// Cada adaptador de repositorios proporciona su propia implementación, que solo
+// abarca los repositorios de ese adaptador
+type UnitOfWork interface {
+	// Begin inicia una transacción y retorna el contexto que la lleva
+	// Retorna ErrTransactionInProgress si ctx ya tiene una transacción del adaptador
+	Begin(ctx context.Context) (context.Context, error)
+
+	// Commit confirma la transacción del contexto
+	Commit(ctx context.Context) error
+
+	// Rollback deshace las escrituras de la transacción del contexto
+	Rollback(ctx context.Context) error
+}
+
+// Transaction es el estado común a las transacciones de todos los adaptadores
+// Los adaptadores la incluyen en su propia transacción y la guardan en el
+// contexto con WithTransaction para que AfterCommit funcione con cualquiera
+type Transaction struct {
+	// owner es la unidad de trabajo que inició la transacción
+	owner UnitOfWork
+
+	mutex       sync.Mutex
+	done        bool
+	afterCommit []func(ctx context.Context)
+}
+
+// Finish marca la transacción como terminada
+// Retorna ErrTransactionDone si ya se había confirmado o deshecho
+func (t *Transaction) Finish() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.done {
+		return ErrTransactionDone
+	}
+	t.done = true
+	return nil
+}
+
+// Done indica si la transacción ya se confirmó o se deshizo
+func (t *Transaction) Done() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.done
+}
+
+// RunAfterCommit ejecuta las funciones registradas con AfterCommit
+// Los adaptadores lo llaman tras confirmar la transacción
+func (t *Transaction) RunAfterCommit(ctx context.Context) {
+	t.mutex.Lock()
+	hooks := t.afterCommit
+	t.afterCommit = nil
+	t.mutex.Unlock()
+
+	for _, hook := range hooks {
+		hook(ctx)
+	}
+}
+
+// transactionKey es la clave de contexto de Transaction
+type transactionKey struct{}
+
+// WithTransaction retorna un contexto que lleva la transacción indicada, iniciada
+// por owner; RunInTransaction solo une a ella las funciones que usan owner
+func WithTransaction(ctx context.Context, owner UnitOfWork, tx *Transaction) context.Context {
+	tx.owner = owner
+	return context.WithValue(ctx, transactionKey{}, tx)
+}
+
+// InTransaction indica si ctx lleva una transacción en curso
+func InTransaction(ctx context.Context) bool {
+	return transactionFrom(ctx) != nil
+}
+
+// transactionFrom retorna la transacción en curso de ctx, o nil si no hay ninguna
+func transactionFrom(ctx context.Context) *Transaction {
+	tx, _ := ctx.Value(transactionKey{}).(*Transaction)
+	if tx == nil || tx.Done() {
+		return nil
+	}
+	return tx
+}
+
+// AfterCommit registra fn para ejecutarse cuando se confirme la transacción de ctx
+// Si la transacción se deshace, fn no se ejecuta. Retorna false si ctx no tiene una
+// transacción en curso, en cuyo caso fn no se registra
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) bool {
+	tx, _ := ctx.Value(transactionKey{}).(*Transaction)
+	if tx == nil {
+		return false
+	}
+
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	if tx.done {
+		return false
+	}
+	tx.afterCommit = append(tx.afterCommit, fn)
+	return true
+}
+
+// RunInTransaction ejecuta fn dentro de una transacción de uow
+// Si fn retorna un error (o entra en pánico) la transacción se deshace; si no, se
+// confirma. Si ctx ya lleva una transacción de uow, fn se une a ella; si la lleva
+// de otra unidad de trabajo se retorna ErrForeignTransaction sin ejecutar fn, ya que
+// sus escrituras no quedarían en esa transacción ni podría abrirse otra anidada
+func RunInTransaction(ctx context.Context, uow UnitOfWork, fn func(ctx context.Context) error) (err error) {
+	if tx := transactionFrom(ctx); tx != nil {
+		if tx.owner != uow {
+			return ErrForeignTransaction
+		}
+		return fn(ctx)
+	}
+
+	txCtx, err := uow.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if rollbackErr := uow.Rollback(txCtx); rollbackErr != nil && err != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	if err := fn(txCtx); err != nil {
+		return err
+	}
+	committed = true
+	return uow.Commit(txCtx)
+}
@@ -14,6 +14,15 @@ import (
 type UserRepository interface {
 	// Save guarda un usuario en el repositorio
 	// Si el usuario ya existe, lo actualiza; si no, lo crea
+	//
+	// Control de concurrencia optimista: un usuario con Version 0 se crea
+	// (ErrUserAlreadyExists si el ID ya existe); en otro caso la versión debe
+	// coincidir con la almacenada o se retorna ErrUserVersionConflict
+	// Tras guardar, el repositorio incrementa user.Version
+	//
+	// El email es único: si otro usuario tiene el mismo email normalizado se
+	// retorna ErrEmailAlreadyInUse. La comprobación es atómica con la escritura,
+	// así que dos altas simultáneas con el mismo email no pueden tener éxito ambas
 	Save(ctx context.Context, user *entities.User) error
 
 	// FindByID busca un usuario por su ID
@@ -21,6 +30,7 @@ type UserRepository interface {
 	FindByID(ctx context.Context, id string) (*entities.User, error)
 
 	// FindByEmail busca un usuario por su email
+	// El email se compara normalizado (ver entities.NormalizeEmail)
 	// Retorna nil si no se encuentra
 	FindByEmail(ctx context.Context, email string) (*entities.User, error)
 
@@ -31,6 +41,16 @@ type UserRepository interface {
 	// FindActive retorna todos los usuarios activos
 	FindActive(ctx context.Context, limit, offset int) ([]*entities.User, error)
 
+	// FindMatching retorna los usuarios que cumplen spec, ordenados por ID
+	// Si spec no es válida para usuarios retorna un error que envuelve ErrInvalidSpecification
+	FindMatching(ctx context.Context, spec Specification, limit, offset int) ([]*entities.User, error)
+
+	// FindPage retorna una página de los usuarios que cumplen spec (nil para todos)
+	// en el orden pedido, con el cursor de la siguiente y el total de coincidencias
+	// Retorna un error que envuelve ErrInvalidSpecification, ErrInvalidPageRequest o
+	// ErrInvalidCursor si la consulta no es válida
+	FindPage(ctx context.Context, spec Specification, request PageRequest) (Page[*entities.User], error)
+
 	// Delete elimina un usuario del repositorio
 	Delete(ctx context.Context, id string) error
 
@@ -63,4 +83,7 @@ var (
 	ErrUserNotFound    = &UserRepositoryError{Message: "user not found"}
 	ErrUserAlreadyExists = &UserRepositoryError{Message: "user already exists"}
 	ErrInvalidUserData   = &UserRepositoryError{Message: "invalid user data"}
+	ErrEmailAlreadyInUse = &UserRepositoryError{Message: "email already in use"}
+	ErrUserVersionConflict = &UserRepositoryError{Message: "user was modified concurrently"}
+	ErrUserNotActive = &UserRepositoryError{Message: "user is not active"}
 )
\ No newline at end of file
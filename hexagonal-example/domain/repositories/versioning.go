@@ -0,0 +1,49 @@
+package repositories
+
+import "hexagonal-example/domain/entities"
+
+// CheckUserVersion aplica las reglas de concurrencia optimista de UserRepository.Save
+// Lo comparten los adaptadores que no delegan la comprobación en un motor de base de datos
+// stored es el usuario almacenado (nil si no existe) y user el que se quiere guardar
+func CheckUserVersion(stored, user *entities.User) error {
+	if user.Version == 0 {
+		if stored != nil {
+			return ErrUserAlreadyExists
+		}
+		return nil
+	}
+	if stored == nil || stored.Version != user.Version {
+		return ErrUserVersionConflict
+	}
+	return nil
+}
+
+// CheckProductVersion aplica las reglas de concurrencia optimista de ProductRepository.Save
+// stored es el producto almacenado (nil si no existe) y product el que se quiere guardar
+func CheckProductVersion(stored, product *entities.Product) error {
+	if product.Version == 0 {
+		if stored != nil {
+			return ErrProductAlreadyExists
+		}
+		return nil
+	}
+	if stored == nil || stored.Version != product.Version {
+		return ErrProductVersionConflict
+	}
+	return nil
+}
+
+// CheckOrderVersion aplica las reglas de concurrencia optimista de OrderRepository.Save
+// stored es el pedido almacenado (nil si no existe) y order el que se quiere guardar
+func CheckOrderVersion(stored, order *entities.Order) error {
+	if order.Version == 0 {
+		if stored != nil {
+			return ErrOrderAlreadyExists
+		}
+		return nil
+	}
+	if stored == nil || stored.Version != order.Version {
+		return ErrOrderVersionConflict
+	}
+	return nil
+}
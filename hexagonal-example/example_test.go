@@ -2,9 +2,26 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	"hexagonal-example/application/factories"
+	"hexagonal-example/application/services"
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
 	"hexagonal-example/infrastructure/config"
+	"hexagonal-example/infrastructure/events"
+	"hexagonal-example/infrastructure/repositories/eventsourced"
+	"hexagonal-example/infrastructure/repositories/file"
+	"hexagonal-example/infrastructure/repositories/memory"
 )
 
 // TestExample demuestra cómo probar la arquitectura hexagonal
@@ -61,7 +78,7 @@ func TestProductService(t *testing.T) {
 	ctx := context.Background()
 	
 	// Crear un producto
-	product, err := productService.CreateProduct(ctx, "test-product", "Test Product", "Test Description", "Test Category", 99.99, 10)
+	product, err := productService.CreateProduct(ctx, "test-product", "Test Product", "Test Description", "Test Category", entities.MustParseMoney("99.99", "USD"), 10)
 	if err != nil {
 		t.Fatalf("Error creando producto: %v", err)
 	}
@@ -71,8 +88,8 @@ func TestProductService(t *testing.T) {
 		t.Errorf("Expected product ID 'test-product', got '%s'", product.ID)
 	}
 	
-	if product.Price != 99.99 {
-		t.Errorf("Expected price 99.99, got %.2f", product.Price)
+	if product.Price != entities.MustParseMoney("99.99", "USD") {
+		t.Errorf("Expected price 99.99 USD, got %s", product.Price)
 	}
 	
 	if product.Stock != 10 {
@@ -184,4 +201,1679 @@ func TestConcurrentAccess(t *testing.T) {
 	if len(users) < 10 {
 		t.Errorf("Expected at least 10 users, got %d", len(users))
 	}
-}
\ No newline at end of file
+}
+
+// TestHTTPAdapter demuestra el testing del adaptador HTTP
+func TestHTTPAdapter(t *testing.T) {
+	container := config.NewContainer()
+	handler := container.GetHTTPHandler()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Crear un usuario
+	rec := do(http.MethodPost, "/users", `{"id":"http-user","email":"http@example.com","name":"HTTP User"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Email duplicado
+	rec = do(http.MethodPost, "/users", `{"id":"http-user-2","email":"http@example.com","name":"HTTP User"}`)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", rec.Code)
+	}
+
+	// Datos inválidos
+	rec = do(http.MethodPost, "/users", `{"id":"x","email":"invalid","name":""}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+
+	// Usuario inexistente
+	rec = do(http.MethodGet, "/users/missing", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rec.Code)
+	}
+
+	// Crear un producto y retirar más stock del disponible
+	rec = do(http.MethodPost, "/products", `{"id":"http-product","name":"Teclado","category":"Accesorios","price":49.99,"stock":2}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodPost, "/products/http-product/stock/remove", `{"quantity":5}`)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", rec.Code)
+	}
+
+	rec = do(http.MethodPost, "/products/http-product/stock/add", `{"quantity":3}`)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"stock":5`) {
+		t.Errorf("Expected stock 5 in response, got %s", rec.Body.String())
+	}
+
+	// Reservar y fijar el stock por debajo de lo reservado
+	rec = do(http.MethodPost, "/products/http-product/reservations", `{"quantity":4}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodPut, "/products/http-product/stock", `{"stock":1}`)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestFileRepositoryPersistence demuestra que el adaptador de ficheros
+// conserva los datos entre instancias y se recupera de escrituras interrumpidas
+func TestFileRepositoryPersistence(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+
+	repo, err := file.NewUserRepository(dataDir)
+	if err != nil {
+		t.Fatalf("Error abriendo repositorio: %v", err)
+	}
+
+	user, _ := entities.NewUser("file-user", "file@example.com", "File User")
+	if err := repo.Save(ctx, user); err != nil {
+		t.Fatalf("Error guardando usuario: %v", err)
+	}
+
+	// Modificar la copia retornada no debe afectar al repositorio
+	found, _ := repo.FindByID(ctx, "file-user")
+	found.Name = "Changed"
+
+	// Simular una escritura interrumpida dejando un fichero temporal a medias
+	if err := os.WriteFile(filepath.Join(dataDir, "users.json.tmp"), []byte(`[{"id":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := file.NewUserRepository(dataDir)
+	if err != nil {
+		t.Fatalf("Error reabriendo repositorio: %v", err)
+	}
+
+	persisted, err := reopened.FindByID(ctx, "file-user")
+	if err != nil || persisted == nil {
+		t.Fatalf("Expected persisted user, got %v (err: %v)", persisted, err)
+	}
+	if persisted.Name != "File User" {
+		t.Errorf("Expected name 'File User', got '%s'", persisted.Name)
+	}
+
+	if _, err := os.Stat(filepath.Join(dataDir, "users.json.tmp")); !os.IsNotExist(err) {
+		t.Error("Expected stale temp file to be removed on startup")
+	}
+}
+
+// TestContainerFromConfig demuestra la selección de adaptadores por configuración
+func TestContainerFromConfig(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"repository":{"adapter":"memory"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Las variables de entorno sobrescriben el fichero
+	t.Setenv(config.EnvRepositoryAdapter, config.AdapterFile)
+	t.Setenv(config.EnvDataDir, dataDir)
+
+	container, err := config.NewContainerFromConfig(configPath)
+	if err != nil {
+		t.Fatalf("Error creando contenedor: %v", err)
+	}
+	defer container.Close()
+
+	if _, err := container.GetUserService().CreateUser(ctx, "cfg-user", "cfg@example.com", "Config User"); err != nil {
+		t.Fatalf("Error creando usuario: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "users.json")); err != nil {
+		t.Errorf("Expected users.json in data dir: %v", err)
+	}
+
+	// Un adaptador desconocido debe fallar al construir el contenedor
+	t.Setenv(config.EnvRepositoryAdapter, "mongo")
+	if _, err := config.NewContainerFromConfig(configPath); err == nil {
+		t.Error("Expected error for unknown repository adapter")
+	}
+
+	// Un adaptador mal configurado también
+	t.Setenv(config.EnvRepositoryAdapter, config.AdapterFile)
+	t.Setenv(config.EnvDataDir, "")
+	if _, err := config.NewContainerFromConfig(configPath); err == nil {
+		t.Error("Expected error for file adapter without data dir")
+	}
+
+	// Una clave desconocida en el fichero es un error, no el valor por defecto
+	for _, content := range []string{`{"repository":{"adaptor":"file"}}`, `{"repository":{}} {}`} {
+		if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := config.LoadConfig(configPath); err == nil {
+			t.Errorf("Expected error for config file %s", content)
+		}
+	}
+}
+
+// TestOptimisticConcurrency demuestra la detección de actualizaciones perdidas
+func TestOptimisticConcurrency(t *testing.T) {
+	container := config.NewContainer()
+	productService := container.GetProductService()
+	productRepo := container.GetProductRepository()
+	ctx := context.Background()
+
+	if _, err := productService.CreateProduct(ctx, "occ-product", "OCC Product", "", "Test Category", entities.MustParseMoney("10", "USD"), 0); err != nil {
+		t.Fatalf("Error creando producto: %v", err)
+	}
+
+	// Dos lectores obtienen la misma versión; el segundo en guardar debe fallar
+	first, _ := productRepo.FindByID(ctx, "occ-product")
+	second, _ := productRepo.FindByID(ctx, "occ-product")
+
+	first.Name = "First Writer"
+	if err := productRepo.Save(ctx, first); err != nil {
+		t.Fatalf("Error guardando primera copia: %v", err)
+	}
+
+	second.Name = "Second Writer"
+	if err := productRepo.Save(ctx, second); !errors.Is(err, repositories.ErrProductVersionConflict) {
+		t.Errorf("Expected ErrProductVersionConflict, got %v", err)
+	}
+
+	// Las modificaciones concurrentes a través del servicio no se pierden:
+	// cada una se aplica o se reporta como conflicto
+	const workers = 10
+	results := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			_, err := productService.AddStock(ctx, "occ-product", 1)
+			results <- err
+		}()
+	}
+
+	applied := 0
+	for i := 0; i < workers; i++ {
+		err := <-results
+		switch {
+		case err == nil:
+			applied++
+		case !errors.Is(err, repositories.ErrProductVersionConflict):
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+
+	product, _ := productService.GetProduct(ctx, "occ-product")
+	if product.Stock != applied {
+		t.Errorf("Expected stock %d, got %d (lost update)", applied, product.Stock)
+	}
+}
+
+// TestStockReservations demuestra la reserva, confirmación, liberación y
+// expiración de stock
+func TestStockReservations(t *testing.T) {
+	container := config.NewContainer()
+	productService := container.GetProductService()
+	ctx := context.Background()
+
+	if _, err := productService.CreateProduct(ctx, "res-product", "Reserved Product", "", "Test Category", entities.MustParseMoney("10", "USD"), 10); err != nil {
+		t.Fatalf("Error creando producto: %v", err)
+	}
+
+	// Reservar reduce el stock disponible pero no el físico
+	reservation, err := productService.Reserve(ctx, "res-product", 4, time.Minute)
+	if err != nil {
+		t.Fatalf("Error reservando stock: %v", err)
+	}
+	product, _ := productService.GetProduct(ctx, "res-product")
+	if product.Stock != 10 || product.AvailableStock() != 6 {
+		t.Errorf("Expected stock 10 and available 6, got %d and %d", product.Stock, product.AvailableStock())
+	}
+
+	// No se puede reservar más de lo disponible
+	if _, err := productService.Reserve(ctx, "res-product", 7, time.Minute); !errors.Is(err, repositories.ErrInsufficientStock) {
+		t.Errorf("Expected ErrInsufficientStock, got %v", err)
+	}
+
+	// El stock no puede quedar por debajo de lo reservado
+	if _, err := productService.UpdateStock(ctx, "res-product", 3); !errors.Is(err, repositories.ErrStockBelowReserved) {
+		t.Errorf("Expected ErrStockBelowReserved, got %v", err)
+	}
+
+	// Confirmar descuenta el stock físico
+	product, err = productService.Confirm(ctx, "res-product", reservation.ID)
+	if err != nil {
+		t.Fatalf("Error confirmando reserva: %v", err)
+	}
+	if product.Stock != 6 || product.ReservedStock() != 0 {
+		t.Errorf("Expected stock 6 and reserved 0, got %d and %d", product.Stock, product.ReservedStock())
+	}
+	if _, err := productService.Confirm(ctx, "res-product", reservation.ID); !errors.Is(err, repositories.ErrReservationNotFound) {
+		t.Errorf("Expected ErrReservationNotFound, got %v", err)
+	}
+
+	// Liberar devuelve el stock al disponible
+	reservation, _ = productService.Reserve(ctx, "res-product", 6, time.Minute)
+	product, err = productService.Release(ctx, "res-product", reservation.ID)
+	if err != nil {
+		t.Fatalf("Error liberando reserva: %v", err)
+	}
+	if product.AvailableStock() != 6 {
+		t.Errorf("Expected available 6, got %d", product.AvailableStock())
+	}
+
+	// Las reservas expiradas devuelven el stock automáticamente
+	if _, err := productService.Reserve(ctx, "res-product", 6, 10*time.Millisecond); err != nil {
+		t.Fatalf("Error reservando stock: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	released, err := productService.ReleaseExpiredReservations(ctx)
+	if err != nil {
+		t.Fatalf("Error liberando reservas expiradas: %v", err)
+	}
+	product, _ = productService.GetProduct(ctx, "res-product")
+	if released != 1 || product.AvailableStock() != 6 || len(product.Reservations) != 0 {
+		t.Errorf("Expected expired reservation to be released, got %d released and %d available", released, product.AvailableStock())
+	}
+}
+
+// TestOrders demuestra que un pedido descuenta el stock de todas sus líneas o de
+// ninguna, y que cancelarlo lo devuelve
+func TestOrders(t *testing.T) {
+	container := config.NewContainer()
+	userService := container.GetUserService()
+	productService := container.GetProductService()
+	orderService := container.GetOrderService()
+	ctx := context.Background()
+
+	userService.CreateUser(ctx, "order-user", "buyer@example.com", "Buyer")
+	productService.CreateProduct(ctx, "order-p1", "Keyboard", "", "Accesorios", entities.MustParseMoney("50", "USD"), 5)
+	productService.CreateProduct(ctx, "order-p2", "Monitor", "", "Electrónicos", entities.MustParseMoney("200", "USD"), 1)
+
+	// Un pedido válido descuenta el stock y toma el precio del producto
+	order, err := orderService.CreateOrder(ctx, "order-1", "order-user", []services.OrderLine{
+		{ProductID: "order-p1", Quantity: 2},
+		{ProductID: "order-p2", Quantity: 1},
+	})
+	if err != nil {
+		t.Fatalf("Error creando pedido: %v", err)
+	}
+	if order.Total() != entities.MustParseMoney("300", "USD") {
+		t.Errorf("Expected total 300 USD, got %s", order.Total())
+	}
+	p1, _ := productService.GetProduct(ctx, "order-p1")
+	if p1.Stock != 3 {
+		t.Errorf("Expected stock 3, got %d", p1.Stock)
+	}
+
+	// Si una línea no tiene stock, no se descuenta ninguna
+	_, err = orderService.CreateOrder(ctx, "order-2", "order-user", []services.OrderLine{
+		{ProductID: "order-p1", Quantity: 1},
+		{ProductID: "order-p2", Quantity: 1},
+	})
+	if !errors.Is(err, repositories.ErrInsufficientStock) {
+		t.Errorf("Expected ErrInsufficientStock, got %v", err)
+	}
+	p1, _ = productService.GetProduct(ctx, "order-p1")
+	if p1.Stock != 3 || p1.ReservedStock() != 0 {
+		t.Errorf("Expected stock 3 and no reservations, got %d and %d", p1.Stock, p1.ReservedStock())
+	}
+
+	// Un pedido con productos en monedas distintas se deshace en su transacción
+	productService.CreateProduct(ctx, "order-p3", "Mouse", "", "Accesorios", entities.MustParseMoney("20", "EUR"), 5)
+	_, err = orderService.CreateOrder(ctx, "order-2", "order-user", []services.OrderLine{
+		{ProductID: "order-p1", Quantity: 1},
+		{ProductID: "order-p3", Quantity: 1},
+	})
+	var validationErr *services.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+	p1, _ = productService.GetProduct(ctx, "order-p1")
+	p3, _ := productService.GetProduct(ctx, "order-p3")
+	if p1.ReservedStock() != 0 || p3.ReservedStock() != 0 {
+		t.Errorf("Expected no reservations, got %d and %d", p1.ReservedStock(), p3.ReservedStock())
+	}
+
+	// Solo los usuarios activos pueden hacer pedidos
+	userService.DeactivateUser(ctx, "order-user")
+	_, err = orderService.CreateOrder(ctx, "order-3", "order-user", []services.OrderLine{{ProductID: "order-p1", Quantity: 1}})
+	if !errors.Is(err, repositories.ErrUserNotActive) {
+		t.Errorf("Expected ErrUserNotActive, got %v", err)
+	}
+
+	// Cancelar devuelve el stock
+	if _, err := orderService.CancelOrder(ctx, "order-1"); err != nil {
+		t.Fatalf("Error cancelando pedido: %v", err)
+	}
+	p1, _ = productService.GetProduct(ctx, "order-p1")
+	if p1.Stock != 5 {
+		t.Errorf("Expected stock 5 after cancel, got %d", p1.Stock)
+	}
+	if _, err := orderService.CancelOrder(ctx, "order-1"); !errors.Is(err, repositories.ErrOrderAlreadyCancelled) {
+		t.Errorf("Expected ErrOrderAlreadyCancelled, got %v", err)
+	}
+}
+
+// TestMoney demuestra la aritmética exacta de importes, los decimales de cada
+// moneda y la compatibilidad con los JSON de precio sin moneda
+func TestMoney(t *testing.T) {
+	// Los importes se suman sin errores de redondeo
+	total := entities.MustParseMoney("0", "USD")
+	for i := 0; i < 3; i++ {
+		total, _ = total.Add(entities.MustParseMoney("0.10", "USD"))
+	}
+	if total != entities.MustParseMoney("0.30", "USD") {
+		t.Errorf("Expected 0.30 USD, got %s", total)
+	}
+	if _, err := total.Add(entities.MustParseMoney("1", "EUR")); !errors.Is(err, entities.ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+	if _, err := entities.ParseMoney("1.999", "USD"); err == nil {
+		t.Error("Expected error for amount with too many decimals")
+	}
+	if yen := entities.MustParseMoney("1500", "JPY"); yen.Amount() != 1500 {
+		t.Errorf("Expected 1500 minor units for JPY, got %d", yen.Amount())
+	}
+
+	// Los JSON anteriores (precio float sin moneda) se siguen leyendo
+	var legacy entities.Product
+	if err := json.Unmarshal([]byte(`{"id":"p1","name":"Legacy","price":12.5,"stock":1}`), &legacy); err != nil {
+		t.Fatalf("Error decodificando producto heredado: %v", err)
+	}
+	if legacy.Price != entities.MustParseMoney("12.50", "USD") {
+		t.Errorf("Expected 12.50 USD, got %s", legacy.Price)
+	}
+
+	// El precio se serializa como número y la moneda en un campo aparte
+	product, _ := entities.NewProduct("p2", "Euro", "", "Test", entities.MustParseMoney("19.90", "EUR"), 1)
+	data, _ := json.Marshal(product)
+	if !strings.Contains(string(data), `"price":19.90`) || !strings.Contains(string(data), `"currency":"EUR"`) {
+		t.Errorf("Unexpected product JSON: %s", data)
+	}
+	var decoded entities.Product
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Price != product.Price {
+		t.Errorf("Expected round trip of %s, got %s (%v)", product.Price, decoded.Price, err)
+	}
+
+	// Un importe suelto, como el total de un pedido, conserva su moneda
+	order, _ := entities.NewOrder("o1", "u1", []entities.OrderItem{{ProductID: "p3", Quantity: 2, UnitPrice: entities.MustParseMoney("1500", "JPY")}})
+	for _, money := range []entities.Money{entities.MustParseMoney("19.90", "EUR"), order.Total()} {
+		data, _ := json.Marshal(money)
+		var decoded entities.Money
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded != money {
+			t.Errorf("Expected round trip of %s, got %s from %s (%v)", money, decoded, data, err)
+		}
+	}
+
+	// El valor cero es 0 en la moneda por defecto, también al compararlo con ==
+	if zero, _ := entities.NewMoney(0, entities.DefaultCurrency); zero != (entities.Money{}) {
+		t.Errorf("Expected NewMoney(0, %s) to equal the zero value", entities.DefaultCurrency)
+	}
+	if sum, _ := entities.MustParseMoney("1", "usd").Add(entities.Money{}); sum != entities.MustParseMoney("1", "USD") {
+		t.Errorf("Expected 1 USD, got %s", sum)
+	}
+}
+
+// TestAsyncEventBus demuestra la entrega en segundo plano y el backpressure
+func TestAsyncEventBus(t *testing.T) {
+	ctx := context.Background()
+	bus, err := events.NewAsyncEventBus(events.AsyncOptions{QueueSize: 16, Workers: 4})
+	if err != nil {
+		t.Fatalf("Error creando bus: %v", err)
+	}
+
+	// Los eventos de un mismo tipo se entregan en orden aunque haya varios workers
+	var received []int
+	bus.Subscribe("test.ordered", events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+		received = append(received, envelope.Payload.(int))
+		return nil
+	}))
+	for i := 0; i < 10; i++ {
+		if err := bus.Publish(ctx, "test.ordered", i); err != nil {
+			t.Fatalf("Error publicando: %v", err)
+		}
+	}
+
+	// Close entrega lo que queda en cola antes de detener los workers
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Error cerrando bus: %v", err)
+	}
+	if len(received) != 10 {
+		t.Fatalf("Expected 10 events delivered, got %d", len(received))
+	}
+	for i, n := range received {
+		if n != i {
+			t.Fatalf("Expected events in order, got %v", received)
+		}
+	}
+	if err := bus.Publish(ctx, "test.ordered", 10); !errors.Is(err, events.ErrBusClosed) {
+		t.Errorf("Expected ErrBusClosed, got %v", err)
+	}
+
+	// Con la cola llena, la política error rechaza el evento y drop lo descarta
+	for _, policy := range []events.Backpressure{events.BackpressureError, events.BackpressureDrop} {
+		bus, _ := events.NewAsyncEventBus(events.AsyncOptions{QueueSize: 1, Workers: 1, Backpressure: policy})
+		release := make(chan struct{})
+		bus.Subscribe("test.slow", events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+			<-release
+			return nil
+		}))
+
+		var lastErr error
+		for i := 0; i < 3; i++ {
+			lastErr = bus.Publish(ctx, "test.slow", i)
+		}
+		switch policy {
+		case events.BackpressureError:
+			if !errors.Is(lastErr, events.ErrQueueFull) {
+				t.Errorf("Expected ErrQueueFull, got %v", lastErr)
+			}
+		case events.BackpressureDrop:
+			if lastErr != nil || bus.Stats().Dropped == 0 {
+				t.Errorf("Expected dropped event without error, got %v (%+v)", lastErr, bus.Stats())
+			}
+		}
+		close(release)
+		bus.Close()
+	}
+
+	// Un handler que publica en su propia cola llena no bloquea al worker
+	bus, _ = events.NewAsyncEventBus(events.AsyncOptions{QueueSize: 1, Workers: 1, Backpressure: events.BackpressureBlock})
+	publishErrs := make(chan error, 2)
+	bus.Subscribe("test.fanout", events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+		for i := 0; i < 2; i++ {
+			publishErrs <- bus.Publish(ctx, "test.child", i)
+		}
+		return nil
+	}))
+	bus.Publish(ctx, "test.fanout", nil)
+	drainCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	if err := bus.Drain(drainCtx); err != nil {
+		t.Fatalf("Expected the worker to finish, got %v", err)
+	}
+	cancel()
+	if err := <-publishErrs; err != nil {
+		t.Errorf("Expected the first child event to be queued, got %v", err)
+	}
+	if err := <-publishErrs; !errors.Is(err, events.ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull for the second child event, got %v", err)
+	}
+	bus.Close()
+
+	// El adaptador async se elige por configuración
+	t.Setenv(config.EnvEventBusAdapter, config.AdapterAsync)
+	t.Setenv(config.EnvEventBusBackpressure, "sideways")
+	if _, err := config.NewContainerFromConfig(""); err == nil {
+		t.Error("Expected error for unknown backpressure policy")
+	}
+	t.Setenv(config.EnvEventBusBackpressure, "block")
+	container, err := config.NewContainerFromConfig("")
+	if err != nil {
+		t.Fatalf("Error creando contenedor: %v", err)
+	}
+	if _, ok := container.GetEventBus().(*events.AsyncEventBus); !ok {
+		t.Errorf("Expected AsyncEventBus, got %T", container.GetEventBus())
+	}
+	container.Close()
+}
+
+// TestEventDeadLetters demuestra los reintentos y el reenvío de entregas fallidas
+func TestEventDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	bus := events.NewInMemoryEventBusWithOptions(events.DeliveryOptions{
+		Retry: events.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	})
+
+	// Un handler que falla siempre agota los reintentos y acaba en el dead-letter
+	healthy := false
+	calls := 0
+	bus.Subscribe("test.notify", events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+		calls++
+		if !healthy {
+			return errors.New("smtp unavailable")
+		}
+		return nil
+	}))
+	if err := bus.Publish(ctx, "test.notify", "hello"); err != nil {
+		t.Fatalf("Expected failed delivery to be dead-lettered, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+
+	letters, _ := bus.DeadLetters(ctx)
+	if len(letters) != 1 || letters[0].Attempts != 3 || letters[0].Error != "smtp unavailable" {
+		t.Fatalf("Unexpected dead letters: %+v", letters)
+	}
+
+	// Mientras el handler siga fallando, el reenvío acumula intentos
+	if err := bus.Redeliver(ctx, letters[0].ID); err == nil {
+		t.Error("Expected redelivery to fail while handler is unhealthy")
+	}
+	if letters, _ = bus.DeadLetters(ctx); letters[0].Attempts != 6 {
+		t.Errorf("Expected 6 attempts after redelivery, got %d", letters[0].Attempts)
+	}
+
+	// Cuando el handler se recupera, el reenvío lo saca del dead-letter
+	healthy = true
+	if delivered, err := bus.RedeliverAll(ctx); err != nil || delivered != 1 {
+		t.Fatalf("Expected 1 redelivered event, got %d (%v)", delivered, err)
+	}
+	if letters, _ = bus.DeadLetters(ctx); len(letters) != 0 {
+		t.Errorf("Expected empty dead-letter queue, got %d", len(letters))
+	}
+	if err := bus.Redeliver(ctx, "dl_missing"); !errors.Is(err, events.ErrDeadLetterNotFound) {
+		t.Errorf("Expected ErrDeadLetterNotFound, got %v", err)
+	}
+
+	// El reenvío llega solo al handler que falló, aunque el almacén no conserve
+	// más que los campos serializables de la entrega
+	bus = events.NewInMemoryEventBusWithOptions(events.DeliveryOptions{
+		Retry:       events.RetryPolicy{MaxAttempts: 1},
+		DeadLetters: &jsonDeadLetterStore{InMemoryDeadLetterStore: events.NewInMemoryDeadLetterStore(0)},
+	})
+	okCalls, failingCalls := 0, 0
+	bus.Subscribe("test.split", events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+		okCalls++
+		return nil
+	}))
+	failing := bus.Subscribe("test.split", events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+		failingCalls++
+		return errors.New("down")
+	}))
+	bus.Publish(ctx, "test.split", nil)
+	letters, _ = bus.DeadLetters(ctx)
+	if len(letters) != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d", len(letters))
+	}
+	bus.Redeliver(ctx, letters[0].ID)
+	if okCalls != 1 || failingCalls != 2 {
+		t.Errorf("Expected only the failed handler to be retried, got %d and %d calls", okCalls, failingCalls)
+	}
+
+	// Si el handler que falló ya no está suscrito, la entrega se conserva
+	failing.Unsubscribe()
+	if err := bus.Redeliver(ctx, letters[0].ID); !errors.Is(err, events.ErrHandlerNotSubscribed) {
+		t.Errorf("Expected ErrHandlerNotSubscribed, got %v", err)
+	}
+	if letters, _ = bus.DeadLetters(ctx); len(letters) != 1 || okCalls != 1 {
+		t.Errorf("Expected the dead letter to be kept and no other delivery, got %d letters and %d calls", len(letters), okCalls)
+	}
+
+	// Los servicios informan de los errores de publicación en lugar de ignorarlos
+	asyncBus, _ := events.NewAsyncEventBus(events.AsyncOptions{})
+	asyncBus.Close()
+	var publishErr error
+	factory := factories.NewServiceFactory(memory.NewUserRepository(), memory.NewProductRepository(), memory.NewOrderRepository(), asyncBus)
+	userService := factory.CreateUserService().WithPublishErrorHandler(func(ctx context.Context, err error) {
+		publishErr = err
+	})
+	if _, err := userService.CreateUser(ctx, "dl-user", "dl@example.com", "DL User"); err != nil {
+		t.Fatalf("Expected user to be created despite publish error, got %v", err)
+	}
+	if !errors.Is(publishErr, events.ErrBusClosed) {
+		t.Errorf("Expected ErrBusClosed to be reported, got %v", publishErr)
+	}
+}
+
+// jsonDeadLetterStore simula un almacén persistente: guarda las entregas
+// fallidas serializadas, así que no conserva el handler que falló
+type jsonDeadLetterStore struct {
+	*events.InMemoryDeadLetterStore
+}
+
+// Add guarda la entrega tal como quedaría tras leerla de JSON
+func (s *jsonDeadLetterStore) Add(ctx context.Context, letter events.DeadLetter) error {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+	var stored events.DeadLetter
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	return s.InMemoryDeadLetterStore.Add(ctx, stored)
+}
+
+// TestSubscriptions demuestra cómo desuscribir handlers con su suscripción
+func TestSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	bus := events.NewInMemoryEventBus()
+
+	received := 0
+	handler := events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+		received++
+		return nil
+	})
+
+	// Dos suscripciones del mismo closure se eliminan de forma independiente
+	first := bus.Subscribe("test.sub", handler)
+	second := bus.Subscribe("test.sub", handler)
+	bus.Publish(ctx, "test.sub", nil)
+	first.Unsubscribe()
+	first.Unsubscribe()
+	bus.Publish(ctx, "test.sub", nil)
+	second.Close()
+	bus.Publish(ctx, "test.sub", nil)
+	if received != 3 {
+		t.Errorf("Expected 3 deliveries, got %d", received)
+	}
+
+	// Una suscripción ligada a un contexto se elimina sola cuando este termina
+	scoped, cancel := context.WithCancel(ctx)
+	subscription := bus.SubscribeContext(scoped, "test.sub", handler)
+	cancel()
+	select {
+	case <-subscription.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected subscription to end with its context")
+	}
+	bus.Publish(ctx, "test.sub", nil)
+	if received != 3 {
+		t.Errorf("Expected no deliveries after context ended, got %d", received-3)
+	}
+}
+
+// TestWildcardSubscriptions demuestra las suscripciones por patrón
+func TestWildcardSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	bus := events.NewInMemoryEventBus()
+
+	received := map[string][]string{}
+	record := func(name string) events.EventHandler {
+		return events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+			received[name] = append(received[name], envelope.Payload.(string))
+			return nil
+		})
+	}
+	bus.Subscribe("product.*", record("product.*"))
+	bus.Subscribe("product.**", record("product.**"))
+	bus.Subscribe("*.created", record("*.created"))
+	all := bus.Subscribe(events.AllEvents, record("all"))
+
+	for _, eventType := range []string{"product.created", "product.stock.updated", "user.created", "order.cancelled"} {
+		bus.Publish(ctx, eventType, eventType)
+	}
+
+	expected := map[string][]string{
+		"product.*":  {"product.created"},
+		"product.**": {"product.created", "product.stock.updated"},
+		"*.created":  {"product.created", "user.created"},
+		"all":        {"product.created", "product.stock.updated", "user.created", "order.cancelled"},
+	}
+	for name, want := range expected {
+		if strings.Join(received[name], ",") != strings.Join(want, ",") {
+			t.Errorf("%s: expected %v, got %v", name, want, received[name])
+		}
+	}
+
+	// Las suscripciones por patrón se eliminan igual que las exactas
+	all.Unsubscribe()
+	bus.Publish(ctx, "order.created", "order.created")
+	if len(received["all"]) != 4 || len(received["*.created"]) != 3 {
+		t.Errorf("Unexpected deliveries after unsubscribe: %v", received)
+	}
+
+	// Dar de baja un patrón y volver a suscribirlo cambia solo los tipos que casan
+	stock := bus.Subscribe("product.stock.*", record("product.stock.*"))
+	bus.Publish(ctx, "product.stock.updated", "first")
+	stock.Unsubscribe()
+	bus.Publish(ctx, "product.stock.updated", "second")
+	bus.Subscribe("product.stock.*", record("product.stock.*"))
+	bus.Publish(ctx, "product.stock.updated", "third")
+	if got := strings.Join(received["product.stock.*"], ","); got != "first,third" {
+		t.Errorf("Expected first,third for product.stock.*, got %s", got)
+	}
+	if len(received["product.**"]) != 5 {
+		t.Errorf("Expected 5 deliveries for product.**, got %v", received["product.**"])
+	}
+
+	if err := events.ValidatePattern("product.**.updated"); err == nil {
+		t.Error("Expected error for ** in the middle of a pattern")
+	}
+	if !events.MatchPattern("*.stock.*", "product.stock.updated") || events.MatchPattern("product.*", "product") {
+		t.Error("Unexpected MatchPattern result")
+	}
+}
+
+// TestTypedEvents demuestra la API genérica de publicación y suscripción
+func TestTypedEvents(t *testing.T) {
+	ctx := context.Background()
+	bus := events.NewInMemoryEventBusWithOptions(events.DeliveryOptions{
+		Retry: events.RetryPolicy{MaxAttempts: 1},
+	})
+
+	var created []string
+	events.Subscribe(bus, func(ctx context.Context, event events.UserCreatedEvent) error {
+		created = append(created, event.UserID)
+		return nil
+	})
+
+	// El tipo de evento se deriva del struct publicado
+	if events.TypeOf[events.StockUpdatedEvent]() != "product.stock.updated" {
+		t.Errorf("Unexpected event type %q", events.TypeOf[events.StockUpdatedEvent]())
+	}
+	events.Publish(ctx, bus, events.UserCreatedEvent{UserID: "u1"})
+	bus.Publish(ctx, "user.created", &events.UserCreatedEvent{UserID: "u2"})
+	if strings.Join(created, ",") != "u1,u2" {
+		t.Errorf("Expected u1,u2, got %v", created)
+	}
+
+	// Un valor de otro tipo no se ignora en silencio: la entrega falla
+	bus.Publish(ctx, "user.created", events.UserDeletedEvent{UserID: "u3"})
+	letters, _ := bus.DeadLetters(ctx)
+	if len(letters) != 1 || !strings.Contains(letters[0].Error, "expected events.UserCreatedEvent") {
+		t.Errorf("Expected dead letter for mismatched event, got %+v", letters)
+	}
+}
+
+// TestEventEnvelope demuestra los metadatos con los que se publican los eventos
+func TestEventEnvelope(t *testing.T) {
+	container := config.NewContainer()
+	bus := container.GetEventBus()
+
+	var received []events.Envelope
+	bus.Subscribe(events.AllEvents, events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+		received = append(received, envelope)
+		return nil
+	}))
+
+	// Un handler que publica otro evento lo enlaza como causa
+	events.Subscribe(bus, func(ctx context.Context, event events.UserCreatedEvent) error {
+		envelope, _ := events.EnvelopeFromContext(ctx)
+		if envelope.AggregateID != event.UserID {
+			t.Errorf("Expected aggregate %s, got %s", event.UserID, envelope.AggregateID)
+		}
+		return bus.Publish(ctx, "test.welcome.sent", event.UserID)
+	})
+
+	// El ID de correlación se toma de la cabecera de la petición HTTP
+	server := httptest.NewServer(container.GetHTTPHandler())
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/users",
+		strings.NewReader(`{"id":"env-user","email":"env@example.com","name":"Envelope User"}`))
+	req.Header.Set("X-Correlation-ID", "corr-123")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error en la petición: %v", err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("X-Correlation-ID") != "corr-123" {
+		t.Errorf("Expected correlation header to be echoed, got %q", resp.Header.Get("X-Correlation-ID"))
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(received))
+	}
+	created, welcome := received[0], received[1]
+	if created.Type != "user.created" || created.AggregateID != "env-user" || created.SchemaVersion != events.DefaultSchemaVersion {
+		t.Errorf("Unexpected envelope: %+v", created)
+	}
+	if created.ID == "" || created.OccurredAt.IsZero() || created.CorrelationID != "corr-123" || created.CausationID != "" {
+		t.Errorf("Unexpected envelope metadata: %+v", created)
+	}
+	if welcome.CorrelationID != "corr-123" || welcome.CausationID != created.ID {
+		t.Errorf("Expected welcome event caused by %s, got %+v", created.ID, welcome)
+	}
+}
+
+// TestEventStoreReplay demuestra la reproducción de eventos para suscriptores tardíos
+func TestEventStoreReplay(t *testing.T) {
+	ctx := context.Background()
+	storePath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	store, err := events.NewFileEventStore(storePath)
+	if err != nil {
+		t.Fatalf("Error abriendo almacén: %v", err)
+	}
+	bus := events.NewInMemoryEventBusWithOptions(events.DeliveryOptions{Store: store})
+
+	// Los eventos se guardan aunque nadie esté suscrito
+	for _, id := range []string{"u1", "u2", "u3"} {
+		events.Publish(ctx, bus, events.UserCreatedEvent{UserID: id})
+	}
+	events.Publish(ctx, bus, events.ProductCreatedEvent{ProductID: "p1", Price: entities.MustParseMoney("1500", "JPY"), Currency: "JPY"})
+
+	// Simular una caída a mitad de escritura: la línea incompleta se descarta al reabrir
+	f, _ := os.OpenFile(storePath, os.O_WRONLY|os.O_APPEND, 0o644)
+	f.WriteString(`{"id":"evt_torn","type":"user.cre`)
+	f.Close()
+
+	store, err = events.NewFileEventStore(storePath)
+	if err != nil {
+		t.Fatalf("Error reabriendo almacén: %v", err)
+	}
+	if last, _ := store.LastPosition(ctx); last != 4 {
+		t.Fatalf("Expected 4 stored events, got %d", last)
+	}
+	bus = events.NewInMemoryEventBusWithOptions(events.DeliveryOptions{Store: store})
+
+	// Una proyección nueva reconstruye su estado reproduciendo el histórico
+	var users []string
+	projection := events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+		users = append(users, envelope.Payload.(events.UserCreatedEvent).UserID)
+		return nil
+	})
+	last, err := bus.Replay(ctx, "user.*", 0, projection)
+	if err != nil || last != 4 || strings.Join(users, ",") != "u1,u2,u3" {
+		t.Fatalf("Unexpected replay: %v (last %d, err %v)", users, last, err)
+	}
+
+	// SubscribeFrom continúa desde una posición y después recibe los eventos nuevos
+	users = nil
+	subscription, err := bus.SubscribeFrom(ctx, "user.created", 1, projection)
+	if err != nil {
+		t.Fatalf("Error suscribiendo desde posición: %v", err)
+	}
+	defer subscription.Unsubscribe()
+	events.Publish(ctx, bus, events.UserCreatedEvent{UserID: "u4"})
+	if strings.Join(users, ",") != "u2,u3,u4" {
+		t.Errorf("Expected u2,u3,u4, got %v", users)
+	}
+
+	// Los payloads se reconstruyen con su tipo, incluidos los importes en su moneda
+	stored, _ := store.Load(ctx, 3, 1)
+	if created, ok := stored[0].Payload.(events.ProductCreatedEvent); !ok || created.Price != entities.MustParseMoney("1500", "JPY") {
+		t.Errorf("Unexpected stored payload: %#v", stored[0].Payload)
+	}
+
+	// Un evento retenido durante la reproducción se entrega una sola vez, aunque
+	// después vuelva a publicarse con su posición
+	late := &lateEventStore{EventStore: events.NewInMemoryEventStore()}
+	lateBus := events.NewInMemoryEventBusWithOptions(events.DeliveryOptions{Store: late})
+	events.Publish(ctx, lateBus, events.UserCreatedEvent{UserID: "u1"})
+	late.onDrained = func() {
+		events.Publish(ctx, lateBus, events.UserCreatedEvent{UserID: "u2"})
+	}
+	users = nil
+	lateSubscription, err := lateBus.SubscribeFrom(ctx, "user.created", 0, projection)
+	if err != nil {
+		t.Fatalf("Error suscribiendo con eventos retenidos: %v", err)
+	}
+	defer lateSubscription.Unsubscribe()
+	buffered, _ := late.Load(ctx, 1, 1)
+	lateBus.PublishEnvelope(ctx, buffered[0])
+	if strings.Join(users, ",") != "u1,u2" {
+		t.Errorf("Expected u1,u2, got %v", users)
+	}
+
+	if _, err := events.NewInMemoryEventBusWithOptions(events.DeliveryOptions{}).Replay(ctx, events.AllEvents, 0, projection); !errors.Is(err, events.ErrNoEventStore) {
+		t.Errorf("Expected ErrNoEventStore, got %v", err)
+	}
+}
+
+// lateEventStore simula un evento publicado justo cuando la reproducción termina
+// de leer el almacén: llama una vez a onDrained al leer un lote vacío
+type lateEventStore struct {
+	events.EventStore
+	onDrained func()
+}
+
+// Load lee del almacén y, si no quedan eventos, ejecuta onDrained
+func (s *lateEventStore) Load(ctx context.Context, after uint64, limit int) ([]events.Envelope, error) {
+	batch, err := s.EventStore.Load(ctx, after, limit)
+	if len(batch) == 0 && s.onDrained != nil {
+		onDrained := s.onDrained
+		s.onDrained = nil
+		onDrained()
+	}
+	return batch, err
+}
+
+// TestTransactionalOutbox verifica que los eventos se guardan con el producto y los publica el relay
+func TestTransactionalOutbox(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+
+	productRepo, err := file.NewProductRepository(dataDir)
+	if err != nil {
+		t.Fatalf("Error abriendo repositorio: %v", err)
+	}
+	bus := events.NewInMemoryEventBus()
+	var delivered []events.Envelope
+	bus.Subscribe(events.AllEvents, events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+		delivered = append(delivered, envelope)
+		return nil
+	}))
+
+	factory := factories.NewServiceFactory(memory.NewUserRepository(), productRepo, memory.NewOrderRepository(), bus).WithOutbox()
+	productService := factory.CreateProductService()
+
+	// El evento queda en el outbox junto al producto; nada se publica todavía
+	if _, err := productService.CreateProduct(ctx, "prod-1", "Laptop", "Portátil", "Electrónicos", entities.MustParseMoney("999.99", "EUR"), 5); err != nil {
+		t.Fatalf("Error creando producto: %v", err)
+	}
+	if _, err := productService.RemoveStock(ctx, "prod-1", 10); err == nil {
+		t.Fatal("Expected insufficient stock error")
+	}
+	if _, err := productService.AddStock(ctx, "prod-1", 3); err != nil {
+		t.Fatalf("Error añadiendo stock: %v", err)
+	}
+	if len(delivered) != 0 {
+		t.Fatalf("Expected no events before relaying, got %d", len(delivered))
+	}
+
+	// Los mensajes pendientes sobreviven a un reinicio; la escritura fallida no dejó ninguno
+	productRepo, err = file.NewProductRepository(dataDir)
+	if err != nil {
+		t.Fatalf("Error reabriendo repositorio: %v", err)
+	}
+	outbox := productRepo.(repositories.OutboxRepository)
+	pending, _ := outbox.Pending(ctx, 0)
+	if len(pending) != 2 || pending[0].EventType != "product.created" || pending[1].EventType != "product.stock.updated" {
+		t.Fatalf("Unexpected pending messages: %+v", pending)
+	}
+
+	// El relay publica en orden conservando el ID del evento y los marca como enviados
+	sent, err := events.NewOutboxRelay(outbox, bus).RelayPending(ctx)
+	if err != nil || sent != 2 {
+		t.Fatalf("Expected 2 relayed messages, got %d (err %v)", sent, err)
+	}
+	if len(delivered) != 2 || delivered[0].ID != pending[0].ID {
+		t.Fatalf("Unexpected delivered events: %+v", delivered)
+	}
+	if stock := delivered[1].Payload.(events.StockUpdatedEvent); stock.OldStock != 5 || stock.NewStock != 8 {
+		t.Errorf("Unexpected stock event: %+v", stock)
+	}
+	if pending, _ := outbox.Pending(ctx, 0); len(pending) != 0 {
+		t.Errorf("Expected empty outbox, got %d messages", len(pending))
+	}
+
+	// Si el bus no acepta el evento, el mensaje sigue pendiente con el intento anotado
+	asyncBus, _ := events.NewAsyncEventBus(events.AsyncOptions{})
+	asyncBus.Close()
+	factory = factories.NewServiceFactory(memory.NewUserRepository(), productRepo, memory.NewOrderRepository(), asyncBus).WithOutbox()
+	factory.CreateProductService().DeactivateProduct(ctx, "prod-1")
+	if _, err := events.NewOutboxRelay(outbox, asyncBus).RelayPending(ctx); !errors.Is(err, events.ErrBusClosed) {
+		t.Fatalf("Expected ErrBusClosed, got %v", err)
+	}
+	if pending, _ := outbox.Pending(ctx, 0); len(pending) != 1 || pending[0].Attempts != 1 {
+		t.Errorf("Expected one pending message with one attempt, got %+v", pending)
+	}
+
+	// Un mensaje que no se puede decodificar se retira tras agotar sus intentos y
+	// deja de bloquear a los posteriores
+	memoryRepo := memory.NewProductRepository()
+	valid, _ := events.NewOutboxMessage(ctx, events.ProductCreatedEvent{ProductID: "prod-3"})
+	badCtx := repositories.WithProductOutbox(ctx, func(product *entities.Product) ([]*repositories.OutboxMessage, error) {
+		return []*repositories.OutboxMessage{
+			{ID: "bad", EventType: "product.created", AggregateID: product.ID, Payload: json.RawMessage(`"not an envelope"`)},
+			valid,
+		}, nil
+	})
+	product, _ := entities.NewProduct("prod-3", "Ratón", "", "Periféricos", entities.MustParseMoney("5", "EUR"), 1)
+	if err := memoryRepo.Save(badCtx, product); err != nil {
+		t.Fatalf("Error guardando producto: %v", err)
+	}
+	delivered = nil
+	relay := events.NewOutboxRelay(memoryRepo.(repositories.OutboxRepository), bus).WithMaxAttempts(2)
+	if sent, err := relay.RelayPending(ctx); err == nil || sent != 0 {
+		t.Fatalf("Expected the first attempt to fail without sending, got %d (err %v)", sent, err)
+	}
+	if sent, err := relay.RelayPending(ctx); err != nil || sent != 1 || len(delivered) != 1 || delivered[0].ID != valid.ID {
+		t.Fatalf("Expected the valid message to be relayed after the bad one, got %d (err %v)", sent, err)
+	}
+	if letters, _ := relay.DeadLetters(ctx); len(letters) != 1 || letters[0].ID != "bad" || letters[0].Attempts != 2 {
+		t.Errorf("Expected the bad message as a dead letter, got %+v", letters)
+	}
+	if pending, _ := memoryRepo.(repositories.OutboxRepository).Pending(ctx, 0); len(pending) != 0 {
+		t.Errorf("Expected empty outbox, got %d messages", len(pending))
+	}
+}
+
+// TestUnitOfWork verifica que las escrituras de varios repositorios se confirman o
+// se deshacen juntas, y que los eventos solo se publican tras el commit
+func TestUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+
+	userRepo, _ := file.NewUserRepository(dataDir)
+	productRepo, _ := file.NewProductRepository(dataDir)
+	uow := file.NewUnitOfWork(dataDir)
+	bus := events.NewInMemoryEventBus()
+	published := 0
+	bus.Subscribe(events.AllEvents, events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+		published++
+		return nil
+	}))
+	factory := factories.NewServiceFactory(userRepo, productRepo, memory.NewOrderRepository(), bus).WithUnitOfWork(uow)
+	management := factory.CreateProductManagementService()
+
+	// Si un producto del lote falla no se crea ninguno ni se publica nada
+	price := entities.MustParseMoney("10.00", "EUR")
+	created, errs := management.BulkCreateProducts(ctx, []services.CreateProductRequest{
+		{ID: "prod-1", Name: "Teclado", Category: "Periféricos", Price: price, Stock: 5},
+		{ID: "prod-2", Name: "", Category: "Periféricos", Price: price, Stock: 5},
+	})
+	if len(created) != 0 || len(errs) != 1 || errs[0].(*services.BulkOperationError).Index != 1 {
+		t.Fatalf("Expected the whole batch to be rolled back, got %d created and errors %v", len(created), errs)
+	}
+	if count, _ := productRepo.Count(ctx); count != 0 || published != 0 {
+		t.Fatalf("Expected no products and no events, got %d products and %d events", count, published)
+	}
+
+	created, errs = management.BulkCreateProducts(ctx, []services.CreateProductRequest{
+		{ID: "prod-1", Name: "Teclado", Category: "Periféricos", Price: price, Stock: 5},
+		{ID: "prod-2", Name: "Ratón", Category: "Periféricos", Price: price, Stock: 5},
+	})
+	if len(created) != 2 || len(errs) != 0 || published != 2 {
+		t.Fatalf("Expected 2 products and 2 events, got %d, %v and %d events", len(created), errs, published)
+	}
+
+	// Una transacción que abarca usuarios y productos se deshace por completo
+	userService := factory.CreateUserService()
+	productService := factory.CreateProductService()
+	err := repositories.RunInTransaction(ctx, uow, func(ctx context.Context) error {
+		if _, err := userService.CreateUser(ctx, "user-1", "ana@example.com", "Ana"); err != nil {
+			return err
+		}
+		if _, err := productService.UpdateStock(ctx, "prod-1", 1); err != nil {
+			return err
+		}
+		_, err := productService.RemoveStock(ctx, "prod-2", 10)
+		return err
+	})
+	if err == nil {
+		t.Fatal("Expected insufficient stock error")
+	}
+
+	// El estado en memoria y en disco es el anterior a la transacción
+	userRepo, _ = file.NewUserRepository(dataDir)
+	productRepo, _ = file.NewProductRepository(dataDir)
+	if user, _ := userRepo.FindByID(ctx, "user-1"); user != nil {
+		t.Error("Expected the user to be rolled back")
+	}
+	if product, _ := productRepo.FindByID(ctx, "prod-1"); product == nil || product.Stock != 5 {
+		t.Errorf("Expected prod-1 stock to be rolled back, got %+v", product)
+	}
+	if published != 2 {
+		t.Errorf("Expected no events from the rolled back transaction, got %d", published-2)
+	}
+
+	// Una transacción de otra unidad de trabajo no se une ni se anida
+	memoryUoW := memory.NewUnitOfWork()
+	err = repositories.RunInTransaction(ctx, memoryUoW, func(ctx context.Context) error {
+		return repositories.RunInTransaction(ctx, uow, func(ctx context.Context) error {
+			t.Error("Expected fn not to run inside a foreign transaction")
+			return nil
+		})
+	})
+	if !errors.Is(err, repositories.ErrForeignTransaction) {
+		t.Errorf("Expected ErrForeignTransaction, got %v", err)
+	}
+
+	// Si además falla el rollback, el lote incluye ese error y no el error interno
+	// con el que el servicio deshace la transacción
+	brokenUoW := &failingRollbackUoW{UnitOfWork: memory.NewUnitOfWork()}
+	brokenManagement := factories.NewServiceFactory(memory.NewUserRepository(), memory.NewProductRepository(), memory.NewOrderRepository(), bus).
+		WithUnitOfWork(brokenUoW).CreateProductManagementService()
+	_, errs = brokenManagement.BulkCreateProducts(ctx, []services.CreateProductRequest{
+		{ID: "prod-1", Name: "", Category: "Periféricos", Price: price, Stock: 5},
+	})
+	if len(errs) != 2 || !errors.Is(errs[1], errRollbackFailed) || strings.Contains(errs[1].Error(), "bulk operation rolled back") {
+		t.Errorf("Expected the item error and the rollback error, got %v", errs)
+	}
+
+	// Si no se puede escribir el journal, los mensajes del outbox de la transacción
+	// nunca llegan a ser visibles para el relay
+	failDir := t.TempDir()
+	failRepo, _ := file.NewProductRepository(failDir)
+	failUoW := file.NewUnitOfWork(failDir)
+	os.Mkdir(filepath.Join(failDir, "transaction.journal.tmp"), 0o755)
+	failService := factories.NewServiceFactory(memory.NewUserRepository(), failRepo, memory.NewOrderRepository(), bus).
+		WithOutbox().WithUnitOfWork(failUoW).CreateProductService()
+	err = repositories.RunInTransaction(ctx, failUoW, func(ctx context.Context) error {
+		_, err := failService.CreateProduct(ctx, "prod-9", "Monitor", "", "Periféricos", price, 1)
+		return err
+	})
+	if err == nil {
+		t.Fatal("Expected an error writing the journal")
+	}
+	if pending, _ := failRepo.(repositories.OutboxRepository).Pending(ctx, 0); len(pending) != 0 {
+		t.Errorf("Expected no pending messages after a failed commit, got %+v", pending)
+	}
+	if product, _ := failRepo.FindByID(ctx, "prod-9"); product != nil {
+		t.Error("Expected prod-9 to be rolled back")
+	}
+	os.Remove(filepath.Join(failDir, "transaction.journal.tmp"))
+	err = repositories.RunInTransaction(ctx, failUoW, func(ctx context.Context) error {
+		_, err := failService.CreateProduct(ctx, "prod-9", "Monitor", "", "Periféricos", price, 1)
+		return err
+	})
+	reopened, _ := file.NewProductRepository(failDir)
+	for _, repo := range []repositories.ProductRepository{failRepo, reopened} {
+		if pending, _ := repo.(repositories.OutboxRepository).Pending(ctx, 0); err != nil || len(pending) != 1 {
+			t.Errorf("Expected one pending message after the commit, got %d (err %v)", len(pending), err)
+		}
+	}
+
+	// El journal de una transacción confirmada se aplica cuando un proceso nuevo
+	// abre el directorio, simulado aquí con un directorio que no se ha abierto aún
+	crashDir := t.TempDir()
+	data, _ := os.ReadFile(filepath.Join(dataDir, "products.json"))
+	os.WriteFile(filepath.Join(crashDir, "products.json"), data, 0o644)
+	if err := os.WriteFile(filepath.Join(crashDir, "transaction.journal"), []byte(`[{"path": "products.json", "data": []}]`), 0o644); err != nil {
+		t.Fatalf("Error escribiendo journal: %v", err)
+	}
+	productRepo, err = file.NewProductRepository(crashDir)
+	if err != nil {
+		t.Fatalf("Error reabriendo repositorio: %v", err)
+	}
+	if count, _ := productRepo.Count(ctx); count != 0 {
+		t.Errorf("Expected the journal to be applied, got %d products", count)
+	}
+
+	// Un journal con rutas fuera del directorio de datos no se aplica
+	evilDir := t.TempDir()
+	os.WriteFile(filepath.Join(evilDir, "transaction.journal"), []byte(`[{"path": "../products.json", "data": []}]`), 0o644)
+	if _, err := file.NewProductRepository(evilDir); err == nil {
+		t.Error("Expected an error for a journal entry outside the data directory")
+	}
+}
+
+// errRollbackFailed es el error de failingRollbackUoW
+var errRollbackFailed = errors.New("rollback failed")
+
+// failingRollbackUoW deshace la transacción pero retorna siempre errRollbackFailed
+type failingRollbackUoW struct {
+	repositories.UnitOfWork
+}
+
+// Rollback deshace la transacción y retorna errRollbackFailed
+func (u *failingRollbackUoW) Rollback(ctx context.Context) error {
+	return errors.Join(u.UnitOfWork.Rollback(ctx), errRollbackFailed)
+}
+
+// TestEventSourcedProducts verifica que el repositorio basado en eventos reconstruye
+// el estado actual y el de cualquier instante a partir del historial
+func TestEventSourcedProducts(t *testing.T) {
+	ctx := context.Background()
+	repo := eventsourced.NewProductRepository().WithSnapshotInterval(3)
+	monday := time.Date(2024, time.March, 4, 12, 0, 0, 0, time.UTC)
+
+	product, _ := entities.NewProduct("prod-1", "Teclado", "Mecánico", "Periféricos", entities.MustParseMoney("50.00", "EUR"), 10)
+	product.CreatedAt, product.UpdatedAt = monday, monday
+	if err := repo.Save(ctx, product); err != nil {
+		t.Fatalf("Error guardando producto: %v", err)
+	}
+
+	// Un cambio por día: precio el martes, stock el miércoles, desactivación el jueves
+	product.UpdatePrice(entities.MustParseMoney("45.00", "EUR"))
+	product.UpdatedAt = monday.AddDate(0, 0, 1)
+	repo.Save(ctx, product)
+	product.RemoveStock(10)
+	product.UpdatedAt = monday.AddDate(0, 0, 2)
+	repo.Save(ctx, product)
+	product.Deactivate()
+	product.UpdatedAt = monday.AddDate(0, 0, 3)
+	if err := repo.Save(ctx, product); err != nil {
+		t.Fatalf("Error guardando producto: %v", err)
+	}
+
+	// El estado actual coincide con el último guardado, versión incluida
+	current, _ := repo.FindByID(ctx, "prod-1")
+	if current.Stock != 0 || current.IsActive || current.Price != product.Price || current.Version != 4 {
+		t.Fatalf("Unexpected current state: %+v", current)
+	}
+	stale := current.Clone()
+	stale.Version = 3
+	if err := repo.Save(ctx, stale); !errors.Is(err, repositories.ErrProductVersionConflict) {
+		t.Errorf("Expected version conflict, got %v", err)
+	}
+
+	// El estado de cualquier instante se reconstruye desde el historial
+	tuesday, _ := repo.ProductAt(ctx, "prod-1", monday.AddDate(0, 0, 1).Add(time.Hour))
+	if tuesday.Price.String() != "45.00 EUR" || tuesday.Stock != 10 || !tuesday.IsActive {
+		t.Errorf("Unexpected state on Tuesday: %+v", tuesday)
+	}
+	if before, _ := repo.ProductAt(ctx, "prod-1", monday.Add(-time.Hour)); before != nil {
+		t.Errorf("Expected no product before its creation, got %+v", before)
+	}
+
+	// Tras eliminarlo desaparece de las consultas pero conserva el historial
+	if err := repo.Delete(ctx, "prod-1"); err != nil {
+		t.Fatalf("Error eliminando producto: %v", err)
+	}
+	if count, _ := repo.Count(ctx); count != 0 {
+		t.Errorf("Expected no products, got %d", count)
+	}
+	history, _ := repo.History(ctx, "prod-1")
+	var types []eventsourced.ProductEventType
+	for _, event := range history {
+		types = append(types, event.Type)
+	}
+	expected := []eventsourced.ProductEventType{
+		eventsourced.ProductCreated, eventsourced.ProductPriceChanged, eventsourced.ProductStockRemoved,
+		eventsourced.ProductDeactivated, eventsourced.ProductDeleted,
+	}
+	if fmt.Sprint(types) != fmt.Sprint(expected) {
+		t.Errorf("Expected history %v, got %v", expected, types)
+	}
+
+	// Las fechas de los guardados pueden llegar desordenadas: en cada instante solo
+	// cuentan los cambios ocurridos hasta entonces, sea cual sea su orden de guardado
+	late, _ := entities.NewProduct("prod-2", "Ratón", "Inalámbrico", "Periféricos", entities.MustParseMoney("20.00", "EUR"), 5)
+	late.CreatedAt, late.UpdatedAt = monday, monday
+	repo.Save(ctx, late)
+	late.UpdatePrice(entities.MustParseMoney("18.00", "EUR"))
+	late.UpdatedAt = monday.AddDate(0, 0, 2)
+	repo.Save(ctx, late)
+	late.RemoveStock(5)
+	late.UpdatedAt = monday.AddDate(0, 0, 1)
+	if err := repo.Save(ctx, late); err != nil {
+		t.Fatalf("Error guardando producto: %v", err)
+	}
+	lateTuesday, _ := repo.ProductAt(ctx, "prod-2", monday.AddDate(0, 0, 1).Add(time.Hour))
+	if lateTuesday == nil || lateTuesday.Price.String() != "20.00 EUR" || lateTuesday.Stock != 0 {
+		t.Errorf("Unexpected state on Tuesday: %+v", lateTuesday)
+	}
+	if lateThursday, _ := repo.ProductAt(ctx, "prod-2", monday.AddDate(0, 0, 3)); lateThursday == nil || lateThursday.Price.String() != "18.00 EUR" || lateThursday.Stock != 0 {
+		t.Errorf("Unexpected state on Thursday: %+v", lateThursday)
+	}
+}
+
+// TestDomainEvents verifica que las entidades registran sus eventos y que los
+// servicios publican exactamente esos eventos tras guardar
+func TestDomainEvents(t *testing.T) {
+	ctx := context.Background()
+
+	// La entidad acumula sus cambios; un guardado registra un solo StockChanged
+	product, _ := entities.NewProduct("prod-1", "Laptop", "Portátil", "Electrónicos", entities.MustParseMoney("999.99", "EUR"), 5)
+	product.UpdatePrice(entities.MustParseMoney("899.99", "EUR"))
+	product.AddStock(3)
+	product.RemoveStock(1)
+	var names []string
+	for _, event := range product.PullEvents() {
+		names = append(names, event.EventName())
+	}
+	if fmt.Sprint(names) != "[product.created product.updated product.stock_changed]" {
+		t.Fatalf("Unexpected domain events: %v", names)
+	}
+	if len(product.PullEvents()) != 0 || len(product.Clone().Events()) != 0 {
+		t.Error("Expected no events after PullEvents")
+	}
+
+	// Los servicios publican los eventos registrados, y nada si la operación falla
+	bus := events.NewInMemoryEventBus()
+	var delivered []string
+	bus.Subscribe(events.AllEvents, events.EventHandlerFunc(func(ctx context.Context, envelope events.Envelope) error {
+		delivered = append(delivered, envelope.Type)
+		return nil
+	}))
+	factory := factories.NewServiceFactory(memory.NewUserRepository(), memory.NewProductRepository(), memory.NewOrderRepository(), bus)
+	productService := factory.CreateProductService()
+	userService := factory.CreateUserService()
+
+	productService.CreateProduct(ctx, "prod-1", "Laptop", "Portátil", "Electrónicos", entities.MustParseMoney("999.99", "EUR"), 5)
+	stock := 7
+	name := "Laptop Pro"
+	productService.UpdateProduct(ctx, "prod-1", &name, nil, nil, nil, &stock)
+	if _, err := productService.RemoveStock(ctx, "prod-1", 100); err == nil {
+		t.Fatal("Expected insufficient stock error")
+	}
+	productService.DeleteProduct(ctx, "prod-1")
+	userService.CreateUser(ctx, "user-1", "ana@example.com", "Ana")
+	userService.DeactivateUser(ctx, "user-1")
+
+	expected := "[product.created product.updated product.stock.updated product.deleted user.created user.deactivated]"
+	if fmt.Sprint(delivered) != expected {
+		t.Errorf("Expected events %s, got %v", expected, delivered)
+	}
+
+	// Lo guardado en el repositorio no conserva eventos pendientes
+	stored, _ := userService.GetUser(ctx, "user-1")
+	if len(stored.Events()) != 0 {
+		t.Errorf("Expected stored user without events, got %v", stored.Events())
+	}
+}
+
+// TestSpecifications verifica que todos los adaptadores evalúan igual las
+// especificaciones combinadas y que las búsquedas aplican todos los criterios
+func TestSpecifications(t *testing.T) {
+	ctx := context.Background()
+	fileRepo, err := file.NewProductRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error abriendo repositorio: %v", err)
+	}
+	adapters := map[string]repositories.ProductRepository{
+		"memory":       memory.NewProductRepository(),
+		"file":         fileRepo,
+		"eventsourced": eventsourced.NewProductRepository(),
+	}
+
+	for name, repo := range adapters {
+		for _, p := range []struct {
+			id, name, category, price string
+			stock                     int
+		}{
+			{"prod-1", "Laptop Pro", "Electrónicos", "999.99", 5},
+			{"prod-2", "Mouse", "Electrónicos", "19.99", 0},
+			{"prod-3", "Silla", "Muebles", "149.00", 12},
+			{"prod-4", "Laptop Air", "Electrónicos", "1299.00", 2},
+		} {
+			product, _ := entities.NewProduct(p.id, p.name, "", p.category, entities.MustParseMoney(p.price, "EUR"), p.stock)
+			repo.Save(ctx, product)
+		}
+
+		// Electrónicos disponibles que o bien son portátiles o tienen mucho stock,
+		// fuera del rango de precios 1000-2000
+		spec := repositories.And(
+			repositories.InCategory("Electrónicos"),
+			repositories.IsAvailable(),
+			repositories.Or(repositories.NameContains("LAPTOP"), repositories.StockAtLeast(10)),
+			repositories.Not(repositories.PriceBetween(entities.MustParseMoney("1000", "EUR"), entities.MustParseMoney("2000", "EUR"))),
+		)
+		products, err := repo.FindMatching(ctx, spec, 10, 0)
+		if err != nil || len(products) != 1 || products[0].ID != "prod-1" {
+			t.Errorf("%s: expected [prod-1], got %v (err %v)", name, products, err)
+		}
+
+		if _, err := repo.FindMatching(ctx, repositories.Where(repositories.FieldEmail, repositories.OpEqual, "x"), 10, 0); !errors.Is(err, repositories.ErrInvalidSpecification) {
+			t.Errorf("%s: expected ErrInvalidSpecification, got %v", name, err)
+		}
+	}
+
+	// SearchProducts y SearchUsers combinan todos los criterios en una consulta
+	factory := factories.NewServiceFactory(memory.NewUserRepository(), adapters["memory"], memory.NewOrderRepository(), events.NewInMemoryEventBus())
+	products, err := factory.CreateProductManagementService().SearchProducts(ctx, services.ProductSearchCriteria{
+		Category: "Electrónicos",
+		MinPrice: entities.MustParseMoney("500", "EUR"),
+		Name:     "laptop",
+		MinStock: 3,
+		Limit:    10,
+	})
+	if err != nil || len(products.Items) != 1 || products.Items[0].ID != "prod-1" {
+		t.Errorf("Expected [prod-1], got %v (err %v)", products.Items, err)
+	}
+
+	userService := factory.CreateUserService()
+	userService.CreateUser(ctx, "user-1", "ana@example.com", "Ana López")
+	userService.CreateUser(ctx, "user-2", "luis@example.com", "Luis López")
+	userService.DeactivateUser(ctx, "user-2")
+	active := true
+	users, err := factory.CreateUserManagementService().SearchUsers(ctx, services.SearchCriteria{Name: "lópez", Active: &active, Limit: 10})
+	if err != nil || len(users.Items) != 1 || users.Items[0].ID != "user-1" {
+		t.Errorf("Expected [user-1], got %v (err %v)", users.Items, err)
+	}
+}
+
+// TestPagination comprueba que recorrer las páginas con cursor retorna cada
+// producto una vez, en orden, aunque varios compartan el valor de ordenación
+func TestPagination(t *testing.T) {
+	ctx := context.Background()
+	fileRepo, err := file.NewProductRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error abriendo repositorio: %v", err)
+	}
+	adapters := map[string]repositories.ProductRepository{
+		"memory":       memory.NewProductRepository(),
+		"file":         fileRepo,
+		"eventsourced": eventsourced.NewProductRepository(),
+	}
+
+	prices := []string{"10", "25", "10", "40", "25", "10", "99"}
+	for name, repo := range adapters {
+		for i, price := range prices {
+			product, _ := entities.NewProduct(fmt.Sprintf("prod-%d", i), "Producto", "", "General", entities.MustParseMoney(price, "EUR"), 1)
+			repo.Save(ctx, product)
+		}
+
+		request := repositories.PageRequest{Sort: repositories.SortOrder{Field: repositories.SortByPrice, Descending: true}, Limit: 3}
+		var ids []string
+		for pages := 0; ; pages++ {
+			page, err := repo.FindPage(ctx, nil, request)
+			if err != nil {
+				t.Fatalf("%s: error obteniendo página: %v", name, err)
+			}
+			if page.Total != len(prices) || len(page.Items) > request.Limit {
+				t.Fatalf("%s: unexpected page %d (total %d, %d items)", name, pages, page.Total, len(page.Items))
+			}
+			for _, product := range page.Items {
+				ids = append(ids, product.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			request.Cursor = page.NextCursor
+		}
+
+		expected := "prod-6 prod-3 prod-4 prod-1 prod-5 prod-2 prod-0"
+		if got := strings.Join(ids, " "); got != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, got)
+		}
+
+		// Un cursor solo sirve para el orden con el que se obtuvo
+		request.Sort = repositories.SortOrder{Field: repositories.SortByName}
+		if _, err := repo.FindPage(ctx, nil, request); !errors.Is(err, repositories.ErrInvalidCursor) {
+			t.Errorf("%s: expected ErrInvalidCursor, got %v", name, err)
+		}
+	}
+}
+
+// TestFullTextSearch comprueba la búsqueda de texto: normalización de acentos,
+// prefijos, erratas, ranking y actualización del índice con los eventos
+func TestFullTextSearch(t *testing.T) {
+	ctx := context.Background()
+	container := config.NewContainer()
+	defer container.Close()
+	productService := container.GetProductService()
+
+	price := entities.MustParseMoney("10", "EUR")
+	productService.CreateProduct(ctx, "prod-1", "Laptop Gamer", "Portátil con pantalla de 17 pulgadas", "Electrónicos", price, 5)
+	productService.CreateProduct(ctx, "prod-2", "Funda", "Funda acolchada para laptop", "Accesorios", price, 5)
+	productService.CreateProduct(ctx, "prod-3", "Mouse inalámbrico", "Ratón óptico", "Electrónicos", price, 5)
+
+	ids := func(query string) string {
+		results, err := productService.SearchText(ctx, query, 10)
+		if err != nil {
+			t.Fatalf("Error buscando %q: %v", query, err)
+		}
+		var found []string
+		for _, result := range results {
+			found = append(found, result.Product.ID)
+		}
+		return strings.Join(found, " ")
+	}
+
+	for query, expected := range map[string]string{
+		"ELECTRONICOS": "prod-1 prod-3", // sin acentos ni mayúsculas
+		"portatil":     "prod-1",
+		"lap":          "prod-1 prod-2", // prefijo; el nombre pesa más que la descripción
+		"mose":         "prod-3",        // una errata
+		"laptop funda": "prod-2",        // todos los términos
+		"inexistente":  "",
+		"de para con":  "",
+	} {
+		if got := ids(query); got != expected {
+			t.Errorf("%q: expected %q, got %q", query, expected, got)
+		}
+	}
+
+	// El índice sigue los eventos de modificación y eliminación
+	name := "Teclado"
+	if _, err := productService.UpdateProduct(ctx, "prod-3", &name, nil, nil, nil, nil); err != nil {
+		t.Fatalf("Error actualizando producto: %v", err)
+	}
+	productService.DeleteProduct(ctx, "prod-1")
+	if got := ids("teclado"); got != "prod-3" {
+		t.Errorf("Expected updated product to be indexed, got %q", got)
+	}
+	if got := ids("electronicos"); got != "prod-3" {
+		t.Errorf("Expected deleted product to be removed, got %q", got)
+	}
+}
+
+// TestMemoryIndexes comprueba que las consultas resueltas con los índices de los
+// repositorios en memoria coinciden con recorrer todas las entidades, también tras
+// modificar, eliminar y deshacer escrituras
+func TestMemoryIndexes(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewProductRepository()
+	uow := memory.NewUnitOfWork()
+
+	for i := 0; i < 30; i++ {
+		currency := "EUR"
+		if i%5 == 0 {
+			currency = "USD"
+		}
+		product, _ := entities.NewProduct(fmt.Sprintf("prod-%02d", i), "Producto", "", fmt.Sprintf("cat-%d", i%3),
+			entities.MustParseMoney(fmt.Sprint(i*7%20+1), currency), i%4)
+		if i%6 == 0 {
+			product.Deactivate()
+		}
+		repo.Save(ctx, product)
+	}
+
+	check := func(step string) {
+		t.Helper()
+		minPrice, maxPrice := entities.MustParseMoney("5", "EUR"), entities.MustParseMoney("15", "EUR")
+		for name, query := range map[string]struct {
+			indexed func() ([]*entities.Product, error)
+			spec    repositories.Specification
+		}{
+			"category":  {func() ([]*entities.Product, error) { return repo.FindByCategory(ctx, "cat-1", 100, 0) }, repositories.InCategory("cat-1")},
+			"price":     {func() ([]*entities.Product, error) { return repo.FindByPriceRange(ctx, minPrice, maxPrice, 100, 0) }, repositories.PriceBetween(minPrice, maxPrice)},
+			"available": {func() ([]*entities.Product, error) { return repo.FindAvailable(ctx, 100, 0) }, repositories.IsAvailable()},
+		} {
+			indexed, _ := query.indexed()
+			scanned, _ := repo.FindMatching(ctx, query.spec, 100, 0)
+			if fmt.Sprint(productIDs(indexed)) != fmt.Sprint(productIDs(scanned)) {
+				t.Errorf("%s, %s: index returned %v, scan returned %v", step, name, productIDs(indexed), productIDs(scanned))
+			}
+		}
+		count, _ := repo.CountByCategory(ctx, "cat-1")
+		if all, _ := repo.FindMatching(ctx, repositories.InCategory("cat-1"), 100, 0); count != len(all) {
+			t.Errorf("%s: CountByCategory returned %d, expected %d", step, count, len(all))
+		}
+	}
+	check("inicial")
+
+	// Cambiar categoría, precio, stock y estado mueve el producto entre índices
+	for i := 0; i < 30; i += 4 {
+		repo.Update(ctx, fmt.Sprintf("prod-%02d", i), func(p *entities.Product) error {
+			p.UpdateDetails(p.Name, p.Description, "cat-1")
+			p.UpdatePrice(entities.MustParseMoney("9", "EUR"))
+			p.Activate()
+			return p.UpdateStock(0)
+		})
+	}
+	repo.Delete(ctx, "prod-01")
+	check("tras modificar")
+
+	// Un rollback restaura también los índices
+	txCtx, _ := uow.Begin(ctx)
+	repo.Update(txCtx, "prod-07", func(p *entities.Product) error {
+		return p.UpdateDetails(p.Name, p.Description, "cat-2")
+	})
+	repo.Delete(txCtx, "prod-10")
+	uow.Rollback(txCtx)
+	check("tras rollback")
+
+	// El índice de email sigue los cambios de email
+	users := memory.NewUserRepository()
+	user, _ := entities.NewUser("user-1", "ana@example.com", "Ana")
+	users.Save(ctx, user)
+	user.UpdateEmail("ana.lopez@example.com")
+	users.Save(ctx, user)
+	if found, _ := users.FindByEmail(ctx, "ana@example.com"); found != nil {
+		t.Errorf("Expected the old email to be unindexed, got %v", found)
+	}
+	if found, _ := users.FindByEmail(ctx, "ana.lopez@example.com"); found == nil || found.ID != "user-1" {
+		t.Errorf("Expected user-1 by its new email, got %v", found)
+	}
+}
+
+// productIDs retorna los IDs de una lista de productos
+func productIDs(products []*entities.Product) []string {
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	return ids
+}
+
+// TestEmailUniqueness verifica que los emails se normalizan y que el repositorio
+// rechaza de forma atómica los emails en uso, también con altas simultáneas
+func TestEmailUniqueness(t *testing.T) {
+	ctx := context.Background()
+	fileUsers, err := file.NewUserRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error abriendo repositorio de ficheros: %v", err)
+	}
+
+	for name, users := range map[string]repositories.UserRepository{
+		"memory": memory.NewUserRepository(),
+		"file":   fileUsers,
+	} {
+		newFactory := func() *factories.ServiceFactory {
+			return factories.NewServiceFactory(users, memory.NewProductRepository(), memory.NewOrderRepository(), events.NewInMemoryEventBus())
+		}
+		userService := newFactory().CreateUserService()
+
+		user, err := userService.CreateUser(ctx, "user-ana", "  Ana@Example.COM ", "Ana")
+		if err != nil {
+			t.Fatalf("%s: error creando usuario: %v", name, err)
+		}
+		if user.Email != "ana@example.com" {
+			t.Errorf("%s: expected normalized email, got %q", name, user.Email)
+		}
+		if _, err := userService.CreateUser(ctx, "user-ana2", "ANA@example.com", "Ana Bis"); !errors.Is(err, repositories.ErrEmailAlreadyInUse) {
+			t.Errorf("%s: expected ErrEmailAlreadyInUse for a differently cased email, got %v", name, err)
+		}
+		if found, _ := userService.GetUserByEmail(ctx, "ana@EXAMPLE.com"); found == nil || found.ID != "user-ana" {
+			t.Errorf("%s: expected to find user-ana by email, got %v", name, found)
+		}
+
+		// De varias altas simultáneas con el mismo email solo una tiene éxito
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		created := 0
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := userService.CreateUser(ctx, fmt.Sprintf("user-race-%d", i), "race@example.com", "Race")
+				mutex.Lock()
+				defer mutex.Unlock()
+				if err == nil {
+					created++
+				} else if !errors.Is(err, repositories.ErrEmailAlreadyInUse) {
+					t.Errorf("%s: unexpected error: %v", name, err)
+				}
+			}(i)
+		}
+		wg.Wait()
+		if created != 1 {
+			t.Errorf("%s: expected exactly one user with the raced email, got %d", name, created)
+		}
+
+		// Cambiar el email al de otro usuario también se rechaza
+		taken := "Race@Example.com"
+		if _, err := userService.UpdateUser(ctx, "user-ana", &taken, nil); !errors.Is(err, repositories.ErrEmailAlreadyInUse) {
+			t.Errorf("%s: expected ErrEmailAlreadyInUse when updating to a taken email, got %v", name, err)
+		}
+		own := "ANA@example.com"
+		if _, err := userService.UpdateUser(ctx, "user-ana", &own, nil); err != nil {
+			t.Errorf("%s: expected to keep its own email, got %v", name, err)
+		}
+
+		// Con la política StripPlusTag la etiqueta no distingue emails
+		plusService := newFactory().WithEmailPolicy(entities.EmailPolicy{StripPlusTag: true}).CreateUserService()
+		if _, err := plusService.CreateUser(ctx, "user-ana3", "ana+news@example.com", "Ana Tres"); !errors.Is(err, repositories.ErrEmailAlreadyInUse) {
+			t.Errorf("%s: expected ErrEmailAlreadyInUse for a plus-tagged email, got %v", name, err)
+		}
+		if found, _ := plusService.GetUserByEmail(ctx, "Ana+Other@example.com"); found == nil || found.ID != "user-ana" {
+			t.Errorf("%s: expected to find user-ana by a plus-tagged email, got %v", name, found)
+		}
+	}
+}
@@ -1,3 +1,5 @@
 module hexagonal-example
 
 go 1.24.2
+
+require github.com/mattn/go-sqlite3 v1.14.32
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Variables de entorno que sobrescriben los valores del fichero de configuración
+const (
+	EnvRepositoryAdapter    = "HEXAGONAL_REPOSITORY_ADAPTER"
+	EnvDataDir              = "HEXAGONAL_DATA_DIR"
+	EnvSQLDriver            = "HEXAGONAL_SQL_DRIVER"
+	EnvSQLDSN               = "HEXAGONAL_SQL_DSN"
+	EnvEventBusAdapter      = "HEXAGONAL_EVENT_BUS_ADAPTER"
+	EnvEventBusQueueSize    = "HEXAGONAL_EVENT_BUS_QUEUE_SIZE"
+	EnvEventBusWorkers      = "HEXAGONAL_EVENT_BUS_WORKERS"
+	EnvEventBusBackpressure = "HEXAGONAL_EVENT_BUS_BACKPRESSURE"
+	EnvEventBusMaxAttempts  = "HEXAGONAL_EVENT_BUS_MAX_ATTEMPTS"
+	EnvEventStore           = "HEXAGONAL_EVENT_STORE"
+	EnvEventStorePath       = "HEXAGONAL_EVENT_STORE_PATH"
+	EnvEventOutbox          = "HEXAGONAL_EVENT_OUTBOX"
+	EnvUsersStripPlusTag    = "HEXAGONAL_USERS_STRIP_PLUS_TAG"
+)
+
+// Nombres de los adaptadores incluidos por defecto
+const (
+	AdapterMemory       = "memory"
+	AdapterFile         = "file"
+	AdapterSQL          = "sql"
+	AdapterEventSourced = "eventsourced"
+	AdapterAsync        = "async"
+)
+
+// Config describe qué adaptadores debe usar el contenedor
+type Config struct {
+	Repository RepositoryConfig `json:"repository"`
+	EventBus   EventBusConfig   `json:"event_bus"`
+	Users      UsersConfig      `json:"users"`
+}
+
+// RepositoryConfig configura el adaptador de persistencia de usuarios y productos
+type RepositoryConfig struct {
+	// Adapter es el nombre del adaptador registrado (memory, file, sql...)
+	Adapter string `json:"adapter"`
+
+	// DataDir es el directorio de datos del adaptador file
+	DataDir string `json:"data_dir,omitempty"`
+
+	// Driver y DSN configuran el adaptador sql
+	// cmd/hexagonal solo registra los drivers de SQLite ("sqlite" y "sqlite3")
+	Driver string `json:"driver,omitempty"`
+	DSN    string `json:"dsn,omitempty"`
+}
+
+// EventBusConfig configura el adaptador del bus de eventos
+type EventBusConfig struct {
+	// Adapter es el nombre del adaptador registrado (memory, async...)
+	Adapter string `json:"adapter"`
+
+	// QueueSize, Workers y Backpressure configuran el adaptador async
+	// Los valores cero usan los valores por defecto de events.AsyncOptions
+	QueueSize    int    `json:"queue_size,omitempty"`
+	Workers      int    `json:"workers,omitempty"`
+	Backpressure string `json:"backpressure,omitempty"`
+
+	// MaxAttempts es el número de intentos de entrega a cada handler antes de
+	// guardar la entrega en el dead-letter (0 usa events.DefaultMaxAttempts)
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// Store elige el almacén de eventos (memory, file); vacío si no se guardan
+	// StorePath es el fichero del almacén file
+	Store     string `json:"store,omitempty"`
+	StorePath string `json:"store_path,omitempty"`
+
+	// Outbox hace que los eventos de producto se guarden junto al producto y los
+	// publique un relay en segundo plano (ver Container.StartOutboxRelay)
+	Outbox bool `json:"outbox,omitempty"`
+}
+
+// UsersConfig configura el tratamiento de los usuarios
+type UsersConfig struct {
+	// StripPlusTag hace que "juan+news@example.com" y "juan@example.com" se
+	// consideren el mismo email (ver entities.EmailPolicy)
+	StripPlusTag bool `json:"strip_plus_tag,omitempty"`
+}
+
+// DefaultConfig retorna la configuración equivalente a NewContainer
+func DefaultConfig() *Config {
+	return &Config{
+		Repository: RepositoryConfig{Adapter: AdapterMemory},
+		EventBus:   EventBusConfig{Adapter: AdapterMemory},
+	}
+}
+
+// LoadConfig lee la configuración de un fichero JSON y aplica las variables de entorno
+// Si path está vacío se parte de la configuración por defecto
+func LoadConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+		if err := decodeConfig(data, cfg); err != nil {
+			return nil, fmt.Errorf("decoding config file %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// decodeConfig decodifica el JSON de un fichero de configuración sobre cfg
+// Las claves desconocidas son un error: una errata como "adaptor" dejaría el
+// valor por defecto sin avisar
+func decodeConfig(data []byte, cfg *Config) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return err
+	}
+	if err := decoder.Decode(&json.RawMessage{}); err != io.EOF {
+		return errors.New("unexpected data after the configuration object")
+	}
+	return nil
+}
+
+// applyEnv sobrescribe los valores con las variables de entorno definidas
+func (c *Config) applyEnv() error {
+	overrides := []struct {
+		env   string
+		field *string
+	}{
+		{EnvRepositoryAdapter, &c.Repository.Adapter},
+		{EnvDataDir, &c.Repository.DataDir},
+		{EnvSQLDriver, &c.Repository.Driver},
+		{EnvSQLDSN, &c.Repository.DSN},
+		{EnvEventBusAdapter, &c.EventBus.Adapter},
+		{EnvEventBusBackpressure, &c.EventBus.Backpressure},
+		{EnvEventStore, &c.EventBus.Store},
+		{EnvEventStorePath, &c.EventBus.StorePath},
+	}
+
+	for _, o := range overrides {
+		if value, ok := os.LookupEnv(o.env); ok {
+			*o.field = value
+		}
+	}
+
+	intOverrides := []struct {
+		env   string
+		field *int
+	}{
+		{EnvEventBusQueueSize, &c.EventBus.QueueSize},
+		{EnvEventBusWorkers, &c.EventBus.Workers},
+		{EnvEventBusMaxAttempts, &c.EventBus.MaxAttempts},
+	}
+
+	for _, o := range intOverrides {
+		if value, ok := os.LookupEnv(o.env); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s must be an integer, got %q", o.env, value)
+			}
+			*o.field = n
+		}
+	}
+
+	boolOverrides := []struct {
+		env   string
+		field *bool
+	}{
+		{EnvEventOutbox, &c.EventBus.Outbox},
+		{EnvUsersStripPlusTag, &c.Users.StripPlusTag},
+	}
+
+	for _, o := range boolOverrides {
+		if value, ok := os.LookupEnv(o.env); ok {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s must be a boolean, got %q", o.env, value)
+			}
+			*o.field = enabled
+		}
+	}
+	return nil
+}
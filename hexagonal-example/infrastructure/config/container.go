@@ -1,11 +1,19 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"time"
+
 	"hexagonal-example/application/factories"
 	"hexagonal-example/application/services"
 	"hexagonal-example/domain/repositories"
 	"hexagonal-example/infrastructure/events"
+	apphttp "hexagonal-example/infrastructure/http"
 	"hexagonal-example/infrastructure/repositories/memory"
+	"hexagonal-example/infrastructure/search"
 )
 
 // Container implementa el patrón de Dependency Injection
@@ -15,18 +23,33 @@ type Container struct {
 	// Repositorios
 	userRepo    repositories.UserRepository
 	productRepo repositories.ProductRepository
+	orderRepo   repositories.OrderRepository
+
+	// Transacciones sobre los repositorios; nil si el adaptador no las soporta
+	unitOfWork repositories.UnitOfWork
 
 	// Event Bus
 	eventBus events.EventBus
 
+	// Índice de búsqueda de texto de productos y el indexador que lo mantiene
+	searchIndex *search.ProductIndex
+	indexer     *search.ProductIndexer
+
 	// Factory
 	serviceFactory *factories.ServiceFactory
 
 	// Servicios (lazy-loaded)
 	userService            *services.UserService
 	productService         *services.ProductService
+	orderService           *services.OrderService
 	userManagementService  *services.UserManagementService
 	productManagementService *services.ProductManagementService
+
+	// Adaptadores de entrada (lazy-loaded)
+	httpHandler *apphttp.Handler
+
+	// Funciones de cierre de los adaptadores
+	closers []func() error
 }
 
 // NewContainer crea una nueva instancia del contenedor de dependencias
@@ -35,21 +58,121 @@ func NewContainer() *Container {
 	// Crear implementaciones concretas de repositorios
 	userRepo := memory.NewUserRepository()
 	productRepo := memory.NewProductRepository()
+	orderRepo := memory.NewOrderRepository()
 
 	// Crear implementación concreta del event bus
 	eventBus := events.NewInMemoryEventBus()
 
+	container := newContainer(userRepo, productRepo, orderRepo, eventBus)
+	container.useUnitOfWork(memory.NewUnitOfWork())
+	return container
+}
+
+// NewContainerFromConfig crea un contenedor cuyos adaptadores se eligen por nombre
+// a partir de un fichero de configuración JSON y de las variables de entorno
+// Si path está vacío solo se usan los valores por defecto y el entorno
+func NewContainerFromConfig(path string) (*Container, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return DefaultRegistry().NewContainer(context.Background(), cfg)
+}
+
+// newContainer crea el contenedor a partir de las implementaciones ya construidas
+func newContainer(
+	userRepo repositories.UserRepository,
+	productRepo repositories.ProductRepository,
+	orderRepo repositories.OrderRepository,
+	eventBus events.EventBus,
+) *Container {
+	// Crear el índice de búsqueda, que se actualiza con los eventos de producto
+	searchIndex := search.NewProductIndex()
+	indexer := search.NewProductIndexer(searchIndex, productRepo).Subscribe(eventBus)
+
 	// Crear el factory de servicios
-	serviceFactory := factories.NewServiceFactory(userRepo, productRepo, eventBus)
+	serviceFactory := factories.NewServiceFactory(userRepo, productRepo, orderRepo, eventBus).
+		WithSearchIndex(searchIndex)
 
 	return &Container{
 		userRepo:       userRepo,
 		productRepo:    productRepo,
+		orderRepo:      orderRepo,
 		eventBus:       eventBus,
+		searchIndex:    searchIndex,
+		indexer:        indexer,
 		serviceFactory: serviceFactory,
+		closers:        []func() error{indexer.Close},
 	}
 }
 
+// useUnitOfWork configura la unidad de trabajo de los repositorios
+// Los servicios de gestión y de pedidos la usan para que sus operaciones sean atómicas
+func (c *Container) useUnitOfWork(uow repositories.UnitOfWork) {
+	c.unitOfWork = uow
+	c.serviceFactory.WithUnitOfWork(uow)
+}
+
+// Close libera los recursos de los adaptadores (por ejemplo conexiones a base de datos)
+func (c *Container) Close() error {
+	var errs []error
+	for _, closer := range c.closers {
+		if err := closer(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.closers = nil
+	return errors.Join(errs...)
+}
+
+// StartReservationExpiry libera periódicamente las reservas de stock expiradas
+// La tarea en segundo plano se detiene al cerrar el contenedor
+func (c *Container) StartReservationExpiry(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.GetProductService().RunReservationExpiry(ctx, interval)
+	}()
+
+	c.closers = append([]func() error{func() error {
+		cancel()
+		<-done
+		return nil
+	}}, c.closers...)
+}
+
+// DefaultOutboxRelayInterval es la frecuencia con la que el relay publica el outbox
+const DefaultOutboxRelayInterval = 200 * time.Millisecond
+
+// StartOutboxRelay guarda los eventos de producto en el outbox del repositorio de
+// productos y arranca un relay que los publica en el bus cada interval
+// Debe llamarse antes de obtener los servicios. Al cerrar el contenedor, el relay
+// publica lo que quede pendiente antes de que se cierre el bus
+func (c *Container) StartOutboxRelay(interval time.Duration) error {
+	outbox, ok := c.productRepo.(repositories.OutboxRepository)
+	if !ok {
+		return fmt.Errorf("product repository %T does not support an outbox", c.productRepo)
+	}
+	c.serviceFactory.WithOutbox()
+	relay := events.NewOutboxRelay(outbox, c.eventBus)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay.Run(ctx, interval)
+	}()
+
+	c.closers = append([]func() error{func() error {
+		cancel()
+		<-done
+		_, err := relay.RelayPending(context.Background())
+		return err
+	}}, c.closers...)
+	return nil
+}
+
 // GetUserService retorna la instancia del servicio de usuario
 // Implementa lazy loading para crear el servicio solo cuando se necesita
 func (c *Container) GetUserService() *services.UserService {
@@ -67,6 +190,14 @@ func (c *Container) GetProductService() *services.ProductService {
 	return c.productService
 }
 
+// GetOrderService retorna la instancia del servicio de pedidos
+func (c *Container) GetOrderService() *services.OrderService {
+	if c.orderService == nil {
+		c.orderService = c.serviceFactory.CreateOrderService()
+	}
+	return c.orderService
+}
+
 // GetUserManagementService retorna la instancia del servicio de gestión de usuarios
 func (c *Container) GetUserManagementService() *services.UserManagementService {
 	if c.userManagementService == nil {
@@ -83,6 +214,20 @@ func (c *Container) GetProductManagementService() *services.ProductManagementSer
 	return c.productManagementService
 }
 
+// GetHTTPHandler retorna el adaptador HTTP que expone los servicios como API REST/JSON
+func (c *Container) GetHTTPHandler() nethttp.Handler {
+	if c.httpHandler == nil {
+		c.httpHandler = apphttp.NewHandler(
+			c.GetUserService(),
+			c.GetProductService(),
+			c.GetOrderService(),
+			c.GetUserManagementService(),
+			c.GetProductManagementService(),
+		)
+	}
+	return c.httpHandler
+}
+
 // GetAllServices retorna todas las instancias de servicios
 func (c *Container) GetAllServices() *factories.AllServices {
 	return c.serviceFactory.CreateAllServices()
@@ -98,6 +243,22 @@ func (c *Container) GetProductRepository() repositories.ProductRepository {
 	return c.productRepo
 }
 
+// GetOrderRepository retorna la instancia del repositorio de pedidos
+func (c *Container) GetOrderRepository() repositories.OrderRepository {
+	return c.orderRepo
+}
+
+// GetUnitOfWork retorna la unidad de trabajo de los repositorios, o nil si el
+// adaptador no soporta transacciones
+func (c *Container) GetUnitOfWork() repositories.UnitOfWork {
+	return c.unitOfWork
+}
+
+// GetProductSearchIndex retorna el índice de búsqueda de texto de productos
+func (c *Container) GetProductSearchIndex() *search.ProductIndex {
+	return c.searchIndex
+}
+
 // GetEventBus retorna la instancia del event bus
 func (c *Container) GetEventBus() events.EventBus {
 	return c.eventBus
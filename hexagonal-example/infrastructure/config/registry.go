@@ -0,0 +1,256 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+	"hexagonal-example/infrastructure/events"
+	"hexagonal-example/infrastructure/repositories/eventsourced"
+	"hexagonal-example/infrastructure/repositories/file"
+	"hexagonal-example/infrastructure/repositories/memory"
+	"hexagonal-example/infrastructure/repositories/sqldb"
+)
+
+// RepositorySet agrupa los repositorios creados por un adaptador
+type RepositorySet struct {
+	Users    repositories.UserRepository
+	Products repositories.ProductRepository
+	Orders   repositories.OrderRepository
+
+	// UnitOfWork agrupa escrituras de los repositorios en transacciones; puede ser nil
+	// si el adaptador no las soporta
+	UnitOfWork repositories.UnitOfWork
+
+	// Close libera los recursos del adaptador (conexiones, ficheros); puede ser nil
+	Close func() error
+}
+
+// RepositoryFactory crea los repositorios de un adaptador a partir de su configuración
+type RepositoryFactory func(ctx context.Context, cfg RepositoryConfig) (*RepositorySet, error)
+
+// EventBusFactory crea un bus de eventos a partir de su configuración
+// Si el bus implementa io.Closer, el contenedor lo cierra antes que los repositorios
+type EventBusFactory func(cfg EventBusConfig) (events.EventBus, error)
+
+// Registry asocia nombres de adaptadores con sus factories
+// Permite añadir adaptadores nuevos sin modificar el contenedor
+type Registry struct {
+	repositories map[string]RepositoryFactory
+	eventBuses   map[string]EventBusFactory
+}
+
+// NewRegistry crea un registro vacío
+func NewRegistry() *Registry {
+	return &Registry{
+		repositories: make(map[string]RepositoryFactory),
+		eventBuses:   make(map[string]EventBusFactory),
+	}
+}
+
+// DefaultRegistry crea un registro con los adaptadores incluidos en el proyecto
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.RegisterRepository(AdapterMemory, newMemoryRepositories)
+	r.RegisterRepository(AdapterFile, newFileRepositories)
+	r.RegisterRepository(AdapterSQL, newSQLRepositories)
+	r.RegisterRepository(AdapterEventSourced, newEventSourcedRepositories)
+	r.RegisterEventBus(AdapterMemory, newMemoryEventBus)
+	r.RegisterEventBus(AdapterAsync, newAsyncEventBus)
+	return r
+}
+
+// RegisterRepository registra (o reemplaza) un adaptador de repositorios
+func (r *Registry) RegisterRepository(name string, factory RepositoryFactory) {
+	r.repositories[name] = factory
+}
+
+// RegisterEventBus registra (o reemplaza) un adaptador de bus de eventos
+func (r *Registry) RegisterEventBus(name string, factory EventBusFactory) {
+	r.eventBuses[name] = factory
+}
+
+// NewContainer crea un contenedor con los adaptadores indicados en la configuración
+// Falla en cuanto un adaptador es desconocido o está mal configurado
+func (r *Registry) NewContainer(ctx context.Context, cfg *Config) (*Container, error) {
+	repoFactory, ok := r.repositories[cfg.Repository.Adapter]
+	if !ok {
+		return nil, fmt.Errorf("unknown repository adapter %q (available: %s)",
+			cfg.Repository.Adapter, strings.Join(sortedKeys(r.repositories), ", "))
+	}
+	busFactory, ok := r.eventBuses[cfg.EventBus.Adapter]
+	if !ok {
+		return nil, fmt.Errorf("unknown event bus adapter %q (available: %s)",
+			cfg.EventBus.Adapter, strings.Join(sortedKeys(r.eventBuses), ", "))
+	}
+
+	repos, err := repoFactory(ctx, cfg.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("repository adapter %q: %w", cfg.Repository.Adapter, err)
+	}
+	eventBus, err := busFactory(cfg.EventBus)
+	if err != nil {
+		if repos.Close != nil {
+			repos.Close()
+		}
+		return nil, fmt.Errorf("event bus adapter %q: %w", cfg.EventBus.Adapter, err)
+	}
+
+	container := newContainer(repos.Users, repos.Products, repos.Orders, eventBus)
+	if repos.UnitOfWork != nil {
+		container.useUnitOfWork(repos.UnitOfWork)
+	}
+	container.serviceFactory.WithEmailPolicy(entities.EmailPolicy{StripPlusTag: cfg.Users.StripPlusTag})
+	if closer, ok := eventBus.(io.Closer); ok {
+		container.closers = append(container.closers, closer.Close)
+	}
+	if repos.Close != nil {
+		container.closers = append(container.closers, repos.Close)
+	}
+	if err := container.indexer.Rebuild(ctx); err != nil {
+		container.Close()
+		return nil, fmt.Errorf("building product search index: %w", err)
+	}
+	if cfg.EventBus.Outbox {
+		if err := container.StartOutboxRelay(DefaultOutboxRelayInterval); err != nil {
+			container.Close()
+			return nil, err
+		}
+	}
+	return container, nil
+}
+
+// newMemoryRepositories crea los repositorios en memoria
+func newMemoryRepositories(ctx context.Context, cfg RepositoryConfig) (*RepositorySet, error) {
+	return &RepositorySet{
+		Users:      memory.NewUserRepository(),
+		Products:   memory.NewProductRepository(),
+		Orders:     memory.NewOrderRepository(),
+		UnitOfWork: memory.NewUnitOfWork(),
+	}, nil
+}
+
+// newEventSourcedRepositories crea el repositorio de productos basado en eventos
+// Usuarios y pedidos se guardan en memoria; sin UnitOfWork, porque las
+// transacciones en memoria no abarcan el historial de productos
+func newEventSourcedRepositories(ctx context.Context, cfg RepositoryConfig) (*RepositorySet, error) {
+	return &RepositorySet{
+		Users:    memory.NewUserRepository(),
+		Products: eventsourced.NewProductRepository(),
+		Orders:   memory.NewOrderRepository(),
+	}, nil
+}
+
+// newFileRepositories crea los repositorios persistidos en ficheros JSON
+func newFileRepositories(ctx context.Context, cfg RepositoryConfig) (*RepositorySet, error) {
+	if cfg.DataDir == "" {
+		return nil, fmt.Errorf("data_dir is required (or set %s)", EnvDataDir)
+	}
+
+	users, err := file.NewUserRepository(cfg.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	products, err := file.NewProductRepository(cfg.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := file.NewOrderRepository(cfg.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	return &RepositorySet{
+		Users:      users,
+		Products:   products,
+		Orders:     orders,
+		UnitOfWork: file.NewUnitOfWork(cfg.DataDir),
+	}, nil
+}
+
+// newSQLRepositories abre la base de datos, aplica las migraciones y crea los repositorios
+func newSQLRepositories(ctx context.Context, cfg RepositoryConfig) (*RepositorySet, error) {
+	if cfg.Driver == "" {
+		return nil, fmt.Errorf("driver is required (or set %s)", EnvSQLDriver)
+	}
+	if cfg.DSN == "" {
+		return nil, fmt.Errorf("dsn is required (or set %s)", EnvSQLDSN)
+	}
+
+	db, dialect, err := sqldb.Open(ctx, cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	return &RepositorySet{
+		Users:      sqldb.NewUserRepository(db, dialect),
+		Products:   sqldb.NewProductRepository(db, dialect),
+		Orders:     sqldb.NewOrderRepository(db, dialect),
+		UnitOfWork: sqldb.NewUnitOfWork(db),
+		Close:      db.Close,
+	}, nil
+}
+
+// newMemoryEventBus crea el bus de eventos en memoria
+func newMemoryEventBus(cfg EventBusConfig) (events.EventBus, error) {
+	options, err := deliveryOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return events.NewInMemoryEventBusWithOptions(options), nil
+}
+
+// newAsyncEventBus crea el bus de eventos asíncrono con cola acotada
+func newAsyncEventBus(cfg EventBusConfig) (events.EventBus, error) {
+	backpressure, err := events.ParseBackpressure(cfg.Backpressure)
+	if err != nil {
+		return nil, err
+	}
+	options, err := deliveryOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return events.NewAsyncEventBus(events.AsyncOptions{
+		QueueSize:    cfg.QueueSize,
+		Workers:      cfg.Workers,
+		Backpressure: backpressure,
+		Delivery:     options,
+	})
+}
+
+// deliveryOptions traduce la configuración de reintentos y almacén de eventos del bus
+func deliveryOptions(cfg EventBusConfig) (events.DeliveryOptions, error) {
+	options := events.DeliveryOptions{
+		Retry: events.RetryPolicy{MaxAttempts: cfg.MaxAttempts},
+	}
+
+	switch cfg.Store {
+	case "":
+	case AdapterMemory:
+		options.Store = events.NewInMemoryEventStore()
+	case AdapterFile:
+		if cfg.StorePath == "" {
+			return options, fmt.Errorf("store_path is required for the file event store (or set %s)", EnvEventStorePath)
+		}
+		store, err := events.NewFileEventStore(cfg.StorePath)
+		if err != nil {
+			return options, err
+		}
+		options.Store = store
+	default:
+		return options, fmt.Errorf("unknown event store %q (available: memory, file)", cfg.Store)
+	}
+	return options, nil
+}
+
+// sortedKeys retorna las claves de un mapa ordenadas, para mensajes de error estables
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,308 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// Backpressure indica qué hace Publish cuando la cola del bus asíncrono está llena
+type Backpressure string
+
+const (
+	// BackpressureBlock espera a que haya hueco en la cola (o a que termine el contexto)
+	// Un handler del bus que publica con el contexto recibido no espera: si su cola
+	// está llena se rechaza el evento con ErrQueueFull, porque el worker que debe
+	// vaciarla puede ser el suyo y esperar lo bloquearía para siempre
+	BackpressureBlock Backpressure = "block"
+
+	// BackpressureDrop descarta el evento y lo contabiliza en AsyncStats.Dropped
+	BackpressureDrop Backpressure = "drop"
+
+	// BackpressureError rechaza el evento con ErrQueueFull
+	BackpressureError Backpressure = "error"
+)
+
+// Valores por defecto del bus asíncrono
+const (
+	DefaultAsyncQueueSize = 1024
+	DefaultAsyncWorkers   = 4
+)
+
+// Errores del bus asíncrono
+var (
+	ErrQueueFull = errors.New("event queue is full")
+	ErrBusClosed = errors.New("event bus is closed")
+)
+
+// ParseBackpressure convierte el nombre de una política de backpressure
+// Una cadena vacía equivale a BackpressureBlock
+func ParseBackpressure(name string) (Backpressure, error) {
+	switch Backpressure(name) {
+	case "", BackpressureBlock:
+		return BackpressureBlock, nil
+	case BackpressureDrop, BackpressureError:
+		return Backpressure(name), nil
+	default:
+		return "", fmt.Errorf("unknown backpressure policy %q (available: block, drop, error)", name)
+	}
+}
+
+// AsyncOptions configura el bus asíncrono; los valores cero usan los valores por defecto
+type AsyncOptions struct {
+	// QueueSize es la capacidad de la cola de cada worker
+	QueueSize int
+
+	// Workers es el número de goroutines que entregan eventos
+	Workers int
+
+	// Backpressure es la política cuando la cola está llena
+	Backpressure Backpressure
+
+	// Delivery configura los reintentos y el dead-letter de los handlers
+	Delivery DeliveryOptions
+}
+
+// AsyncStats resume el estado del bus asíncrono
+type AsyncStats struct {
+	// Pending es el número de eventos aceptados que aún no se han entregado
+	Pending int
+
+	// Dropped es el número de eventos descartados por BackpressureDrop
+	Dropped uint64
+}
+
+// workerKey es la clave de contexto con la que los workers marcan las entregas
+type workerKey struct{}
+
+// queuedEvent es un evento a la espera de ser entregado
+type queuedEvent struct {
+	ctx      context.Context
+	envelope Envelope
+}
+
+// AsyncEventBus implementa EventBus entregando los eventos en segundo plano
+// Publish solo encola el evento, así que un handler lento no retrasa al servicio
+// que publica. Los eventos de un mismo tipo van siempre a la cola del mismo worker
+// y se entregan en el orden en que se publicaron; tipos distintos se reparten
+// entre los workers y se entregan en paralelo, así que un handler suscrito a un
+// patrón puede ejecutarse a la vez para tipos distintos. Los reintentos de un handler se
+// hacen en el worker, así que retrasan los eventos siguientes del mismo tipo
+type AsyncEventBus struct {
+	*delivery
+	queues       []chan queuedEvent
+	backpressure Backpressure
+
+	// mutex protege closed, pending e idle
+	mutex   sync.Mutex
+	closed  bool
+	pending int
+	idle    chan struct{}
+
+	dropped   atomic.Uint64
+	stop      chan struct{}
+	workers   sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewAsyncEventBus crea el bus asíncrono y arranca sus workers
+// Hay que llamar a Close para entregar los eventos pendientes y detener los workers
+func NewAsyncEventBus(options AsyncOptions) (*AsyncEventBus, error) {
+	if options.QueueSize < 0 {
+		return nil, fmt.Errorf("queue size must be positive, got %d", options.QueueSize)
+	}
+	if options.Workers < 0 {
+		return nil, fmt.Errorf("workers must be positive, got %d", options.Workers)
+	}
+	if options.QueueSize == 0 {
+		options.QueueSize = DefaultAsyncQueueSize
+	}
+	if options.Workers == 0 {
+		options.Workers = DefaultAsyncWorkers
+	}
+	backpressure, err := ParseBackpressure(string(options.Backpressure))
+	if err != nil {
+		return nil, err
+	}
+
+	b := &AsyncEventBus{
+		delivery:     newDelivery(options.Delivery),
+		queues:       make([]chan queuedEvent, options.Workers),
+		backpressure: backpressure,
+		idle:         closedChannel(),
+		stop:         make(chan struct{}),
+	}
+	for i := range b.queues {
+		b.queues[i] = make(chan queuedEvent, options.QueueSize)
+		b.workers.Add(1)
+		go b.work(b.queues[i])
+	}
+	return b, nil
+}
+
+// Publish encola un evento para entregarlo en segundo plano
+// Los handlers reciben un contexto con los valores de ctx pero sin su cancelación,
+// porque normalmente la petición que publica termina antes de la entrega
+func (b *AsyncEventBus) Publish(ctx context.Context, eventType string, event interface{}) error {
+	return b.PublishEnvelope(ctx, NewEnvelope(ctx, eventType, event))
+}
+
+// PublishEnvelope encola un evento ya envuelto
+// Si hay EventStore, el evento se guarda al publicarlo (y no al entregarlo), así
+// que un evento rechazado por el backpressure sigue disponible para Replay
+func (b *AsyncEventBus) PublishEnvelope(ctx context.Context, envelope Envelope) error {
+	eventType := envelope.Type
+
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return fmt.Errorf("publishing %s: %w", eventType, ErrBusClosed)
+	}
+	b.pending++
+	if b.pending == 1 {
+		b.idle = make(chan struct{})
+	}
+	b.mutex.Unlock()
+
+	envelope, err := b.record(ctx, envelope)
+	if err != nil {
+		b.delivered()
+		return err
+	}
+
+	item := queuedEvent{ctx: context.WithoutCancel(ctx), envelope: envelope}
+	queue := b.queues[b.shard(eventType)]
+
+	if b.backpressure == BackpressureBlock && !b.fromWorker(ctx) {
+		select {
+		case queue <- item:
+			return nil
+		case <-ctx.Done():
+			b.delivered()
+			return ctx.Err()
+		}
+	}
+
+	select {
+	case queue <- item:
+		return nil
+	default:
+		b.delivered()
+		if b.backpressure == BackpressureDrop {
+			b.dropped.Add(1)
+			return nil
+		}
+		return fmt.Errorf("publishing %s: %w", eventType, ErrQueueFull)
+	}
+}
+
+// Subscribe suscribe un handler a un tipo de evento
+// Al desuscribirlo tampoco recibe los eventos que ya estuvieran en cola
+func (b *AsyncEventBus) Subscribe(pattern string, handler EventHandler) *Subscription {
+	return b.handlers.subscribe(pattern, handler)
+}
+
+// SubscribeContext suscribe un handler mientras ctx siga activo
+func (b *AsyncEventBus) SubscribeContext(ctx context.Context, pattern string, handler EventHandler) *Subscription {
+	return subscribeContext(ctx, b.handlers, pattern, handler)
+}
+
+// Drain espera a que se entreguen todos los eventos aceptados hasta el momento
+// El bus sigue aceptando eventos; retorna ctx.Err() si el contexto termina antes
+func (b *AsyncEventBus) Drain(ctx context.Context) error {
+	b.mutex.Lock()
+	idle := b.idle
+	b.mutex.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close deja de aceptar eventos, entrega los que están en cola y detiene los workers
+// A partir de aquí Publish retorna ErrBusClosed; es seguro llamarlo varias veces
+func (b *AsyncEventBus) Close() error {
+	b.closeOnce.Do(func() {
+		b.mutex.Lock()
+		b.closed = true
+		b.mutex.Unlock()
+
+		b.Drain(context.Background())
+		close(b.stop)
+		b.workers.Wait()
+	})
+	return nil
+}
+
+// Stats retorna el número de eventos pendientes y descartados
+func (b *AsyncEventBus) Stats() AsyncStats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return AsyncStats{Pending: b.pending, Dropped: b.dropped.Load()}
+}
+
+// work entrega los eventos de una cola hasta que se cierra el bus
+func (b *AsyncEventBus) work(queue chan queuedEvent) {
+	defer b.workers.Done()
+
+	for {
+		select {
+		case item := <-queue:
+			b.deliver(item)
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// deliver ejecuta los handlers suscritos al tipo del evento
+// Nadie espera el resultado, así que un fallo al guardar en el dead-letter
+// solo puede registrarse en el log
+func (b *AsyncEventBus) deliver(item queuedEvent) {
+	defer b.delivered()
+
+	ctx := context.WithValue(item.ctx, workerKey{}, b)
+	if err := b.dispatch(ctx, item.envelope); err != nil {
+		log.Printf("async event bus: %v", err)
+	}
+}
+
+// fromWorker indica si ctx es el de un handler que se ejecuta en un worker de b
+func (b *AsyncEventBus) fromWorker(ctx context.Context) bool {
+	bus, _ := ctx.Value(workerKey{}).(*AsyncEventBus)
+	return bus == b
+}
+
+// delivered descuenta un evento pendiente y avisa a Drain cuando no queda ninguno
+func (b *AsyncEventBus) delivered() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.pending--
+	if b.pending == 0 {
+		close(b.idle)
+	}
+}
+
+// shard elige la cola de un tipo de evento
+// Usar siempre la misma cola para un tipo es lo que garantiza su orden de entrega
+func (b *AsyncEventBus) shard(eventType string) int {
+	h := fnv.New32a()
+	h.Write([]byte(eventType))
+	return int(h.Sum32() % uint32(len(b.queues)))
+}
+
+// closedChannel retorna un canal ya cerrado
+func closedChannel() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
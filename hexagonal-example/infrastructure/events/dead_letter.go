@@ -0,0 +1,152 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultDeadLetterCapacity es el número máximo de entregas fallidas que guarda
+// el almacén en memoria; al superarlo se descartan las más antiguas
+const DefaultDeadLetterCapacity = 1000
+
+// Errores del dead-letter
+var (
+	// ErrDeadLetterNotFound se retorna cuando no existe una entrega fallida con el ID indicado
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+	// ErrHandlerNotSubscribed se retorna al reenviar una entrega cuyo handler ya no
+	// está suscrito; la entrega se conserva en el dead-letter
+	ErrHandlerNotSubscribed = errors.New("failed handler is no longer subscribed")
+)
+
+// DeadLetter es una entrega que falló después de agotar los reintentos
+type DeadLetter struct {
+	ID       string    `json:"id"`
+	Envelope Envelope  `json:"envelope"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+
+	// SubscriptionID identifica la suscripción del handler que falló; al reenviar
+	// solo se le entrega a él. Es 0 si el handler no estaba suscrito (Replay)
+	SubscriptionID uint64 `json:"subscription_id,omitempty"`
+
+	// handler es el handler que falló; solo se usa si no hay SubscriptionID, ya
+	// que un almacén persistente no lo conserva
+	handler EventHandler
+}
+
+// DeadLetterStore guarda las entregas fallidas para poder inspeccionarlas y reenviarlas
+type DeadLetterStore interface {
+	// Add guarda una entrega fallida; si ya existe una con el mismo ID la reemplaza
+	Add(ctx context.Context, letter DeadLetter) error
+
+	// List retorna las entregas fallidas, de la más antigua a la más reciente
+	List(ctx context.Context) ([]DeadLetter, error)
+
+	// Get retorna una entrega fallida o ErrDeadLetterNotFound
+	Get(ctx context.Context, id string) (DeadLetter, error)
+
+	// Remove elimina una entrega fallida o retorna ErrDeadLetterNotFound
+	Remove(ctx context.Context, id string) error
+}
+
+// DeadLetterQueue es la API de los buses para inspeccionar y reenviar entregas fallidas
+type DeadLetterQueue interface {
+	// DeadLetters retorna las entregas fallidas pendientes
+	DeadLetters(ctx context.Context) ([]DeadLetter, error)
+
+	// Redeliver vuelve a entregar una entrega fallida aplicando la política de reintentos
+	// Si tiene éxito se elimina del almacén; si no, se actualiza su error e intentos
+	Redeliver(ctx context.Context, id string) error
+
+	// RedeliverAll reenvía todas las entregas fallidas y retorna cuántas se entregaron
+	RedeliverAll(ctx context.Context) (int, error)
+}
+
+// InMemoryDeadLetterStore implementa DeadLetterStore en memoria con capacidad acotada
+type InMemoryDeadLetterStore struct {
+	letters  []DeadLetter
+	capacity int
+	mutex    sync.RWMutex
+}
+
+// NewInMemoryDeadLetterStore crea un almacén en memoria
+// Si capacity no es positiva se usa DefaultDeadLetterCapacity
+func NewInMemoryDeadLetterStore(capacity int) *InMemoryDeadLetterStore {
+	if capacity <= 0 {
+		capacity = DefaultDeadLetterCapacity
+	}
+	return &InMemoryDeadLetterStore{capacity: capacity}
+}
+
+// Add guarda una entrega fallida
+func (s *InMemoryDeadLetterStore) Add(ctx context.Context, letter DeadLetter) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if i := s.indexOf(letter.ID); i >= 0 {
+		s.letters = append(s.letters[:i], s.letters[i+1:]...)
+	}
+	if len(s.letters) >= s.capacity {
+		s.letters = s.letters[1:]
+	}
+	s.letters = append(s.letters, letter)
+	return nil
+}
+
+// List retorna una copia de las entregas fallidas
+func (s *InMemoryDeadLetterStore) List(ctx context.Context) ([]DeadLetter, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	letters := make([]DeadLetter, len(s.letters))
+	copy(letters, s.letters)
+	return letters, nil
+}
+
+// Get retorna una entrega fallida por ID
+func (s *InMemoryDeadLetterStore) Get(ctx context.Context, id string) (DeadLetter, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return s.letters[i], nil
+}
+
+// Remove elimina una entrega fallida por ID
+func (s *InMemoryDeadLetterStore) Remove(ctx context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return ErrDeadLetterNotFound
+	}
+	s.letters = append(s.letters[:i], s.letters[i+1:]...)
+	return nil
+}
+
+// indexOf retorna la posición de una entrega fallida o -1; requiere el mutex
+func (s *InMemoryDeadLetterStore) indexOf(id string) int {
+	for i, letter := range s.letters {
+		if letter.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// newDeadLetterID genera un identificador aleatorio para una entrega fallida
+func newDeadLetterID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "dl_" + hex.EncodeToString(b)
+}
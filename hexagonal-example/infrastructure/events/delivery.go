@@ -0,0 +1,256 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Valores por defecto de la política de reintentos
+const (
+	DefaultMaxAttempts    = 3
+	DefaultInitialBackoff = 10 * time.Millisecond
+	DefaultMaxBackoff     = time.Second
+)
+
+// RetryPolicy define cuántas veces se intenta entregar un evento a un handler
+// y cuánto se espera entre intentos; la espera se duplica en cada reintento
+// Los valores cero usan los valores por defecto
+type RetryPolicy struct {
+	// MaxAttempts es el número total de intentos, incluido el primero
+	MaxAttempts int
+
+	// InitialBackoff es la espera antes del primer reintento
+	// Con 0, InMemoryEventBus reintenta sin esperar, porque la espera bloquearía a
+	// quien publica; AsyncEventBus usa DefaultInitialBackoff
+	InitialBackoff time.Duration
+
+	// MaxBackoff limita la espera entre reintentos
+	MaxBackoff time.Duration
+}
+
+// withDefaults completa los valores cero de la política
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultMaxBackoff
+	}
+	return p
+}
+
+// backoff retorna la espera antes del reintento indicado (1 para el primero)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < retry && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	return wait
+}
+
+// DeliveryOptions configura cómo entregan los buses los eventos a sus handlers
+type DeliveryOptions struct {
+	// Retry es la política de reintentos de cada handler
+	Retry RetryPolicy
+
+	// DeadLetters guarda las entregas que agotan los reintentos
+	// Si es nil se usa un InMemoryDeadLetterStore con la capacidad por defecto
+	DeadLetters DeadLetterStore
+
+	// Store, si no es nil, guarda cada evento publicado antes de entregarlo y
+	// permite reproducirlos con Replay y SubscribeFrom
+	Store EventStore
+}
+
+// delivery entrega eventos a los handlers suscritos con reintentos y dead-letter
+// Es la parte común de InMemoryEventBus y AsyncEventBus
+type delivery struct {
+	handlers    *handlerRegistry
+	retry       RetryPolicy
+	deadLetters DeadLetterStore
+	store       EventStore
+}
+
+// newDelivery crea la entrega de eventos a partir de sus opciones
+func newDelivery(options DeliveryOptions) *delivery {
+	deadLetters := options.DeadLetters
+	if deadLetters == nil {
+		deadLetters = NewInMemoryDeadLetterStore(DefaultDeadLetterCapacity)
+	}
+	return &delivery{
+		handlers:    newHandlerRegistry(),
+		retry:       options.Retry.withDefaults(),
+		deadLetters: deadLetters,
+		store:       options.Store,
+	}
+}
+
+// record guarda un evento en el almacén, si lo hay, y retorna el envelope con su posición
+// Los envelopes que ya tienen posición vienen del almacén y no se vuelven a guardar
+func (d *delivery) record(ctx context.Context, envelope Envelope) (Envelope, error) {
+	if d.store == nil || envelope.Position != 0 {
+		return envelope, nil
+	}
+	stored, err := d.store.Append(ctx, envelope)
+	if err != nil {
+		return envelope, fmt.Errorf("storing %s: %w", envelope.Type, err)
+	}
+	return stored, nil
+}
+
+// dispatch entrega un evento a todos sus handlers
+// Un handler que agota los reintentos no impide la entrega al resto: su entrega se
+// guarda como dead-letter. Solo se retorna error si no se pudo guardar
+func (d *delivery) dispatch(ctx context.Context, envelope Envelope) error {
+	var errs []error
+	for _, s := range d.handlers.lookup(envelope.Type) {
+		if err := d.deliverOrDeadLetter(ctx, s.id, s.handler, envelope); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("delivering %s: %w", envelope.Type, errors.Join(errs...))
+	}
+	return nil
+}
+
+// deliverOrDeadLetter entrega un evento a un handler y, si agota los reintentos,
+// guarda la entrega en el dead-letter junto con la suscripción del handler
+// (subscriptionID es 0 si el handler no está suscrito, como en Replay)
+func (d *delivery) deliverOrDeadLetter(ctx context.Context, subscriptionID uint64, handler EventHandler, envelope Envelope) error {
+	attempts, err := d.deliverWithRetry(ctx, handler, envelope)
+	if err == nil {
+		return nil
+	}
+	letter := DeadLetter{
+		ID:       newDeadLetterID(),
+		Envelope: envelope,
+		Error:    err.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now(),
+
+		SubscriptionID: subscriptionID,
+		handler:        handler,
+	}
+	if storeErr := d.deadLetters.Add(ctx, letter); storeErr != nil {
+		return fmt.Errorf("handler failed after %d attempts (%v) and could not be dead-lettered: %w", attempts, err, storeErr)
+	}
+	return nil
+}
+
+// deliverWithRetry entrega un evento a un handler reintentando con espera exponencial
+// Retorna el número de intentos realizados y el último error
+func (d *delivery) deliverWithRetry(ctx context.Context, handler EventHandler, envelope Envelope) (int, error) {
+	handlerCtx := handlerContext(ctx, envelope)
+
+	var err error
+	for attempt := 1; attempt <= d.retry.MaxAttempts; attempt++ {
+		if err = handleSafely(handlerCtx, handler, envelope); err == nil {
+			return attempt, nil
+		}
+		if attempt == d.retry.MaxAttempts {
+			return attempt, err
+		}
+
+		wait := d.retry.backoff(attempt)
+		if wait <= 0 {
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, err
+		}
+	}
+	return d.retry.MaxAttempts, err
+}
+
+// DeadLetters retorna las entregas fallidas pendientes
+func (d *delivery) DeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	return d.deadLetters.List(ctx)
+}
+
+// Redeliver vuelve a entregar una entrega fallida, solo al handler que falló
+func (d *delivery) Redeliver(ctx context.Context, id string) error {
+	letter, err := d.deadLetters.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	handler, err := d.failedHandler(letter)
+	if err != nil {
+		return fmt.Errorf("redelivering %s: %w", id, err)
+	}
+
+	attempts, err := d.deliverWithRetry(ctx, handler, letter.Envelope)
+	letter.Attempts += attempts
+	if err != nil {
+		letter.Error = err.Error()
+		letter.FailedAt = time.Now()
+		if storeErr := d.deadLetters.Add(ctx, letter); storeErr != nil {
+			return errors.Join(err, storeErr)
+		}
+		return fmt.Errorf("redelivering %s: %w", id, err)
+	}
+	return d.deadLetters.Remove(ctx, id)
+}
+
+// failedHandler retorna el handler al que se reenvía una entrega fallida
+// Si la entrega tiene suscripción se busca en el registro, así que también se
+// resuelve desde un almacén que no conserve el handler; si la suscripción ya no
+// existe (o la entrega no tiene suscripción ni handler) se retorna
+// ErrHandlerNotSubscribed en lugar de entregarla a otros handlers
+func (d *delivery) failedHandler(letter DeadLetter) (EventHandler, error) {
+	if letter.SubscriptionID == 0 {
+		if letter.handler == nil {
+			return nil, ErrHandlerNotSubscribed
+		}
+		return letter.handler, nil
+	}
+	for _, s := range d.handlers.lookup(letter.Envelope.Type) {
+		if s.id == letter.SubscriptionID {
+			return s.handler, nil
+		}
+	}
+	return nil, ErrHandlerNotSubscribed
+}
+
+// RedeliverAll reenvía todas las entregas fallidas
+func (d *delivery) RedeliverAll(ctx context.Context) (int, error) {
+	letters, err := d.deadLetters.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	var errs []error
+	for _, letter := range letters {
+		if err := d.Redeliver(ctx, letter.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delivered++
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// handleSafely ejecuta un handler convirtiendo un panic en error
+// Sin esto, un handler defectuoso tumbaría la goroutine que entrega y con ella el proceso
+func handleSafely(ctx context.Context, handler EventHandler, envelope Envelope) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event handler panicked: %v", r)
+		}
+	}()
+	return handler.Handle(ctx, envelope)
+}
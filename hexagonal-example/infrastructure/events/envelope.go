@@ -0,0 +1,126 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// DefaultSchemaVersion es la versión de esquema de los eventos que no indican otra
+const DefaultSchemaVersion = 1
+
+// Envelope envuelve cada evento publicado con los metadatos necesarios para
+// de-duplicarlo, trazarlo y ordenarlo fuera del proceso
+type Envelope struct {
+	// ID identifica el evento de forma única; se conserva al reenviarlo
+	ID string `json:"id"`
+
+	// Type es el tipo del evento ("user.created")
+	Type string `json:"type"`
+
+	// AggregateID es el ID de la entidad a la que se refiere el evento, si se conoce
+	AggregateID string `json:"aggregate_id,omitempty"`
+
+	// OccurredAt es el momento en que se publicó el evento
+	OccurredAt time.Time `json:"occurred_at"`
+
+	// SchemaVersion es la versión del esquema de Payload
+	SchemaVersion int `json:"schema_version"`
+
+	// CorrelationID agrupa todos los eventos derivados de una misma petición
+	CorrelationID string `json:"correlation_id"`
+
+	// CausationID es el ID del evento cuyo handler publicó este, si lo hay
+	CausationID string `json:"causation_id,omitempty"`
+
+	// Position es la posición del evento en el EventStore del bus (0 si no se guardó)
+	Position uint64 `json:"position,omitempty"`
+
+	// Payload es el evento en sí (por ejemplo un UserCreatedEvent)
+	Payload interface{} `json:"payload"`
+}
+
+// AggregateEvent es un evento que conoce el ID de su agregado
+type AggregateEvent interface {
+	AggregateID() string
+}
+
+// VersionedEvent es un evento cuyo esquema no es DefaultSchemaVersion
+// Los eventos implementan SchemaVersion cuando cambian de forma incompatible
+type VersionedEvent interface {
+	SchemaVersion() int
+}
+
+// NewEnvelope envuelve un evento tomando la correlación y la causalidad del contexto
+// Si el contexto no tiene ID de correlación, el evento inicia una cadena nueva y
+// su propio ID hace de correlación
+func NewEnvelope(ctx context.Context, eventType string, event interface{}) Envelope {
+	envelope := Envelope{
+		ID:            newEventID(),
+		Type:          eventType,
+		OccurredAt:    time.Now().UTC(),
+		SchemaVersion: DefaultSchemaVersion,
+		CorrelationID: CorrelationID(ctx),
+		CausationID:   CausationID(ctx),
+		Payload:       event,
+	}
+	if envelope.CorrelationID == "" {
+		envelope.CorrelationID = envelope.ID
+	}
+	if aggregate, ok := event.(AggregateEvent); ok {
+		envelope.AggregateID = aggregate.AggregateID()
+	}
+	if versioned, ok := event.(VersionedEvent); ok {
+		envelope.SchemaVersion = versioned.SchemaVersion()
+	}
+	return envelope
+}
+
+// Claves de contexto de los metadatos de eventos
+type (
+	correlationKey struct{}
+	causationKey   struct{}
+)
+
+// WithCorrelationID retorna un contexto cuyos eventos llevarán el ID de correlación indicado
+// Los adaptadores de entrada lo fijan por petición (ver X-Correlation-ID en el adaptador HTTP)
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, correlationID)
+}
+
+// CorrelationID retorna el ID de correlación del contexto, o "" si no tiene
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// WithCausationID retorna un contexto cuyos eventos indicarán como causa el evento dado
+func WithCausationID(ctx context.Context, causationID string) context.Context {
+	return context.WithValue(ctx, causationKey{}, causationID)
+}
+
+// CausationID retorna el ID del evento causante del contexto, o "" si no tiene
+func CausationID(ctx context.Context) string {
+	id, _ := ctx.Value(causationKey{}).(string)
+	return id
+}
+
+// handlerContext prepara el contexto con el que se llama a los handlers de un evento
+// Lo que publiquen los handlers hereda su correlación y lo tiene como causa
+func handlerContext(ctx context.Context, envelope Envelope) context.Context {
+	ctx = WithCorrelationID(ctx, envelope.CorrelationID)
+	return WithCausationID(ctx, envelope.ID)
+}
+
+// NewCorrelationID genera un ID de correlación nuevo
+func NewCorrelationID() string {
+	return newEventID()
+}
+
+// newEventID genera un identificador aleatorio para un evento
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
@@ -2,86 +2,95 @@ package events
 
 import (
 	"context"
-	"sync"
 )
 
 // EventBus define la interfaz para el bus de eventos
 // Este patrón permite desacoplar la publicación de eventos de su procesamiento
 type EventBus interface {
-	// Publish publica un evento en el bus
+	// Publish publica un evento en el bus envuelto en un Envelope (ver NewEnvelope)
 	Publish(ctx context.Context, eventType string, event interface{}) error
 
-	// Subscribe suscribe un handler a un tipo de evento
-	Subscribe(eventType string, handler EventHandler)
+	// PublishEnvelope publica un evento ya envuelto, conservando su ID y metadatos
+	// Sirve para reenviar eventos guardados sin que cambie su identidad
+	PublishEnvelope(ctx context.Context, envelope Envelope) error
 
-	// Unsubscribe desuscribe un handler de un tipo de evento
-	Unsubscribe(eventType string, handler EventHandler)
+	// Subscribe suscribe un handler a un tipo de evento o a un patrón ("product.*",
+	// "*.created", AllEvents; ver MatchPattern para las reglas)
+	// El handler recibe eventos hasta que se llama a Unsubscribe en la suscripción
+	Subscribe(pattern string, handler EventHandler) *Subscription
+
+	// SubscribeContext suscribe un handler mientras ctx siga activo
+	// Pensado para consumidores de vida corta, como un stream ligado a una petición
+	SubscribeContext(ctx context.Context, pattern string, handler EventHandler) *Subscription
 }
 
 // EventHandler define la interfaz para manejar eventos
+// El handler recibe el evento envuelto; el evento en sí está en envelope.Payload
+// El contexto lleva la correlación del evento y a él como causa, de modo que lo
+// que publique el handler queda enlazado con el evento que lo originó
 type EventHandler interface {
-	Handle(ctx context.Context, event interface{}) error
+	Handle(ctx context.Context, envelope Envelope) error
 }
 
 // EventHandlerFunc es una función que implementa EventHandler
-type EventHandlerFunc func(ctx context.Context, event interface{}) error
+type EventHandlerFunc func(ctx context.Context, envelope Envelope) error
 
 // Handle implementa EventHandler para EventHandlerFunc
-func (f EventHandlerFunc) Handle(ctx context.Context, event interface{}) error {
-	return f(ctx, event)
+func (f EventHandlerFunc) Handle(ctx context.Context, envelope Envelope) error {
+	return f(ctx, envelope)
 }
 
 // InMemoryEventBus implementa EventBus usando memoria
-// Esta es una implementación simple para propósitos de demostración
+// Esta es una implementación simple para propósitos de demostración: los handlers
+// se ejecutan en la goroutine de quien publica (ver AsyncEventBus para la versión
+// asíncrona). Los handlers que fallan se reintentan y, si agotan los reintentos,
+// la entrega queda en el dead-letter para inspeccionarla y reenviarla
 type InMemoryEventBus struct {
-	handlers map[string][]EventHandler
-	mutex    sync.RWMutex
+	*delivery
 }
 
 // NewInMemoryEventBus crea una nueva instancia del event bus en memoria
 func NewInMemoryEventBus() EventBus {
+	return NewInMemoryEventBusWithOptions(DeliveryOptions{})
+}
+
+// NewInMemoryEventBusWithOptions crea el event bus en memoria con una política de
+// reintentos y un almacén de dead-letter propios
+func NewInMemoryEventBusWithOptions(options DeliveryOptions) *InMemoryEventBus {
+	d := newDelivery(options)
+	// Los reintentos se hacen en la goroutine que publica: sin una espera
+	// configurada se reintenta de inmediato en lugar de retrasar al servicio
+	if options.Retry.InitialBackoff <= 0 {
+		d.retry.InitialBackoff = 0
+	}
 	return &InMemoryEventBus{
-		handlers: make(map[string][]EventHandler),
+		delivery: d,
 	}
 }
 
 // Publish publica un evento en el bus
+// Solo retorna error si una entrega fallida no se pudo guardar en el dead-letter
 func (b *InMemoryEventBus) Publish(ctx context.Context, eventType string, event interface{}) error {
-	b.mutex.RLock()
-	handlers := b.handlers[eventType]
-	b.mutex.RUnlock()
+	return b.PublishEnvelope(ctx, NewEnvelope(ctx, eventType, event))
+}
 
-	// Ejecutar todos los handlers para este tipo de evento
-	for _, handler := range handlers {
-		if err := handler.Handle(ctx, event); err != nil {
-			// En un sistema real, podrías querer loggear el error
-			// pero no fallar la publicación del evento
-			// log.Printf("Error handling event %s: %v", eventType, err)
-		}
+// PublishEnvelope publica un evento ya envuelto
+// Si hay EventStore, el evento se guarda antes de entregarlo; si no se puede
+// guardar, no se entrega
+func (b *InMemoryEventBus) PublishEnvelope(ctx context.Context, envelope Envelope) error {
+	envelope, err := b.record(ctx, envelope)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return b.dispatch(ctx, envelope)
 }
 
-// Subscribe suscribe un handler a un tipo de evento
-func (b *InMemoryEventBus) Subscribe(eventType string, handler EventHandler) {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
-
-	b.handlers[eventType] = append(b.handlers[eventType], handler)
+// Subscribe suscribe un handler a un tipo de evento o patrón
+func (b *InMemoryEventBus) Subscribe(pattern string, handler EventHandler) *Subscription {
+	return b.handlers.subscribe(pattern, handler)
 }
 
-// Unsubscribe desuscribe un handler de un tipo de evento
-func (b *InMemoryEventBus) Unsubscribe(eventType string, handler EventHandler) {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
-
-	handlers := b.handlers[eventType]
-	for i, h := range handlers {
-		if h == handler {
-			// Remover el handler de la lista
-			b.handlers[eventType] = append(handlers[:i], handlers[i+1:]...)
-			break
-		}
-	}
-}
\ No newline at end of file
+// SubscribeContext suscribe un handler mientras ctx siga activo
+func (b *InMemoryEventBus) SubscribeContext(ctx context.Context, pattern string, handler EventHandler) *Subscription {
+	return subscribeContext(ctx, b.handlers, pattern, handler)
+}
@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNoEventStore se retorna al reproducir eventos en un bus sin almacén de eventos
+var ErrNoEventStore = errors.New("event bus has no event store")
+
+// EventStore es un almacén de eventos de solo añadir
+// Cada evento recibe una posición consecutiva a partir de 1, que permite
+// reproducir los eventos desde un punto concreto
+type EventStore interface {
+	// Append guarda un evento y retorna el envelope con su posición asignada
+	Append(ctx context.Context, envelope Envelope) (Envelope, error)
+
+	// Load retorna los eventos con posición mayor que after, en orden, hasta limit
+	// (todos si limit no es positivo)
+	Load(ctx context.Context, after uint64, limit int) ([]Envelope, error)
+
+	// LastPosition retorna la posición del último evento guardado (0 si no hay ninguno)
+	LastPosition(ctx context.Context) (uint64, error)
+}
+
+// Replayer es la API de los buses con EventStore para reproducir eventos guardados
+type Replayer interface {
+	// Replay entrega a handler los eventos guardados posteriores a after que casan con pattern
+	Replay(ctx context.Context, pattern string, after uint64, handler EventHandler) (uint64, error)
+
+	// SubscribeFrom reproduce los eventos posteriores a after y sigue con los nuevos
+	SubscribeFrom(ctx context.Context, pattern string, after uint64, handler EventHandler) (*Subscription, error)
+}
+
+// InMemoryEventStore implementa EventStore en memoria
+type InMemoryEventStore struct {
+	events []Envelope
+	mutex  sync.RWMutex
+}
+
+// NewInMemoryEventStore crea un almacén de eventos vacío en memoria
+func NewInMemoryEventStore() *InMemoryEventStore {
+	return &InMemoryEventStore{}
+}
+
+// Append guarda un evento al final del almacén
+func (s *InMemoryEventStore) Append(ctx context.Context, envelope Envelope) (Envelope, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	envelope.Position = uint64(len(s.events)) + 1
+	s.events = append(s.events, envelope)
+	return envelope, nil
+}
+
+// Load retorna los eventos posteriores a una posición
+func (s *InMemoryEventStore) Load(ctx context.Context, after uint64, limit int) ([]Envelope, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return window(s.events, after, limit), nil
+}
+
+// LastPosition retorna la posición del último evento
+func (s *InMemoryEventStore) LastPosition(ctx context.Context) (uint64, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return uint64(len(s.events)), nil
+}
+
+// window retorna una copia de los eventos con posición mayor que after, hasta limit
+// Requiere que events[i] tenga la posición i+1
+func window(events []Envelope, after uint64, limit int) []Envelope {
+	if after >= uint64(len(events)) {
+		return []Envelope{}
+	}
+	selected := events[after:]
+	if limit > 0 && len(selected) > limit {
+		selected = selected[:limit]
+	}
+	result := make([]Envelope, len(selected))
+	copy(result, selected)
+	return result
+}
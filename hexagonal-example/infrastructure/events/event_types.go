@@ -0,0 +1,69 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// eventTypes asocia cada tipo de evento con su tipo Go, para poder reconstruir el
+// payload de los envelopes leídos de un almacén persistente
+var eventTypes = struct {
+	types map[string]reflect.Type
+	mutex sync.RWMutex
+}{types: make(map[string]reflect.Type)}
+
+// RegisterEventType registra el tipo Go de un evento para decodificar su payload
+// Los eventos incluidos en este paquete ya están registrados; los payloads de
+// tipos no registrados se conservan como json.RawMessage
+func RegisterEventType[T Event]() {
+	eventTypes.mutex.Lock()
+	defer eventTypes.mutex.Unlock()
+
+	eventTypes.types[TypeOf[T]()] = reflect.TypeFor[T]()
+}
+
+func init() {
+	RegisterEventType[UserCreatedEvent]()
+	RegisterEventType[UserUpdatedEvent]()
+	RegisterEventType[UserDeactivatedEvent]()
+	RegisterEventType[UserActivatedEvent]()
+	RegisterEventType[UserDeletedEvent]()
+	RegisterEventType[ProductCreatedEvent]()
+	RegisterEventType[ProductUpdatedEvent]()
+	RegisterEventType[StockUpdatedEvent]()
+	RegisterEventType[ProductDeactivatedEvent]()
+	RegisterEventType[ProductActivatedEvent]()
+	RegisterEventType[ProductDeletedEvent]()
+	RegisterEventType[OrderCreatedEvent]()
+	RegisterEventType[OrderCancelledEvent]()
+}
+
+// UnmarshalJSON decodifica un envelope reconstruyendo su payload con el tipo registrado
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	type envelopeJSON Envelope
+	var raw struct {
+		envelopeJSON
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*e = Envelope(raw.envelopeJSON)
+	e.Payload = raw.Payload
+
+	eventTypes.mutex.RLock()
+	t, ok := eventTypes.types[e.Type]
+	eventTypes.mutex.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	payload := reflect.New(t)
+	if err := json.Unmarshal(raw.Payload, payload.Interface()); err != nil {
+		return err
+	}
+	e.Payload = payload.Elem().Interface()
+	return nil
+}
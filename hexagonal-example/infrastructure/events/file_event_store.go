@@ -0,0 +1,166 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileEventStore implementa EventStore sobre un fichero JSON Lines de solo añadir
+// Cada línea es un Envelope; la posición de un evento es su número de línea
+// Cada Append se sincroniza a disco antes de retornar. El fichero pertenece a un
+// único proceso: dos procesos escribiendo a la vez asignarían posiciones repetidas
+type FileEventStore struct {
+	path string
+
+	// offsets[i] es el desplazamiento en bytes del evento con posición i+1
+	offsets []int64
+	size    int64
+	mutex   sync.RWMutex
+}
+
+// NewFileEventStore abre (o crea) el fichero de eventos indicado
+// Si la última línea quedó a medias por una caída del proceso, se descarta
+func NewFileEventStore(path string) (*FileEventStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating event store directory: %w", err)
+	}
+
+	s := &FileEventStore{path: path}
+	if err := s.index(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// index recorre el fichero para conocer el desplazamiento de cada evento
+func (s *FileEventStore) index() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("opening event store: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				// Escritura interrumpida: la línea no llegó a completarse
+				return s.truncate(offset)
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading event store: %w", err)
+		}
+		if !json.Valid(line) {
+			if _, err := reader.Peek(1); errors.Is(err, io.EOF) {
+				return s.truncate(offset)
+			}
+			return fmt.Errorf("event store %s is corrupt at event %d", s.path, len(s.offsets)+1)
+		}
+		s.offsets = append(s.offsets, offset)
+		offset += int64(len(line))
+	}
+	s.size = offset
+	return nil
+}
+
+// truncate descarta el final del fichero a partir de offset
+func (s *FileEventStore) truncate(offset int64) error {
+	if err := os.Truncate(s.path, offset); err != nil {
+		return fmt.Errorf("recovering event store: %w", err)
+	}
+	s.size = offset
+	return nil
+}
+
+// Append añade un evento al final del fichero y lo sincroniza a disco
+func (s *FileEventStore) Append(ctx context.Context, envelope Envelope) (Envelope, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	envelope.Position = uint64(len(s.offsets)) + 1
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("encoding event: %w", err)
+	}
+	data = append(data, '\n')
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("opening event store: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		// Deshacer la escritura parcial para no dejar una línea a medias
+		os.Truncate(s.path, s.size)
+		return Envelope{}, fmt.Errorf("appending event: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return Envelope{}, fmt.Errorf("syncing event store: %w", err)
+	}
+
+	s.offsets = append(s.offsets, s.size)
+	s.size += int64(len(data))
+	return envelope, nil
+}
+
+// Load lee los eventos posteriores a una posición
+func (s *FileEventStore) Load(ctx context.Context, after uint64, limit int) ([]Envelope, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if after >= uint64(len(s.offsets)) {
+		return []Envelope{}, nil
+	}
+	count := len(s.offsets) - int(after)
+	if limit > 0 && count > limit {
+		count = limit
+	}
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("opening event store: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(s.offsets[after], io.SeekStart); err != nil {
+		return nil, fmt.Errorf("reading event store: %w", err)
+	}
+
+	reader := bufio.NewReader(f)
+	envelopes := make([]Envelope, 0, count)
+	for len(envelopes) < count {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return nil, fmt.Errorf("reading event store: %w", err)
+		}
+		var envelope Envelope
+		if err := json.Unmarshal(bytes.TrimSpace(line), &envelope); err != nil {
+			return nil, fmt.Errorf("decoding event %d: %w", int(after)+len(envelopes)+1, err)
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	return envelopes, nil
+}
+
+// LastPosition retorna la posición del último evento
+func (s *FileEventStore) LastPosition(ctx context.Context) (uint64, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return uint64(len(s.offsets)), nil
+}
@@ -0,0 +1,224 @@
+package events
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxCachedEventTypes limita la caché de handlers por tipo de evento; al
+// superarlo se vacía, así que un número muy alto de tipos distintos solo
+// cuesta volver a recorrer el árbol de patrones
+const maxCachedEventTypes = 1024
+
+// subscriber es un handler suscrito, identificado por el ID de su suscripción
+// Los handlers no se comparan entre sí: los EventHandlerFunc no son comparables
+type subscriber struct {
+	id      uint64
+	handler EventHandler
+}
+
+// patternNode es un nodo del árbol de patrones: cada nivel es un segmento
+type patternNode struct {
+	// children indexa los segmentos literales y el comodín "*"
+	children map[string]*patternNode
+
+	// subscribers son los patrones que terminan exactamente en este nodo
+	subscribers []subscriber
+
+	// rest son los patrones que terminan en "**" tras este nodo
+	rest []subscriber
+}
+
+// handlerRegistry guarda los handlers suscritos a tipos de evento y patrones
+// Lo comparten las implementaciones de EventBus para que la semántica de
+// Subscribe y de las suscripciones sea la misma en todas
+//
+// Los tipos exactos se resuelven con un mapa y los patrones con un árbol por
+// segmentos, de modo que el coste de publicar no depende del número de
+// suscripciones sino de la profundidad del tipo. El resultado se cachea por
+// tipo de evento hasta que se suscribe o se da de baja un patrón que lo incluye
+type handlerRegistry struct {
+	exact    map[string][]subscriber
+	patterns *patternNode
+	cache    map[string][]subscriber
+	nextID   uint64
+	mutex    sync.RWMutex
+}
+
+// newHandlerRegistry crea un registro vacío
+func newHandlerRegistry() *handlerRegistry {
+	return &handlerRegistry{
+		exact:    make(map[string][]subscriber),
+		patterns: &patternNode{},
+		cache:    make(map[string][]subscriber),
+	}
+}
+
+// subscribe añade un handler a un tipo de evento o patrón y retorna su suscripción
+// Entra en pánico si el patrón no es válido (ver ValidatePattern)
+func (r *handlerRegistry) subscribe(pattern string, handler EventHandler) *Subscription {
+	if err := ValidatePattern(pattern); err != nil {
+		panic("events: " + err.Error())
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.nextID++
+	s := subscriber{id: r.nextID, handler: handler}
+	if IsPattern(pattern) {
+		node, rest := r.patterns.walk(pattern, true)
+		if rest {
+			node.rest = append(node.rest, s)
+		} else {
+			node.subscribers = append(node.subscribers, s)
+		}
+	} else {
+		r.exact[pattern] = append(r.exact[pattern], s)
+	}
+	r.invalidate(pattern)
+	return newSubscription(r, pattern, r.nextID)
+}
+
+// remove elimina la suscripción indicada; no hace nada si ya no existe
+func (r *handlerRegistry) remove(pattern string, id uint64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if IsPattern(pattern) {
+		segments, rest := splitPattern(pattern)
+		r.patterns.remove(segments, rest, id)
+	} else {
+		if remaining := without(r.exact[pattern], id); len(remaining) == 0 {
+			delete(r.exact, pattern)
+		} else {
+			r.exact[pattern] = remaining
+		}
+	}
+	r.invalidate(pattern)
+}
+
+// invalidate elimina de la caché los tipos de evento que casan con pattern,
+// los únicos cuyos handlers cambian al suscribirlo o darlo de baja
+// Debe llamarse con el mutex bloqueado
+func (r *handlerRegistry) invalidate(pattern string) {
+	if !IsPattern(pattern) {
+		delete(r.cache, pattern)
+		return
+	}
+	for eventType := range r.cache {
+		if MatchPattern(pattern, eventType) {
+			delete(r.cache, eventType)
+		}
+	}
+}
+
+// lookup retorna los handlers que reciben un tipo de evento, en orden de suscripción
+// El slice retornado no se modifica después, así que puede recorrerse sin bloqueo
+func (r *handlerRegistry) lookup(eventType string) []subscriber {
+	r.mutex.RLock()
+	subscribers, ok := r.cache[eventType]
+	r.mutex.RUnlock()
+	if ok {
+		return subscribers
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if subscribers, ok := r.cache[eventType]; ok {
+		return subscribers
+	}
+
+	subscribers = append([]subscriber(nil), r.exact[eventType]...)
+	subscribers = r.patterns.match(strings.Split(eventType, "."), subscribers)
+	sort.Slice(subscribers, func(i, j int) bool { return subscribers[i].id < subscribers[j].id })
+
+	if len(r.cache) >= maxCachedEventTypes {
+		clear(r.cache)
+	}
+	r.cache[eventType] = subscribers
+	return subscribers
+}
+
+// walk recorre (y si create es true, crea) los nodos de un patrón
+// Retorna el nodo del último segmento literal o "*" y si el patrón termina en "**"
+func (n *patternNode) walk(pattern string, create bool) (*patternNode, bool) {
+	segments, rest := splitPattern(pattern)
+
+	node := n
+	for _, segment := range segments {
+		child := node.children[segment]
+		if child == nil {
+			if !create {
+				return nil, rest
+			}
+			if node.children == nil {
+				node.children = make(map[string]*patternNode)
+			}
+			child = &patternNode{}
+			node.children[segment] = child
+		}
+		node = child
+	}
+	return node, rest
+}
+
+// remove elimina una suscripción del patrón formado por segments (y "**" si rest
+// es true) y poda los nodos que quedan vacíos al volver
+// Retorna si este nodo quedó vacío, para que su padre lo elimine
+func (n *patternNode) remove(segments []string, rest bool, id uint64) bool {
+	if len(segments) == 0 {
+		if rest {
+			n.rest = without(n.rest, id)
+		} else {
+			n.subscribers = without(n.subscribers, id)
+		}
+	} else if child := n.children[segments[0]]; child != nil {
+		if child.remove(segments[1:], rest, id) {
+			delete(n.children, segments[0])
+		}
+	}
+	return len(n.children) == 0 && len(n.subscribers) == 0 && len(n.rest) == 0
+}
+
+// match añade a out los suscriptores cuyos patrones casan con los segmentos
+func (n *patternNode) match(segments []string, out []subscriber) []subscriber {
+	if len(segments) > 0 {
+		out = append(out, n.rest...)
+	}
+	if len(segments) == 0 {
+		return append(out, n.subscribers...)
+	}
+	if child := n.children[segments[0]]; child != nil {
+		out = child.match(segments[1:], out)
+	}
+	if child := n.children[SingleSegmentWildcard]; child != nil {
+		out = child.match(segments[1:], out)
+	}
+	return out
+}
+
+// splitPattern separa un patrón en segmentos e indica si termina en "**", que
+// no se incluye entre los segmentos
+func splitPattern(pattern string) ([]string, bool) {
+	segments := strings.Split(pattern, ".")
+	if segments[len(segments)-1] == MultiSegmentWildcard {
+		return segments[:len(segments)-1], true
+	}
+	return segments, false
+}
+
+// without retorna una copia de subscribers sin la suscripción indicada
+// Se copia en lugar de modificar el slice porque los lectores pueden estar
+// recorriendo la versión anterior
+func without(subscribers []subscriber, id uint64) []subscriber {
+	remaining := make([]subscriber, 0, len(subscribers))
+	for _, s := range subscribers {
+		if s.id != id {
+			remaining = append(remaining, s)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,140 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"hexagonal-example/domain/entities"
+)
+
+// OrderItemData representa una línea de pedido dentro de los eventos
+type OrderItemData struct {
+	ProductID string         `json:"product_id"`
+	Quantity  int            `json:"quantity"`
+	UnitPrice entities.Money `json:"unit_price"`
+}
+
+// OrderCreatedEvent representa el evento cuando se realiza un pedido
+type OrderCreatedEvent struct {
+	OrderID   string          `json:"order_id"`
+	UserID    string          `json:"user_id"`
+	Items     []OrderItemData `json:"items"`
+	Total     entities.Money  `json:"total"`
+	Currency  string          `json:"currency"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// EventType implementa Event
+func (OrderCreatedEvent) EventType() string { return "order.created" }
+
+// AggregateID implementa AggregateEvent
+func (e OrderCreatedEvent) AggregateID() string { return e.OrderID }
+
+// OrderCancelledEvent representa el evento cuando se cancela un pedido
+type OrderCancelledEvent struct {
+	OrderID     string          `json:"order_id"`
+	UserID      string          `json:"user_id"`
+	Items       []OrderItemData `json:"items"`
+	Currency    string          `json:"currency"`
+	CancelledAt time.Time       `json:"cancelled_at"`
+}
+
+// EventType implementa Event
+func (OrderCancelledEvent) EventType() string { return "order.cancelled" }
+
+// AggregateID implementa AggregateEvent
+func (e OrderCancelledEvent) AggregateID() string { return e.OrderID }
+
+// MarshalJSON serializa el total y los precios de las líneas como números en la
+// moneda del pedido
+func (e OrderCreatedEvent) MarshalJSON() ([]byte, error) {
+	type event OrderCreatedEvent
+	return json.Marshal(struct {
+		event
+		Items []orderItemJSON `json:"items"`
+		Total json.RawMessage `json:"total"`
+	}{event(e), encodeOrderItems(e.Items), entities.EncodeMoneyJSON(e.Total)})
+}
+
+// UnmarshalJSON interpreta el total y los precios de las líneas en la moneda del pedido
+func (e *OrderCreatedEvent) UnmarshalJSON(data []byte) error {
+	type event OrderCreatedEvent
+	aux := struct {
+		*event
+		Items []orderItemJSON `json:"items"`
+		Total json.RawMessage `json:"total"`
+	}{event: (*event)(e)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	total, err := entities.DecodeMoneyJSON(aux.Total, e.Currency)
+	if err != nil {
+		return err
+	}
+	e.Total = total
+	e.Items, err = decodeOrderItems(aux.Items, e.Currency)
+	return err
+}
+
+// MarshalJSON serializa los precios de las líneas como números en la moneda del pedido
+func (e OrderCancelledEvent) MarshalJSON() ([]byte, error) {
+	type event OrderCancelledEvent
+	return json.Marshal(struct {
+		event
+		Items []orderItemJSON `json:"items"`
+	}{event(e), encodeOrderItems(e.Items)})
+}
+
+// UnmarshalJSON interpreta los precios de las líneas en la moneda del pedido
+func (e *OrderCancelledEvent) UnmarshalJSON(data []byte) error {
+	type event OrderCancelledEvent
+	aux := struct {
+		*event
+		Items []orderItemJSON `json:"items"`
+	}{event: (*event)(e)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	e.Items, err = decodeOrderItems(aux.Items, e.Currency)
+	return err
+}
+
+// orderItemJSON es una línea de pedido con el precio sin interpretar
+type orderItemJSON struct {
+	ProductID string          `json:"product_id"`
+	Quantity  int             `json:"quantity"`
+	UnitPrice json.RawMessage `json:"unit_price"`
+}
+
+// encodeOrderItems serializa los precios de las líneas sin su moneda
+func encodeOrderItems(items []OrderItemData) []orderItemJSON {
+	result := make([]orderItemJSON, 0, len(items))
+	for _, item := range items {
+		result = append(result, orderItemJSON{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: entities.EncodeMoneyJSON(item.UnitPrice),
+		})
+	}
+	return result
+}
+
+// decodeOrderItems interpreta los precios de las líneas en la moneda indicada
+func decodeOrderItems(items []orderItemJSON, currency string) ([]OrderItemData, error) {
+	result := make([]OrderItemData, 0, len(items))
+	for _, item := range items {
+		unitPrice, err := entities.DecodeMoneyJSON(item.UnitPrice, currency)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, OrderItemData{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: unitPrice,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"hexagonal-example/domain/repositories"
+)
+
+// DefaultOutboxBatchSize es el número de mensajes que el relay publica en cada pasada
+const DefaultOutboxBatchSize = 100
+
+// DefaultOutboxMaxAttempts es el número de intentos fallidos tras el que el relay
+// retira un mensaje del outbox y lo guarda como entrega fallida
+const DefaultOutboxMaxAttempts = 5
+
+// NewOutboxMessage envuelve un evento y lo serializa como mensaje del outbox
+// El envelope se crea al registrar el mensaje, de modo que su ID, su correlación y
+// su causalidad son los mismos cuando el relay lo publica más tarde
+func NewOutboxMessage[T Event](ctx context.Context, event T) (*repositories.OutboxMessage, error) {
+	envelope := NewEnvelope(ctx, event.EventType(), event)
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s: %w", envelope.Type, err)
+	}
+	return &repositories.OutboxMessage{
+		ID:          envelope.ID,
+		EventType:   envelope.Type,
+		AggregateID: envelope.AggregateID,
+		Payload:     payload,
+		CreatedAt:   envelope.OccurredAt,
+	}, nil
+}
+
+// OutboxRelay publica en el bus los mensajes pendientes de un outbox
+//
+// La entrega es al menos una vez: un mensaje se marca como enviado después de
+// publicarlo, así que si el proceso cae entre ambos pasos se vuelve a publicar.
+// El envelope conserva su ID, que los handlers pueden usar para de-duplicar
+//
+// Un mensaje que falla maxAttempts veces (por ejemplo porque no se puede decodificar)
+// se retira del outbox y se guarda en deadLetters, para que no bloquee a los demás
+type OutboxRelay struct {
+	outbox      repositories.OutboxRepository
+	eventBus    EventBus
+	batchSize   int
+	maxAttempts int
+	deadLetters DeadLetterStore
+}
+
+// NewOutboxRelay crea un relay que publica los mensajes de outbox en eventBus
+func NewOutboxRelay(outbox repositories.OutboxRepository, eventBus EventBus) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:      outbox,
+		eventBus:    eventBus,
+		batchSize:   DefaultOutboxBatchSize,
+		maxAttempts: DefaultOutboxMaxAttempts,
+		deadLetters: NewInMemoryDeadLetterStore(DefaultDeadLetterCapacity),
+	}
+}
+
+// WithBatchSize configura cuántos mensajes se leen del outbox en cada lote
+func (r *OutboxRelay) WithBatchSize(size int) *OutboxRelay {
+	if size > 0 {
+		r.batchSize = size
+	}
+	return r
+}
+
+// WithMaxAttempts configura tras cuántos intentos fallidos se retira un mensaje
+func (r *OutboxRelay) WithMaxAttempts(attempts int) *OutboxRelay {
+	if attempts > 0 {
+		r.maxAttempts = attempts
+	}
+	return r
+}
+
+// WithDeadLetterStore configura dónde se guardan los mensajes retirados
+func (r *OutboxRelay) WithDeadLetterStore(store DeadLetterStore) *OutboxRelay {
+	if store != nil {
+		r.deadLetters = store
+	}
+	return r
+}
+
+// DeadLetters retorna los mensajes retirados del outbox tras agotar sus intentos
+func (r *OutboxRelay) DeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	return r.deadLetters.List(ctx)
+}
+
+// RelayPending publica los mensajes pendientes en orden y retorna cuántos publicó
+// Se detiene en el primer mensaje que no se puede publicar, que queda pendiente
+// con el intento anotado, para no adelantar los eventos posteriores. Si el mensaje
+// agota sus intentos se retira como entrega fallida y el relay continúa
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	sent := 0
+	for {
+		messages, err := r.outbox.Pending(ctx, r.batchSize)
+		if err != nil {
+			return sent, fmt.Errorf("reading outbox: %w", err)
+		}
+		if len(messages) == 0 {
+			return sent, nil
+		}
+		for _, message := range messages {
+			if err := r.relay(ctx, message); err != nil {
+				if markErr := r.outbox.MarkFailed(ctx, message.ID, err.Error()); markErr != nil {
+					return sent, fmt.Errorf("relaying %s: %v (recording the failure: %w)", message.ID, err, markErr)
+				}
+				if message.Attempts+1 < r.maxAttempts {
+					return sent, fmt.Errorf("relaying %s: %w", message.ID, err)
+				}
+				if deadErr := r.deadLetter(ctx, message, err); deadErr != nil {
+					return sent, fmt.Errorf("relaying %s: %v (dead-lettering it: %w)", message.ID, err, deadErr)
+				}
+				log.Printf("Outbox message %s dead-lettered after %d attempts: %v", message.ID, message.Attempts+1, err)
+				continue
+			}
+			sent++
+		}
+	}
+}
+
+// relay publica un mensaje y lo marca como enviado
+func (r *OutboxRelay) relay(ctx context.Context, message *repositories.OutboxMessage) error {
+	var envelope Envelope
+	if err := json.Unmarshal(message.Payload, &envelope); err != nil {
+		return fmt.Errorf("decoding %s: %w", message.EventType, err)
+	}
+	if err := r.eventBus.PublishEnvelope(ctx, envelope); err != nil {
+		return err
+	}
+	return r.outbox.MarkSent(ctx, message.ID)
+}
+
+// deadLetter guarda un mensaje que agotó sus intentos como entrega fallida y lo
+// retira del outbox
+// Si el payload no se puede decodificar, el envelope guarda solo los datos del
+// mensaje y el payload sin interpretar
+func (r *OutboxRelay) deadLetter(ctx context.Context, message *repositories.OutboxMessage, cause error) error {
+	var envelope Envelope
+	if err := json.Unmarshal(message.Payload, &envelope); err != nil {
+		envelope = Envelope{
+			ID:          message.ID,
+			Type:        message.EventType,
+			AggregateID: message.AggregateID,
+			OccurredAt:  message.CreatedAt,
+			Payload:     message.Payload,
+		}
+	}
+	letter := DeadLetter{
+		ID:       message.ID,
+		Envelope: envelope,
+		Error:    cause.Error(),
+		Attempts: message.Attempts + 1,
+		FailedAt: time.Now(),
+	}
+	if err := r.deadLetters.Add(ctx, letter); err != nil {
+		return err
+	}
+	return r.outbox.MarkSent(ctx, message.ID)
+}
+
+// Run publica los mensajes pendientes cada interval hasta que ctx termina
+// Los errores se registran y la pasada siguiente reintenta los mensajes pendientes
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayPending(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to relay outbox messages: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Comodines de los patrones de suscripción
+// Los tipos de evento son cadenas separadas por puntos ("product.stock.updated");
+// un patrón se compara segmento a segmento con ellas:
+//
+//   - un segmento literal casa solo con el mismo segmento
+//   - "*" casa con exactamente un segmento cualquiera
+//   - "**", solo como último segmento, casa con uno o más segmentos
+//
+// Así "product.*" recibe "product.created" pero no "product.stock.updated",
+// "product.**" recibe ambos, "*.created" recibe "user.created" y "product.created",
+// y AllEvents recibe todos los eventos
+const (
+	SingleSegmentWildcard = "*"
+	MultiSegmentWildcard  = "**"
+
+	// AllEvents es el patrón que recibe todos los eventos publicados en el bus
+	AllEvents = MultiSegmentWildcard
+)
+
+// IsPattern indica si una suscripción contiene comodines
+func IsPattern(pattern string) bool {
+	return strings.Contains(pattern, SingleSegmentWildcard)
+}
+
+// ValidatePattern comprueba que un tipo de evento o patrón esté bien formado
+// Subscribe entra en pánico con los patrones inválidos, igual que ocurre con
+// las rutas de net/http, porque suelen ser errores de programación
+func ValidatePattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("empty event type")
+	}
+	segments := strings.Split(pattern, ".")
+	for i, segment := range segments {
+		switch {
+		case segment == "":
+			return fmt.Errorf("event pattern %q has an empty segment", pattern)
+		case segment == MultiSegmentWildcard && i != len(segments)-1:
+			return fmt.Errorf("event pattern %q: %q is only allowed as the last segment", pattern, MultiSegmentWildcard)
+		case segment != SingleSegmentWildcard && segment != MultiSegmentWildcard && strings.Contains(segment, SingleSegmentWildcard):
+			return fmt.Errorf("event pattern %q: wildcards must be a whole segment", pattern)
+		}
+	}
+	return nil
+}
+
+// MatchPattern indica si un tipo de evento casa con un patrón
+// Aplica las mismas reglas que el bus al resolver las suscripciones
+func MatchPattern(pattern, eventType string) bool {
+	patternSegments := strings.Split(pattern, ".")
+	typeSegments := strings.Split(eventType, ".")
+
+	for i, segment := range patternSegments {
+		if segment == MultiSegmentWildcard {
+			return len(typeSegments) > i
+		}
+		if i >= len(typeSegments) {
+			return false
+		}
+		if segment != SingleSegmentWildcard && segment != typeSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(typeSegments)
+}
@@ -1,36 +1,65 @@
 package events
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"hexagonal-example/domain/entities"
+)
 
 // ProductCreatedEvent representa el evento cuando se crea un producto
 type ProductCreatedEvent struct {
 	ProductID  string    `json:"product_id"`
 	Name       string    `json:"name"`
-	Category   string    `json:"category"`
-	Price      float64   `json:"price"`
-	Stock      int       `json:"stock"`
-	CreatedAt  time.Time `json:"created_at"`
+	Category   string         `json:"category"`
+	Price      entities.Money `json:"price"`
+	Currency   string         `json:"currency"`
+	Stock      int            `json:"stock"`
+	CreatedAt  time.Time      `json:"created_at"`
 }
 
+// EventType implementa Event
+func (ProductCreatedEvent) EventType() string { return "product.created" }
+
+// AggregateID implementa AggregateEvent
+func (e ProductCreatedEvent) AggregateID() string { return e.ProductID }
+
 // ProductUpdatedEvent representa el evento cuando se actualiza un producto
 type ProductUpdatedEvent struct {
 	ProductID  string    `json:"product_id"`
 	Name       string    `json:"name"`
-	Category   string    `json:"category"`
-	Price      float64   `json:"price"`
-	Stock      int       `json:"stock"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	Category   string         `json:"category"`
+	Price      entities.Money `json:"price"`
+	Currency   string         `json:"currency"`
+	Stock      int            `json:"stock"`
+	UpdatedAt  time.Time      `json:"updated_at"`
 }
 
+// EventType implementa Event
+func (ProductUpdatedEvent) EventType() string { return "product.updated" }
+
+// AggregateID implementa AggregateEvent
+func (e ProductUpdatedEvent) AggregateID() string { return e.ProductID }
+
 // StockUpdatedEvent representa el evento cuando se actualiza el stock de un producto
+// También se publica al reservar, confirmar o liberar stock: OldStock y NewStock
+// son el stock físico y ReservedStock/AvailableStock el reparto tras el cambio
 type StockUpdatedEvent struct {
-	ProductID  string    `json:"product_id"`
-	Name       string    `json:"name"`
-	OldStock   int       `json:"old_stock"`
-	NewStock   int       `json:"new_stock"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ProductID      string    `json:"product_id"`
+	Name           string    `json:"name"`
+	OldStock       int       `json:"old_stock"`
+	NewStock       int       `json:"new_stock"`
+	ReservedStock  int       `json:"reserved_stock"`
+	AvailableStock int       `json:"available_stock"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+// EventType implementa Event
+func (StockUpdatedEvent) EventType() string { return "product.stock.updated" }
+
+// AggregateID implementa AggregateEvent
+func (e StockUpdatedEvent) AggregateID() string { return e.ProductID }
+
 // ProductDeactivatedEvent representa el evento cuando se desactiva un producto
 type ProductDeactivatedEvent struct {
 	ProductID     string    `json:"product_id"`
@@ -38,9 +67,90 @@ type ProductDeactivatedEvent struct {
 	DeactivatedAt time.Time `json:"deactivated_at"`
 }
 
+// EventType implementa Event
+func (ProductDeactivatedEvent) EventType() string { return "product.deactivated" }
+
+// AggregateID implementa AggregateEvent
+func (e ProductDeactivatedEvent) AggregateID() string { return e.ProductID }
+
 // ProductActivatedEvent representa el evento cuando se activa un producto
 type ProductActivatedEvent struct {
 	ProductID   string    `json:"product_id"`
 	Name        string    `json:"name"`
 	ActivatedAt time.Time `json:"activated_at"`
-}
\ No newline at end of file
+}
+
+// EventType implementa Event
+func (ProductActivatedEvent) EventType() string { return "product.activated" }
+
+// AggregateID implementa AggregateEvent
+func (e ProductActivatedEvent) AggregateID() string { return e.ProductID }
+
+// ProductDeletedEvent representa el evento cuando se elimina un producto
+type ProductDeletedEvent struct {
+	ProductID string    `json:"product_id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// EventType implementa Event
+func (ProductDeletedEvent) EventType() string { return "product.deleted" }
+
+// AggregateID implementa AggregateEvent
+func (e ProductDeletedEvent) AggregateID() string { return e.ProductID }
+
+// MarshalJSON serializa el precio como número en la moneda indicada en currency
+func (e ProductCreatedEvent) MarshalJSON() ([]byte, error) {
+	type event ProductCreatedEvent
+	return json.Marshal(struct {
+		event
+		Price json.RawMessage `json:"price"`
+	}{event(e), entities.EncodeMoneyJSON(e.Price)})
+}
+
+// UnmarshalJSON interpreta el precio en la moneda indicada en currency
+func (e *ProductCreatedEvent) UnmarshalJSON(data []byte) error {
+	type event ProductCreatedEvent
+	aux := struct {
+		*event
+		Price json.RawMessage `json:"price"`
+	}{event: (*event)(e)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	price, err := entities.DecodeMoneyJSON(aux.Price, e.Currency)
+	if err != nil {
+		return err
+	}
+	e.Price = price
+	return nil
+}
+
+// MarshalJSON serializa el precio como número en la moneda indicada en currency
+func (e ProductUpdatedEvent) MarshalJSON() ([]byte, error) {
+	type event ProductUpdatedEvent
+	return json.Marshal(struct {
+		event
+		Price json.RawMessage `json:"price"`
+	}{event(e), entities.EncodeMoneyJSON(e.Price)})
+}
+
+// UnmarshalJSON interpreta el precio en la moneda indicada en currency
+func (e *ProductUpdatedEvent) UnmarshalJSON(data []byte) error {
+	type event ProductUpdatedEvent
+	aux := struct {
+		*event
+		Price json.RawMessage `json:"price"`
+	}{event: (*event)(e)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	price, err := entities.DecodeMoneyJSON(aux.Price, e.Currency)
+	if err != nil {
+		return err
+	}
+	e.Price = price
+	return nil
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// replayBatchSize es el número de eventos que se leen del almacén en cada lote
+const replayBatchSize = 256
+
+// Replay entrega a handler los eventos guardados que casan con pattern y cuya
+// posición es mayor que after (0 para reproducirlos todos), en orden
+// Retorna la posición del último evento leído, desde la que se puede continuar
+// Los fallos del handler se reintentan y acaban en el dead-letter como en Publish
+func (d *delivery) Replay(ctx context.Context, pattern string, after uint64, handler EventHandler) (uint64, error) {
+	if d.store == nil {
+		return after, ErrNoEventStore
+	}
+	if err := ValidatePattern(pattern); err != nil {
+		return after, err
+	}
+
+	for {
+		batch, err := d.store.Load(ctx, after, replayBatchSize)
+		if err != nil {
+			return after, err
+		}
+		if len(batch) == 0 {
+			return after, nil
+		}
+		for _, envelope := range batch {
+			if err := ctx.Err(); err != nil {
+				return after, err
+			}
+			if MatchPattern(pattern, envelope.Type) {
+				if err := d.deliverOrDeadLetter(ctx, 0, handler, envelope); err != nil {
+					return after, err
+				}
+			}
+			after = envelope.Position
+		}
+	}
+}
+
+// SubscribeFrom suscribe un handler que primero recibe los eventos guardados
+// posteriores a after y después los nuevos, sin huecos ni duplicados
+// Los eventos publicados mientras se reproduce el histórico se retienen y se
+// entregan al terminar, de modo que el handler los recibe en orden de posición
+func (d *delivery) SubscribeFrom(ctx context.Context, pattern string, after uint64, handler EventHandler) (*Subscription, error) {
+	if d.store == nil {
+		return nil, ErrNoEventStore
+	}
+	if err := ValidatePattern(pattern); err != nil {
+		return nil, err
+	}
+
+	catchUp := &catchUpHandler{delivery: d, handler: handler, replaying: true}
+	subscription := d.handlers.subscribe(pattern, catchUp)
+	catchUp.subscriptionID = subscription.id
+
+	replayed, err := d.Replay(ctx, pattern, after, handler)
+	if err != nil {
+		subscription.Unsubscribe()
+		return nil, err
+	}
+	if err := catchUp.finish(ctx, replayed); err != nil {
+		subscription.Unsubscribe()
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// catchUpHandler envuelve el handler de SubscribeFrom mientras se reproduce el histórico
+type catchUpHandler struct {
+	delivery *delivery
+	handler  EventHandler
+
+	// subscriptionID es la suscripción del handler, con la que se guardan en el
+	// dead-letter los eventos retenidos que fallan
+	subscriptionID uint64
+
+	mutex     sync.Mutex
+	replaying bool
+	buffered  []Envelope
+
+	// replayed es la última posición reproducida; los eventos en vivo con una
+	// posición menor o igual ya se entregaron durante la reproducción
+	replayed uint64
+}
+
+// Handle retiene los eventos mientras dura la reproducción y luego los deja pasar
+func (h *catchUpHandler) Handle(ctx context.Context, envelope Envelope) error {
+	h.mutex.Lock()
+	if h.replaying {
+		h.buffered = append(h.buffered, envelope)
+		h.mutex.Unlock()
+		return nil
+	}
+	replayed := h.replayed
+	h.mutex.Unlock()
+
+	if envelope.Position != 0 && envelope.Position <= replayed {
+		return nil
+	}
+	return h.handler.Handle(ctx, envelope)
+}
+
+// finish entrega los eventos retenidos que no se reprodujeron y termina la reproducción
+// replayed avanza con cada evento entregado, para que Handle descarte después los
+// eventos en vivo que ya se entregaron desde el búfer
+func (h *catchUpHandler) finish(ctx context.Context, replayed uint64) error {
+	for {
+		h.mutex.Lock()
+		h.replayed = replayed
+		buffered := h.buffered
+		h.buffered = nil
+		if len(buffered) == 0 {
+			h.replaying = false
+			h.mutex.Unlock()
+			return nil
+		}
+		h.mutex.Unlock()
+
+		for _, envelope := range buffered {
+			if envelope.Position != 0 && envelope.Position <= replayed {
+				continue
+			}
+			if err := h.delivery.deliverOrDeadLetter(ctx, h.subscriptionID, h.handler, envelope); err != nil {
+				return err
+			}
+			if envelope.Position > replayed {
+				replayed = envelope.Position
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Subscription representa la suscripción de un handler a un tipo de evento o patrón
+// Es la única forma de desuscribir un handler: así no hace falta comparar
+// handlers, algo que no es posible con closures como EventHandlerFunc
+type Subscription struct {
+	registry *handlerRegistry
+	pattern  string
+	id       uint64
+	done     chan struct{}
+	once     sync.Once
+}
+
+// newSubscription crea el handle de una suscripción ya registrada
+func newSubscription(registry *handlerRegistry, pattern string, id uint64) *Subscription {
+	return &Subscription{
+		registry: registry,
+		pattern:  pattern,
+		id:       id,
+		done:     make(chan struct{}),
+	}
+}
+
+// EventType retorna el tipo de evento o patrón de la suscripción
+func (s *Subscription) EventType() string {
+	return s.pattern
+}
+
+// Unsubscribe elimina la suscripción; el handler no recibe más eventos,
+// tampoco los que estuvieran ya encolados. Es seguro llamarlo varias veces
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.registry.remove(s.pattern, s.id)
+		close(s.done)
+	})
+}
+
+// Close implementa io.Closer llamando a Unsubscribe
+func (s *Subscription) Close() error {
+	s.Unsubscribe()
+	return nil
+}
+
+// Done retorna un canal que se cierra cuando la suscripción se elimina
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// subscribeContext suscribe un handler mientras ctx siga activo
+// La suscripción se elimina sola cuando ctx termina, o antes si se llama a Unsubscribe
+func subscribeContext(ctx context.Context, registry *handlerRegistry, pattern string, handler EventHandler) *Subscription {
+	subscription := registry.subscribe(pattern, handler)
+	go func() {
+		select {
+		case <-ctx.Done():
+			subscription.Unsubscribe()
+		case <-subscription.Done():
+		}
+	}()
+	return subscription
+}
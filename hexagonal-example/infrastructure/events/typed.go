@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+	"fmt"
+)
+
+// Event es un evento que conoce su propio tipo
+// El tipo se obtiene del tipo Go del evento, de modo que Publish y Subscribe
+// genéricos no dependen de cadenas escritas a mano
+type Event interface {
+	EventType() string
+}
+
+// TypeOf retorna el tipo de evento asociado al tipo Go T
+func TypeOf[T Event]() string {
+	var zero T
+	return zero.EventType()
+}
+
+// Publish publica un evento usando el tipo derivado de su tipo Go
+func Publish[T Event](ctx context.Context, bus EventBus, event T) error {
+	return bus.Publish(ctx, event.EventType(), event)
+}
+
+// Subscribe suscribe un handler tipado al tipo de evento derivado de T
+// El handler recibe solo el payload; el Envelope está disponible con EnvelopeFromContext
+// Si se publica otro valor con el mismo tipo de evento, el handler no se llama y
+// la entrega falla con un error (y acaba en el dead-letter) en lugar de ignorarse
+func Subscribe[T Event](bus EventBus, handler func(ctx context.Context, event T) error) *Subscription {
+	return bus.Subscribe(TypeOf[T](), typedHandler(handler))
+}
+
+// SubscribeContext es la versión tipada de EventBus.SubscribeContext
+func SubscribeContext[T Event](ctx context.Context, bus EventBus, handler func(ctx context.Context, event T) error) *Subscription {
+	return bus.SubscribeContext(ctx, TypeOf[T](), typedHandler(handler))
+}
+
+// typedHandler adapta un handler tipado a EventHandler
+// Acepta tanto el valor como un puntero a él
+func typedHandler[T Event](handler func(ctx context.Context, event T) error) EventHandler {
+	return EventHandlerFunc(func(ctx context.Context, envelope Envelope) error {
+		ctx = context.WithValue(ctx, envelopeKey{}, envelope)
+		switch e := envelope.Payload.(type) {
+		case T:
+			return handler(ctx, e)
+		case *T:
+			if e != nil {
+				return handler(ctx, *e)
+			}
+		}
+		return fmt.Errorf("event %s: expected %T, got %T", TypeOf[T](), *new(T), envelope.Payload)
+	})
+}
+
+// envelopeKey es la clave de contexto del Envelope de un handler tipado
+type envelopeKey struct{}
+
+// EnvelopeFromContext retorna el Envelope del evento que está manejando un handler
+// tipado (ver Subscribe); ok es false fuera de un handler tipado
+func EnvelopeFromContext(ctx context.Context) (Envelope, bool) {
+	envelope, ok := ctx.Value(envelopeKey{}).(Envelope)
+	return envelope, ok
+}
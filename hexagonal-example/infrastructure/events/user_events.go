@@ -10,6 +10,12 @@ type UserCreatedEvent struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// EventType implementa Event
+func (UserCreatedEvent) EventType() string { return "user.created" }
+
+// AggregateID implementa AggregateEvent
+func (e UserCreatedEvent) AggregateID() string { return e.UserID }
+
 // UserUpdatedEvent representa el evento cuando se actualiza un usuario
 type UserUpdatedEvent struct {
 	UserID    string    `json:"user_id"`
@@ -18,6 +24,12 @@ type UserUpdatedEvent struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// EventType implementa Event
+func (UserUpdatedEvent) EventType() string { return "user.updated" }
+
+// AggregateID implementa AggregateEvent
+func (e UserUpdatedEvent) AggregateID() string { return e.UserID }
+
 // UserDeactivatedEvent representa el evento cuando se desactiva un usuario
 type UserDeactivatedEvent struct {
 	UserID         string    `json:"user_id"`
@@ -25,9 +37,34 @@ type UserDeactivatedEvent struct {
 	DeactivatedAt  time.Time `json:"deactivated_at"`
 }
 
+// EventType implementa Event
+func (UserDeactivatedEvent) EventType() string { return "user.deactivated" }
+
+// AggregateID implementa AggregateEvent
+func (e UserDeactivatedEvent) AggregateID() string { return e.UserID }
+
 // UserActivatedEvent representa el evento cuando se activa un usuario
 type UserActivatedEvent struct {
 	UserID       string    `json:"user_id"`
 	Email        string    `json:"email"`
 	ActivatedAt  time.Time `json:"activated_at"`
-}
\ No newline at end of file
+}
+
+// EventType implementa Event
+func (UserActivatedEvent) EventType() string { return "user.activated" }
+
+// AggregateID implementa AggregateEvent
+func (e UserActivatedEvent) AggregateID() string { return e.UserID }
+
+// UserDeletedEvent representa el evento cuando se elimina un usuario
+type UserDeletedEvent struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// EventType implementa Event
+func (UserDeletedEvent) EventType() string { return "user.deleted" }
+
+// AggregateID implementa AggregateEvent
+func (e UserDeletedEvent) AggregateID() string { return e.UserID }
@@ -0,0 +1,95 @@
+package http
+
+import (
+	nethttp "net/http"
+
+	"hexagonal-example/application/services"
+	"hexagonal-example/infrastructure/events"
+)
+
+// Handler expone los servicios de aplicación como una API REST/JSON
+// Es un adaptador primario (puerto de entrada) de la arquitectura hexagonal:
+// traduce peticiones HTTP a llamadas a los servicios y sus resultados a respuestas JSON
+type Handler struct {
+	userService              *services.UserService
+	productService           *services.ProductService
+	orderService             *services.OrderService
+	userManagementService    *services.UserManagementService
+	productManagementService *services.ProductManagementService
+
+	mux *nethttp.ServeMux
+}
+
+// NewHandler crea un nuevo handler HTTP con todas las rutas registradas
+func NewHandler(
+	userService *services.UserService,
+	productService *services.ProductService,
+	orderService *services.OrderService,
+	userManagementService *services.UserManagementService,
+	productManagementService *services.ProductManagementService,
+) *Handler {
+	h := &Handler{
+		userService:              userService,
+		productService:           productService,
+		orderService:             orderService,
+		userManagementService:    userManagementService,
+		productManagementService: productManagementService,
+		mux:                      nethttp.NewServeMux(),
+	}
+	h.routes()
+	return h
+}
+
+// CorrelationIDHeader es la cabecera con la que el cliente indica (y recibe) el ID de
+// correlación de la petición; los eventos publicados al atenderla lo llevan en su Envelope
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// ServeHTTP implementa http.Handler delegando en el enrutador interno
+// Si la petición no trae ID de correlación se genera uno
+func (h *Handler) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	correlationID := r.Header.Get(CorrelationIDHeader)
+	if correlationID == "" {
+		correlationID = events.NewCorrelationID()
+	}
+	w.Header().Set(CorrelationIDHeader, correlationID)
+
+	h.mux.ServeHTTP(w, r.WithContext(events.WithCorrelationID(r.Context(), correlationID)))
+}
+
+// routes registra todas las rutas de la API
+func (h *Handler) routes() {
+	// Usuarios
+	h.mux.HandleFunc("POST /users", h.createUser)
+	h.mux.HandleFunc("GET /users", h.listUsers)
+	h.mux.HandleFunc("GET /users/search", h.searchUsers)
+	h.mux.HandleFunc("GET /users/stats", h.userStatistics)
+	h.mux.HandleFunc("GET /users/{id}", h.getUser)
+	h.mux.HandleFunc("PATCH /users/{id}", h.updateUser)
+	h.mux.HandleFunc("DELETE /users/{id}", h.deleteUser)
+	h.mux.HandleFunc("POST /users/{id}/activate", h.activateUser)
+	h.mux.HandleFunc("POST /users/{id}/deactivate", h.deactivateUser)
+
+	// Productos
+	h.mux.HandleFunc("POST /products", h.createProduct)
+	h.mux.HandleFunc("GET /products", h.listProducts)
+	h.mux.HandleFunc("GET /products/search", h.searchProducts)
+	h.mux.HandleFunc("GET /products/search/text", h.searchProductsText)
+	h.mux.HandleFunc("GET /products/stats", h.productStatistics)
+	h.mux.HandleFunc("GET /products/{id}", h.getProduct)
+	h.mux.HandleFunc("PATCH /products/{id}", h.updateProduct)
+	h.mux.HandleFunc("DELETE /products/{id}", h.deleteProduct)
+	h.mux.HandleFunc("POST /products/{id}/activate", h.activateProduct)
+	h.mux.HandleFunc("POST /products/{id}/deactivate", h.deactivateProduct)
+	h.mux.HandleFunc("PUT /products/{id}/stock", h.updateStock)
+	h.mux.HandleFunc("POST /products/{id}/stock/add", h.addStock)
+	h.mux.HandleFunc("POST /products/{id}/stock/remove", h.removeStock)
+	h.mux.HandleFunc("POST /products/{id}/reservations", h.reserveStock)
+	h.mux.HandleFunc("POST /products/{id}/reservations/{reservationID}/confirm", h.confirmReservation)
+	h.mux.HandleFunc("DELETE /products/{id}/reservations/{reservationID}", h.releaseReservation)
+
+	// Pedidos
+	h.mux.HandleFunc("POST /orders", h.createOrder)
+	h.mux.HandleFunc("GET /orders", h.listOrders)
+	h.mux.HandleFunc("GET /orders/{id}", h.getOrder)
+	h.mux.HandleFunc("POST /orders/{id}/cancel", h.cancelOrder)
+}
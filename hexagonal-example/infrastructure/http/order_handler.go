@@ -0,0 +1,80 @@
+package http
+
+import (
+	nethttp "net/http"
+
+	"hexagonal-example/application/services"
+	"hexagonal-example/domain/entities"
+)
+
+// createOrderRequest es el cuerpo de POST /orders
+type createOrderRequest struct {
+	ID     string               `json:"id"`
+	UserID string               `json:"user_id"`
+	Items  []services.OrderLine `json:"items"`
+}
+
+// createOrder maneja POST /orders
+func (h *Handler) createOrder(w nethttp.ResponseWriter, r *nethttp.Request) {
+	var req createOrderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	order, err := h.orderService.CreateOrder(r.Context(), req.ID, req.UserID, req.Items)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusCreated, order)
+}
+
+// listOrders maneja GET /orders
+// Acepta ?user_id= para listar solo los pedidos de un usuario
+func (h *Handler) listOrders(w nethttp.ResponseWriter, r *nethttp.Request) {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var orders []*entities.Order
+	if userID := r.URL.Query().Get("user_id"); userID != "" {
+		orders, err = h.orderService.ListUserOrders(r.Context(), userID, limit, offset)
+	} else {
+		orders, err = h.orderService.ListOrders(r.Context(), limit, offset)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if orders == nil {
+		orders = []*entities.Order{}
+	}
+	writeJSON(w, nethttp.StatusOK, orders)
+}
+
+// getOrder maneja GET /orders/{id}
+func (h *Handler) getOrder(w nethttp.ResponseWriter, r *nethttp.Request) {
+	order, err := h.orderService.GetOrder(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, order)
+}
+
+// cancelOrder maneja POST /orders/{id}/cancel
+func (h *Handler) cancelOrder(w nethttp.ResponseWriter, r *nethttp.Request) {
+	order, err := h.orderService.CancelOrder(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, order)
+}
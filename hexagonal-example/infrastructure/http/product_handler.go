@@ -0,0 +1,376 @@
+package http
+
+import (
+	"encoding/json"
+	nethttp "net/http"
+	"time"
+
+	"hexagonal-example/application/services"
+	"hexagonal-example/domain/entities"
+)
+
+// createProductRequest es el cuerpo de POST /products
+// price es un número decimal en la moneda indicada (DefaultCurrency si se omite)
+type createProductRequest struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Category    string      `json:"category"`
+	Price       json.Number `json:"price"`
+	Currency    string      `json:"currency"`
+	Stock       int         `json:"stock"`
+}
+
+// updateProductRequest es el cuerpo de PATCH /products/{id}
+// Los campos omitidos no se modifican; currency solo se admite junto a price
+type updateProductRequest struct {
+	Name        *string      `json:"name"`
+	Description *string      `json:"description"`
+	Category    *string      `json:"category"`
+	Price       *json.Number `json:"price"`
+	Currency    *string      `json:"currency"`
+	Stock       *int         `json:"stock"`
+}
+
+// updateStockRequest es el cuerpo de PUT /products/{id}/stock
+type updateStockRequest struct {
+	Stock int `json:"stock"`
+}
+
+// stockQuantityRequest es el cuerpo de POST /products/{id}/stock/add y /stock/remove
+type stockQuantityRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+// reserveStockRequest es el cuerpo de POST /products/{id}/reservations
+// Si no se indica ttl_seconds se usa services.DefaultReservationTTL
+type reserveStockRequest struct {
+	Quantity   int  `json:"quantity"`
+	TTLSeconds *int `json:"ttl_seconds"`
+}
+
+// createProduct maneja POST /products
+func (h *Handler) createProduct(w nethttp.ResponseWriter, r *nethttp.Request) {
+	var req createProductRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	price, err := parseMoney("price", req.Price.String(), req.Currency)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	product, err := h.productService.CreateProduct(r.Context(), req.ID, req.Name, req.Description, req.Category, price, req.Stock)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusCreated, product)
+}
+
+// listProducts maneja GET /products
+// Acepta ?available=true o ?category=X para filtrar el listado
+func (h *Handler) listProducts(w nethttp.ResponseWriter, r *nethttp.Request) {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	availableOnly, err := boolParam(r, "available")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	category := r.URL.Query().Get("category")
+
+	var products []*entities.Product
+	switch {
+	case category != "":
+		products, err = h.productService.ListProductsByCategory(r.Context(), category, limit, offset)
+	case availableOnly:
+		products, err = h.productService.ListAvailableProducts(r.Context(), limit, offset)
+	default:
+		products, err = h.productService.ListProducts(r.Context(), limit, offset)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, nonNilProducts(products))
+}
+
+// searchProducts maneja GET /products/search
+func (h *Handler) searchProducts(w nethttp.ResponseWriter, r *nethttp.Request) {
+	page, err := pageRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	currency := r.URL.Query().Get("currency")
+	minPrice, err := moneyParam(r, "min_price", currency)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	maxPrice, err := moneyParam(r, "max_price", currency)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	active, err := optionalBoolParam(r, "active")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	available, err := boolParam(r, "available")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	minStock, err := intParam(r, "min_stock", 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	criteria := services.ProductSearchCriteria{
+		Category:  r.URL.Query().Get("category"),
+		MinPrice:  minPrice,
+		MaxPrice:  maxPrice,
+		Name:      r.URL.Query().Get("name"),
+		Active:    active,
+		Available: available,
+		MinStock:  minStock,
+		Sort:      page.Sort,
+		Limit:     page.Limit,
+		Cursor:    page.Cursor,
+	}
+
+	products, err := h.productManagementService.SearchProducts(r.Context(), criteria)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, products)
+}
+
+// searchProductsText maneja GET /products/search/text?q=
+// Retorna los productos ordenados por relevancia, cada uno con su puntuación
+func (h *Handler) searchProductsText(w nethttp.ResponseWriter, r *nethttp.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, &badRequestError{message: "q is required"})
+		return
+	}
+	limit, err := limitParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	results, err := h.productService.SearchText(r.Context(), query, limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, results)
+}
+
+// productStatistics maneja GET /products/stats
+func (h *Handler) productStatistics(w nethttp.ResponseWriter, r *nethttp.Request) {
+	stats, err := h.productManagementService.GetProductStatistics(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, stats)
+}
+
+// getProduct maneja GET /products/{id}
+func (h *Handler) getProduct(w nethttp.ResponseWriter, r *nethttp.Request) {
+	product, err := h.productService.GetProduct(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, product)
+}
+
+// updateProduct maneja PATCH /products/{id}
+func (h *Handler) updateProduct(w nethttp.ResponseWriter, r *nethttp.Request) {
+	var req updateProductRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var price *entities.Money
+	switch {
+	case req.Price != nil:
+		currency := ""
+		if req.Currency != nil {
+			currency = *req.Currency
+		}
+		parsed, err := parseMoney("price", req.Price.String(), currency)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		price = &parsed
+	case req.Currency != nil:
+		writeError(w, &badRequestError{message: "currency can only be changed together with price"})
+		return
+	}
+
+	product, err := h.productService.UpdateProduct(r.Context(), r.PathValue("id"), req.Name, req.Description, req.Category, price, req.Stock)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, product)
+}
+
+// deleteProduct maneja DELETE /products/{id}
+func (h *Handler) deleteProduct(w nethttp.ResponseWriter, r *nethttp.Request) {
+	if err := h.productService.DeleteProduct(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(nethttp.StatusNoContent)
+}
+
+// activateProduct maneja POST /products/{id}/activate
+func (h *Handler) activateProduct(w nethttp.ResponseWriter, r *nethttp.Request) {
+	product, err := h.productService.ActivateProduct(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, product)
+}
+
+// deactivateProduct maneja POST /products/{id}/deactivate
+func (h *Handler) deactivateProduct(w nethttp.ResponseWriter, r *nethttp.Request) {
+	product, err := h.productService.DeactivateProduct(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, product)
+}
+
+// updateStock maneja PUT /products/{id}/stock
+func (h *Handler) updateStock(w nethttp.ResponseWriter, r *nethttp.Request) {
+	var req updateStockRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	product, err := h.productService.UpdateStock(r.Context(), r.PathValue("id"), req.Stock)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, product)
+}
+
+// addStock maneja POST /products/{id}/stock/add
+func (h *Handler) addStock(w nethttp.ResponseWriter, r *nethttp.Request) {
+	var req stockQuantityRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	product, err := h.productService.AddStock(r.Context(), r.PathValue("id"), req.Quantity)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, product)
+}
+
+// removeStock maneja POST /products/{id}/stock/remove
+func (h *Handler) removeStock(w nethttp.ResponseWriter, r *nethttp.Request) {
+	var req stockQuantityRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	product, err := h.productService.RemoveStock(r.Context(), r.PathValue("id"), req.Quantity)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, product)
+}
+
+// reserveStock maneja POST /products/{id}/reservations
+func (h *Handler) reserveStock(w nethttp.ResponseWriter, r *nethttp.Request) {
+	var req reserveStockRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ttl := services.DefaultReservationTTL
+	if req.TTLSeconds != nil {
+		ttl = time.Duration(*req.TTLSeconds) * time.Second
+	}
+
+	reservation, err := h.productService.Reserve(r.Context(), r.PathValue("id"), req.Quantity, ttl)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusCreated, reservation)
+}
+
+// confirmReservation maneja POST /products/{id}/reservations/{reservationID}/confirm
+func (h *Handler) confirmReservation(w nethttp.ResponseWriter, r *nethttp.Request) {
+	product, err := h.productService.Confirm(r.Context(), r.PathValue("id"), r.PathValue("reservationID"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, product)
+}
+
+// releaseReservation maneja DELETE /products/{id}/reservations/{reservationID}
+func (h *Handler) releaseReservation(w nethttp.ResponseWriter, r *nethttp.Request) {
+	product, err := h.productService.Release(r.Context(), r.PathValue("id"), r.PathValue("reservationID"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, product)
+}
+
+// nonNilProducts garantiza que las listas vacías se serialicen como [] y no como null
+func nonNilProducts(products []*entities.Product) []*entities.Product {
+	if products == nil {
+		return []*entities.Product{}
+	}
+	return products
+}
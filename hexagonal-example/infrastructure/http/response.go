@@ -0,0 +1,210 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	nethttp "net/http"
+	"strconv"
+
+	"hexagonal-example/application/services"
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+const (
+	// defaultLimit es el tamaño de página usado cuando no se indica limit
+	defaultLimit = 20
+	// maxLimit es el tamaño de página máximo permitido
+	maxLimit = 100
+)
+
+// errorResponse es el cuerpo JSON devuelto en caso de error
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// badRequestError representa un error en el formato de la petición
+type badRequestError struct {
+	message string
+}
+
+func (e *badRequestError) Error() string {
+	return e.message
+}
+
+// writeJSON serializa el valor como JSON con el código de estado indicado
+func writeJSON(w nethttp.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// internalErrorMessage es el mensaje de las respuestas 500
+// El error real puede revelar detalles internos (rutas, SQL), así que solo se registra
+const internalErrorMessage = "internal server error"
+
+// writeError traduce un error de la aplicación al código HTTP correspondiente
+func writeError(w nethttp.ResponseWriter, err error) {
+	status := statusFor(err)
+	message := err.Error()
+	if status == nethttp.StatusInternalServerError {
+		log.Printf("internal error handling request: %v", err)
+		message = internalErrorMessage
+	}
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+// statusFor determina el código HTTP para un error de la aplicación
+func statusFor(err error) int {
+	var validationErr *services.ValidationError
+	var badRequestErr *badRequestError
+
+	switch {
+	case errors.As(err, &validationErr), errors.As(err, &badRequestErr),
+		errors.Is(err, entities.ErrCurrencyMismatch),
+		errors.Is(err, repositories.ErrInvalidSpecification),
+		errors.Is(err, repositories.ErrInvalidPageRequest), errors.Is(err, repositories.ErrInvalidCursor):
+		return nethttp.StatusBadRequest
+	case errors.Is(err, repositories.ErrUserNotFound),
+		errors.Is(err, repositories.ErrProductNotFound),
+		errors.Is(err, repositories.ErrReservationNotFound),
+		errors.Is(err, repositories.ErrOrderNotFound):
+		return nethttp.StatusNotFound
+	case errors.Is(err, repositories.ErrUserAlreadyExists),
+		errors.Is(err, repositories.ErrProductAlreadyExists),
+		errors.Is(err, repositories.ErrEmailAlreadyInUse),
+		errors.Is(err, repositories.ErrInsufficientStock),
+		errors.Is(err, repositories.ErrStockBelowReserved),
+		errors.Is(err, repositories.ErrProductNotAvailable),
+		errors.Is(err, repositories.ErrUserVersionConflict),
+		errors.Is(err, repositories.ErrProductVersionConflict),
+		errors.Is(err, repositories.ErrUserNotActive),
+		errors.Is(err, repositories.ErrOrderAlreadyExists),
+		errors.Is(err, repositories.ErrOrderAlreadyCancelled),
+		errors.Is(err, repositories.ErrOrderVersionConflict):
+		return nethttp.StatusConflict
+	case errors.Is(err, repositories.ErrSearchUnavailable):
+		return nethttp.StatusServiceUnavailable
+	default:
+		return nethttp.StatusInternalServerError
+	}
+}
+
+// decodeJSON decodifica el cuerpo de la petición rechazando campos desconocidos
+func decodeJSON(r *nethttp.Request, dst interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return &badRequestError{message: fmt.Sprintf("invalid request body: %v", err)}
+	}
+	return nil
+}
+
+// pagination obtiene limit y offset de los parámetros de la query
+func pagination(r *nethttp.Request) (limit, offset int, err error) {
+	limit, err = intParam(r, "limit", defaultLimit)
+	if err != nil {
+		return 0, 0, err
+	}
+	offset, err = intParam(r, "offset", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if limit <= 0 || limit > maxLimit {
+		return 0, 0, &badRequestError{message: fmt.Sprintf("limit must be between 1 and %d", maxLimit)}
+	}
+	if offset < 0 {
+		return 0, 0, &badRequestError{message: "offset cannot be negative"}
+	}
+	return limit, offset, nil
+}
+
+// pageRequest obtiene limit, sort y cursor de los parámetros de la query
+// sort tiene el formato "campo" o "campo:asc|desc"; cursor es el next_cursor de la
+// página anterior
+func pageRequest(r *nethttp.Request) (repositories.PageRequest, error) {
+	limit, err := limitParam(r)
+	if err != nil {
+		return repositories.PageRequest{}, err
+	}
+	sort, err := repositories.ParseSortOrder(r.URL.Query().Get("sort"))
+	if err != nil {
+		return repositories.PageRequest{}, err
+	}
+	return repositories.PageRequest{Sort: sort, Limit: limit, Cursor: r.URL.Query().Get("cursor")}, nil
+}
+
+// limitParam obtiene el tamaño de página de la query, entre 1 y maxLimit
+func limitParam(r *nethttp.Request) (int, error) {
+	limit, err := intParam(r, "limit", defaultLimit)
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 || limit > maxLimit {
+		return 0, &badRequestError{message: fmt.Sprintf("limit must be between 1 and %d", maxLimit)}
+	}
+	return limit, nil
+}
+
+// intParam obtiene un parámetro entero de la query con un valor por defecto
+func intParam(r *nethttp.Request, name string, defaultValue int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, &badRequestError{message: fmt.Sprintf("%s must be an integer", name)}
+	}
+	return value, nil
+}
+
+// moneyParam obtiene un importe de la query en la moneda indicada (cero si se omite)
+func moneyParam(r *nethttp.Request, name, currency string) (entities.Money, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return entities.NewMoney(0, currency)
+	}
+	return parseMoney(name, raw, currency)
+}
+
+// parseMoney interpreta un importe decimal exacto en la moneda indicada
+// Un importe vacío equivale a cero, como el antiguo precio float64 omitido
+func parseMoney(name, value, currency string) (entities.Money, error) {
+	if value == "" {
+		value = "0"
+	}
+	money, err := entities.ParseMoney(value, currency)
+	if err != nil {
+		return entities.Money{}, &badRequestError{message: fmt.Sprintf("%s: %v", name, err)}
+	}
+	return money, nil
+}
+
+// boolParam obtiene un parámetro booleano de la query (false por defecto)
+func boolParam(r *nethttp.Request, name string) (bool, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, &badRequestError{message: fmt.Sprintf("%s must be a boolean", name)}
+	}
+	return value, nil
+}
+
+// optionalBoolParam obtiene un parámetro booleano de la query, o nil si se omite
+func optionalBoolParam(r *nethttp.Request, name string) (*bool, error) {
+	if r.URL.Query().Get(name) == "" {
+		return nil, nil
+	}
+	value, err := boolParam(r, name)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
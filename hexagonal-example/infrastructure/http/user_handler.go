@@ -0,0 +1,178 @@
+package http
+
+import (
+	nethttp "net/http"
+
+	"hexagonal-example/application/services"
+	"hexagonal-example/domain/entities"
+)
+
+// createUserRequest es el cuerpo de POST /users
+type createUserRequest struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// updateUserRequest es el cuerpo de PATCH /users/{id}
+// Los campos omitidos no se modifican
+type updateUserRequest struct {
+	Email *string `json:"email"`
+	Name  *string `json:"name"`
+}
+
+// createUser maneja POST /users
+func (h *Handler) createUser(w nethttp.ResponseWriter, r *nethttp.Request) {
+	var req createUserRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	user, err := h.userService.CreateUser(r.Context(), req.ID, req.Email, req.Name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusCreated, user)
+}
+
+// listUsers maneja GET /users
+// Acepta ?active=true para listar solo usuarios activos
+func (h *Handler) listUsers(w nethttp.ResponseWriter, r *nethttp.Request) {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	activeOnly, err := boolParam(r, "active")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var users []*entities.User
+	if activeOnly {
+		users, err = h.userService.ListActiveUsers(r.Context(), limit, offset)
+	} else {
+		users, err = h.userService.ListUsers(r.Context(), limit, offset)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, nonNilUsers(users))
+}
+
+// searchUsers maneja GET /users/search
+func (h *Handler) searchUsers(w nethttp.ResponseWriter, r *nethttp.Request) {
+	page, err := pageRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	active, err := optionalBoolParam(r, "active")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	criteria := services.SearchCriteria{
+		Email:  r.URL.Query().Get("email"),
+		Name:   r.URL.Query().Get("name"),
+		Active: active,
+		Sort:   page.Sort,
+		Limit:  page.Limit,
+		Cursor: page.Cursor,
+	}
+
+	users, err := h.userManagementService.SearchUsers(r.Context(), criteria)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, users)
+}
+
+// userStatistics maneja GET /users/stats
+func (h *Handler) userStatistics(w nethttp.ResponseWriter, r *nethttp.Request) {
+	stats, err := h.userManagementService.GetUserStatistics(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, stats)
+}
+
+// getUser maneja GET /users/{id}
+func (h *Handler) getUser(w nethttp.ResponseWriter, r *nethttp.Request) {
+	user, err := h.userService.GetUser(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, user)
+}
+
+// updateUser maneja PATCH /users/{id}
+func (h *Handler) updateUser(w nethttp.ResponseWriter, r *nethttp.Request) {
+	var req updateUserRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	user, err := h.userService.UpdateUser(r.Context(), r.PathValue("id"), req.Email, req.Name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, user)
+}
+
+// deleteUser maneja DELETE /users/{id}
+func (h *Handler) deleteUser(w nethttp.ResponseWriter, r *nethttp.Request) {
+	if err := h.userService.DeleteUser(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(nethttp.StatusNoContent)
+}
+
+// activateUser maneja POST /users/{id}/activate
+func (h *Handler) activateUser(w nethttp.ResponseWriter, r *nethttp.Request) {
+	user, err := h.userService.ActivateUser(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, user)
+}
+
+// deactivateUser maneja POST /users/{id}/deactivate
+func (h *Handler) deactivateUser(w nethttp.ResponseWriter, r *nethttp.Request) {
+	user, err := h.userService.DeactivateUser(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, user)
+}
+
+// nonNilUsers garantiza que las listas vacías se serialicen como [] y no como null
+func nonNilUsers(users []*entities.User) []*entities.User {
+	if users == nil {
+		return []*entities.User{}
+	}
+	return users
+}
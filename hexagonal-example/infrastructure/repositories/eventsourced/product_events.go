@@ -0,0 +1,163 @@
+package eventsourced
+
+import (
+	"time"
+
+	"hexagonal-example/domain/entities"
+)
+
+// ProductEventType identifica el tipo de un evento del historial de un producto
+type ProductEventType string
+
+// Tipos de eventos del historial de un producto
+const (
+	ProductCreated             ProductEventType = "product.created"
+	ProductDetailsChanged      ProductEventType = "product.details_changed"
+	ProductPriceChanged        ProductEventType = "product.price_changed"
+	ProductStockAdded          ProductEventType = "product.stock_added"
+	ProductStockRemoved        ProductEventType = "product.stock_removed"
+	ProductReservationsChanged ProductEventType = "product.reservations_changed"
+	ProductActivated           ProductEventType = "product.activated"
+	ProductDeactivated         ProductEventType = "product.deactivated"
+	ProductDeleted             ProductEventType = "product.deleted"
+
+	// ProductTouched registra un guardado sin cambios de estado: solo avanza la versión
+	ProductTouched ProductEventType = "product.touched"
+)
+
+// ProductEvent es un cambio del estado de un producto
+// El estado de un producto en cualquier momento es el resultado de aplicar en orden
+// todos sus eventos hasta ese momento
+type ProductEvent struct {
+	Type      ProductEventType
+	ProductID string
+
+	// Sequence es la posición del evento en el historial del producto, desde 1
+	Sequence int
+
+	// Version es la versión del producto tras la escritura que produjo el evento
+	// Un mismo guardado puede producir varios eventos con la misma versión
+	Version int
+
+	// OccurredAt es el momento del cambio (UpdatedAt del producto guardado, o el
+	// momento de la llamada en ProductDeleted). No crece necesariamente con Sequence
+	OccurredAt time.Time
+
+	// Product es el estado inicial del producto en ProductCreated
+	Product *entities.Product
+
+	// Name, Description y Category son los nuevos datos en ProductDetailsChanged
+	Name        string
+	Description string
+	Category    string
+
+	// Price es el nuevo precio en ProductPriceChanged
+	Price entities.Money
+
+	// Quantity es el stock añadido o retirado en ProductStockAdded y ProductStockRemoved
+	Quantity int
+
+	// Reservations son las reservas vigentes tras ProductReservationsChanged
+	Reservations []entities.StockReservation
+}
+
+// applyEvent aplica un evento al estado de un producto y retorna el nuevo estado
+// product es nil antes de ProductCreated, y el resultado es nil tras ProductDeleted
+// product se modifica en el sitio: quien lo llama debe pasar una copia propia
+func applyEvent(product *entities.Product, event ProductEvent) *entities.Product {
+	switch event.Type {
+	case ProductCreated:
+		return event.Product.Clone()
+	case ProductDeleted:
+		return nil
+	}
+	if product == nil {
+		return nil
+	}
+
+	switch event.Type {
+	case ProductDetailsChanged:
+		product.Name = event.Name
+		product.Description = event.Description
+		product.Category = event.Category
+	case ProductPriceChanged:
+		product.Price = event.Price
+	case ProductStockAdded:
+		product.Stock += event.Quantity
+	case ProductStockRemoved:
+		product.Stock -= event.Quantity
+	case ProductReservationsChanged:
+		product.Reservations = append([]entities.StockReservation(nil), event.Reservations...)
+	case ProductActivated:
+		product.IsActive = true
+	case ProductDeactivated:
+		product.IsActive = false
+	}
+	product.Version = event.Version
+	product.UpdatedAt = event.OccurredAt
+	return product
+}
+
+// diffProduct retorna los eventos que llevan el estado previous (nil si el producto
+// no existe) al estado next, sin Sequence asignado
+func diffProduct(previous, next *entities.Product, version int) []ProductEvent {
+	base := ProductEvent{ProductID: next.ID, Version: version, OccurredAt: next.UpdatedAt}
+	with := func(eventType ProductEventType, set func(*ProductEvent)) ProductEvent {
+		event := base
+		event.Type = eventType
+		if set != nil {
+			set(&event)
+		}
+		return event
+	}
+
+	if previous == nil {
+		created := next.Clone()
+		created.Version = version
+		return []ProductEvent{with(ProductCreated, func(e *ProductEvent) { e.Product = created })}
+	}
+
+	var changes []ProductEvent
+	if previous.Name != next.Name || previous.Description != next.Description || previous.Category != next.Category {
+		changes = append(changes, with(ProductDetailsChanged, func(e *ProductEvent) {
+			e.Name, e.Description, e.Category = next.Name, next.Description, next.Category
+		}))
+	}
+	if previous.Price != next.Price {
+		changes = append(changes, with(ProductPriceChanged, func(e *ProductEvent) { e.Price = next.Price }))
+	}
+	if delta := next.Stock - previous.Stock; delta > 0 {
+		changes = append(changes, with(ProductStockAdded, func(e *ProductEvent) { e.Quantity = delta }))
+	} else if delta < 0 {
+		changes = append(changes, with(ProductStockRemoved, func(e *ProductEvent) { e.Quantity = -delta }))
+	}
+	if !sameReservations(previous.Reservations, next.Reservations) {
+		changes = append(changes, with(ProductReservationsChanged, func(e *ProductEvent) {
+			e.Reservations = append([]entities.StockReservation(nil), next.Reservations...)
+		}))
+	}
+	if previous.IsActive != next.IsActive {
+		if next.IsActive {
+			changes = append(changes, with(ProductActivated, nil))
+		} else {
+			changes = append(changes, with(ProductDeactivated, nil))
+		}
+	}
+	if len(changes) == 0 {
+		changes = append(changes, with(ProductTouched, nil))
+	}
+	return changes
+}
+
+// sameReservations compara dos listas de reservas elemento a elemento
+func sameReservations(a, b []entities.StockReservation) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID || a[i].Quantity != b[i].Quantity || !a[i].ExpiresAt.Equal(b[i].ExpiresAt) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,345 @@
+package eventsourced
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// DefaultSnapshotInterval es el número de eventos entre dos snapshots de un producto
+const DefaultSnapshotInterval = 20
+
+// EventSourcedProductRepository implementa ProductRepository guardando el historial
+// de cambios de cada producto en lugar de su último estado
+//
+// Cada Save compara el producto con el estado guardado y añade un evento por cada
+// cambio (precio, stock, activación...). El estado se reconstruye aplicando los
+// eventos en orden a partir del último snapshot, que se toma cada cierto número de
+// eventos para que la carga no dependa de la longitud del historial
+// El historial se conserva tras Delete: History y ProductAt siguen respondiendo
+//
+// No guarda mensajes de outbox ni participa en transacciones de UnitOfWork
+type EventSourcedProductRepository struct {
+	streams map[string]*stream
+	mutex   sync.RWMutex
+
+	snapshotInterval int
+}
+
+// stream es el historial de un producto
+type stream struct {
+	events    []ProductEvent
+	snapshots []snapshot
+}
+
+// snapshot es el estado de un producto tras aplicar sus primeros Sequence eventos
+// product es nil si en ese punto el producto estaba eliminado
+type snapshot struct {
+	sequence int
+	product  *entities.Product
+}
+
+// NewProductRepository crea un repositorio de productos basado en eventos
+func NewProductRepository() *EventSourcedProductRepository {
+	return &EventSourcedProductRepository{
+		streams:          make(map[string]*stream),
+		snapshotInterval: DefaultSnapshotInterval,
+	}
+}
+
+// WithSnapshotInterval configura cada cuántos eventos se toma un snapshot de un producto
+func (r *EventSourcedProductRepository) WithSnapshotInterval(events int) *EventSourcedProductRepository {
+	if events > 0 {
+		r.snapshotInterval = events
+	}
+	return r
+}
+
+// Save guarda un producto añadiendo a su historial los cambios respecto al estado guardado
+func (r *EventSourcedProductRepository) Save(ctx context.Context, product *entities.Product) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := r.current(product.ID)
+	if err := repositories.CheckProductVersion(previous, product); err != nil {
+		return err
+	}
+
+	r.append(product.ID, diffProduct(previous, product, product.Version+1))
+	product.Version++
+	return nil
+}
+
+// Update aplica una modificación de forma atómica bajo el lock de escritura
+func (r *EventSourcedProductRepository) Update(ctx context.Context, id string, mutate func(*entities.Product) error) (*entities.Product, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := r.current(id)
+	if stored == nil {
+		return nil, repositories.ErrProductNotFound
+	}
+
+	product := stored.Clone()
+	if err := mutate(product); err != nil {
+		return nil, err
+	}
+	product.Version++
+
+	r.append(id, diffProduct(stored, product, product.Version))
+	return product, nil
+}
+
+// FindByID reconstruye el estado actual de un producto
+func (r *EventSourcedProductRepository) FindByID(ctx context.Context, id string) (*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.current(id), nil
+}
+
+// FindByName busca productos por nombre
+func (r *EventSourcedProductRepository) FindByName(ctx context.Context, name string) ([]*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(p *entities.Product) bool { return p.Name == name }), nil
+}
+
+// FindByCategory busca productos por categoría
+func (r *EventSourcedProductRepository) FindByCategory(ctx context.Context, category string, limit, offset int) ([]*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return paginate(r.filter(func(p *entities.Product) bool { return p.Category == category }), limit, offset), nil
+}
+
+// FindAll retorna todos los productos ordenados por ID
+func (r *EventSourcedProductRepository) FindAll(ctx context.Context, limit, offset int) ([]*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return paginate(r.filter(func(*entities.Product) bool { return true }), limit, offset), nil
+}
+
+// FindAvailable retorna productos disponibles (activos y con stock)
+func (r *EventSourcedProductRepository) FindAvailable(ctx context.Context, limit, offset int) ([]*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return paginate(r.filter(func(p *entities.Product) bool { return p.IsAvailable() }), limit, offset), nil
+}
+
+// FindByPriceRange busca productos en un rango de precios
+func (r *EventSourcedProductRepository) FindByPriceRange(ctx context.Context, minPrice, maxPrice entities.Money, limit, offset int) ([]*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return paginate(r.filter(func(p *entities.Product) bool {
+		return p.Price.Between(minPrice, maxPrice)
+	}), limit, offset), nil
+}
+
+// FindMatching retorna los productos que cumplen la especificación, ordenados por ID
+func (r *EventSourcedProductRepository) FindMatching(ctx context.Context, spec repositories.Specification, limit, offset int) ([]*entities.Product, error) {
+	if err := repositories.ValidateProductSpecification(spec); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return paginate(r.filter(func(p *entities.Product) bool { return repositories.MatchProduct(spec, p) }), limit, offset), nil
+}
+
+// FindPage retorna una página de los productos que cumplen la especificación
+func (r *EventSourcedProductRepository) FindPage(ctx context.Context, spec repositories.Specification, request repositories.PageRequest) (repositories.Page[*entities.Product], error) {
+	if err := repositories.ValidateProductSpecification(spec); err != nil {
+		return repositories.Page[*entities.Product]{}, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return repositories.ProductPage(r.filter(func(p *entities.Product) bool { return repositories.MatchProduct(spec, p) }), request)
+}
+
+// Delete añade al historial la eliminación del producto
+func (r *EventSourcedProductRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	product := r.current(id)
+	if product == nil {
+		return repositories.ErrProductNotFound
+	}
+
+	r.append(id, []ProductEvent{{
+		Type:       ProductDeleted,
+		ProductID:  id,
+		Version:    product.Version + 1,
+		OccurredAt: time.Now(),
+	}})
+	return nil
+}
+
+// Exists verifica si un producto existe por ID
+func (r *EventSourcedProductRepository) Exists(ctx context.Context, id string) (bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.current(id) != nil, nil
+}
+
+// Count retorna el número total de productos
+func (r *EventSourcedProductRepository) Count(ctx context.Context) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.filter(func(*entities.Product) bool { return true })), nil
+}
+
+// CountByCategory retorna el número de productos en una categoría
+func (r *EventSourcedProductRepository) CountByCategory(ctx context.Context, category string) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.filter(func(p *entities.Product) bool { return p.Category == category })), nil
+}
+
+// History retorna todos los eventos de un producto en orden, incluidos los
+// anteriores a su eliminación. Retorna ErrProductNotFound si nunca existió
+func (r *EventSourcedProductRepository) History(ctx context.Context, id string) ([]ProductEvent, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	s, exists := r.streams[id]
+	if !exists {
+		return nil, repositories.ErrProductNotFound
+	}
+	history := make([]ProductEvent, len(s.events))
+	for i, event := range s.events {
+		history[i] = copyEvent(event)
+	}
+	return history, nil
+}
+
+// ProductAt reconstruye el estado de un producto en el instante at, aplicando los
+// eventos ocurridos hasta entonces. Retorna nil si en ese instante no existía
+// Las fechas vienen del UpdatedAt de cada guardado y no tienen por qué crecer con
+// el historial, así que tras el primer evento posterior a at se siguen revisando
+// los demás y se aplican, en orden, los que ocurrieron antes
+func (r *EventSourcedProductRepository) ProductAt(ctx context.Context, id string, at time.Time) (*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	s, exists := r.streams[id]
+	if !exists {
+		return nil, nil
+	}
+	sequence := 0
+	for sequence < len(s.events) && !s.events[sequence].OccurredAt.After(at) {
+		sequence++
+	}
+	product := s.state(sequence)
+	for _, event := range s.events[sequence:] {
+		if !event.OccurredAt.After(at) {
+			product = applyEvent(product, event)
+		}
+	}
+	return product, nil
+}
+
+// current reconstruye el estado actual de un producto, o nil si no existe
+// Debe llamarse con el mutex adquirido
+func (r *EventSourcedProductRepository) current(id string) *entities.Product {
+	s, exists := r.streams[id]
+	if !exists {
+		return nil
+	}
+	return s.state(len(s.events))
+}
+
+// append añade eventos al historial de un producto y toma un snapshot si desde el
+// último han pasado snapshotInterval eventos o más
+// Debe llamarse con el mutex de escritura adquirido
+func (r *EventSourcedProductRepository) append(id string, events []ProductEvent) {
+	s, exists := r.streams[id]
+	if !exists {
+		s = &stream{}
+		r.streams[id] = s
+	}
+	for _, event := range events {
+		event.Sequence = len(s.events) + 1
+		s.events = append(s.events, event)
+	}
+
+	last := 0
+	if len(s.snapshots) > 0 {
+		last = s.snapshots[len(s.snapshots)-1].sequence
+	}
+	if len(s.events)-last >= r.snapshotInterval {
+		s.snapshots = append(s.snapshots, snapshot{sequence: len(s.events), product: s.state(len(s.events))})
+	}
+}
+
+// state reconstruye el estado tras los primeros sequence eventos, partiendo del
+// último snapshot anterior. Retorna una copia que el llamador puede modificar
+func (s *stream) state(sequence int) *entities.Product {
+	var product *entities.Product
+	start := 0
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		if s.snapshots[i].sequence <= sequence {
+			if s.snapshots[i].product != nil {
+				product = s.snapshots[i].product.Clone()
+			}
+			start = s.snapshots[i].sequence
+			break
+		}
+	}
+	for _, event := range s.events[start:sequence] {
+		product = applyEvent(product, event)
+	}
+	return product
+}
+
+// filter retorna el estado actual de los productos que cumplen la condición, ordenados por ID
+// Debe llamarse con el mutex adquirido
+func (r *EventSourcedProductRepository) filter(match func(*entities.Product) bool) []*entities.Product {
+	var products []*entities.Product
+	for id := range r.streams {
+		if product := r.current(id); product != nil && match(product) {
+			products = append(products, product)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return products
+}
+
+// paginate aplica limit y offset a un slice ya filtrado
+func paginate(products []*entities.Product, limit, offset int) []*entities.Product {
+	start := offset
+	end := offset + limit
+	if start >= len(products) {
+		return []*entities.Product{}
+	}
+	if end > len(products) {
+		end = len(products)
+	}
+	return products[start:end]
+}
+
+// copyEvent retorna una copia del evento que no comparte el producto ni las reservas
+func copyEvent(event ProductEvent) ProductEvent {
+	if event.Product != nil {
+		event.Product = event.Product.Clone()
+	}
+	if event.Reservations != nil {
+		event.Reservations = append([]entities.StockReservation(nil), event.Reservations...)
+	}
+	return event
+}
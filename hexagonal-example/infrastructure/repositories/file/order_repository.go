@@ -0,0 +1,136 @@
+package file
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// ordersFile es el nombre del fichero de pedidos dentro del directorio de datos
+const ordersFile = "orders.json"
+
+// FileOrderRepository implementa OrderRepository persistiendo en un fichero JSON
+type FileOrderRepository struct {
+	store *store[entities.Order]
+	mutex sync.RWMutex
+}
+
+// NewOrderRepository crea un repositorio de pedidos respaldado por dataDir/orders.json
+func NewOrderRepository(dataDir string) (repositories.OrderRepository, error) {
+	s, err := openStore(dataDir, ordersFile, func(o *entities.Order) string { return o.ID })
+	if err != nil {
+		return nil, &repositories.OrderRepositoryError{Message: "opening order store", Err: err}
+	}
+	return &FileOrderRepository{store: s}, nil
+}
+
+// Save guarda un pedido en el repositorio
+func (r *FileOrderRepository) Save(ctx context.Context, order *entities.Order) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := r.store.records[order.ID]
+
+	// Verificar la versión (concurrencia optimista)
+	if err := repositories.CheckOrderVersion(previous, order); err != nil {
+		return err
+	}
+
+	// Crear una copia del pedido para evitar modificaciones externas
+	orderCopy := order.Clone()
+	orderCopy.Version++
+	r.store.records[order.ID] = orderCopy
+
+	if err := r.store.write(ctx, &r.mutex, order.ID, previous, orderCopy); err != nil {
+		return &repositories.OrderRepositoryError{Message: "saving order", Err: err}
+	}
+
+	order.Version = orderCopy.Version
+	return nil
+}
+
+// FindByID busca un pedido por su ID
+func (r *FileOrderRepository) FindByID(ctx context.Context, id string) (*entities.Order, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	order, exists := r.store.records[id]
+	if !exists {
+		return nil, nil
+	}
+
+	// Retornar una copia para evitar modificaciones externas
+	return order.Clone(), nil
+}
+
+// FindByUserID retorna los pedidos de un usuario ordenados por ID
+func (r *FileOrderRepository) FindByUserID(ctx context.Context, userID string, limit, offset int) ([]*entities.Order, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(o *entities.Order) bool { return o.UserID == userID }, limit, offset), nil
+}
+
+// FindAll retorna todos los pedidos ordenados por ID
+func (r *FileOrderRepository) FindAll(ctx context.Context, limit, offset int) ([]*entities.Order, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(*entities.Order) bool { return true }, limit, offset), nil
+}
+
+// Delete elimina un pedido del repositorio
+func (r *FileOrderRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.store.records[id]
+	if !exists {
+		return repositories.ErrOrderNotFound
+	}
+
+	delete(r.store.records, id)
+	if err := r.store.write(ctx, &r.mutex, id, previous, nil); err != nil {
+		return &repositories.OrderRepositoryError{Message: "deleting order", Err: err}
+	}
+	return nil
+}
+
+// Exists verifica si un pedido existe por ID
+func (r *FileOrderRepository) Exists(ctx context.Context, id string) (bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, exists := r.store.records[id]
+	return exists, nil
+}
+
+// Count retorna el número total de pedidos
+func (r *FileOrderRepository) Count(ctx context.Context) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.store.records), nil
+}
+
+// filter retorna copias de los pedidos que cumplen la condición, ordenados y paginados
+// Debe llamarse con el mutex adquirido
+func (r *FileOrderRepository) filter(match func(*entities.Order) bool, limit, offset int) []*entities.Order {
+	var orders []*entities.Order
+	for _, order := range r.store.records {
+		if match(order) {
+			orders = append(orders, order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+
+	page := paginate(orders, limit, offset)
+	result := make([]*entities.Order, 0, len(page))
+	for _, order := range page {
+		result = append(result, order.Clone())
+	}
+	return result
+}
@@ -0,0 +1,322 @@
+package file
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// productsFile es el nombre del fichero de productos dentro del directorio de datos
+const productsFile = "products.json"
+
+// FileProductRepository implementa ProductRepository persistiendo en un fichero JSON
+type FileProductRepository struct {
+	store *store[entities.Product]
+	mutex sync.RWMutex
+}
+
+// NewProductRepository crea un repositorio de productos respaldado por dataDir/products.json
+func NewProductRepository(dataDir string) (repositories.ProductRepository, error) {
+	s, err := openStore(dataDir, productsFile, func(p *entities.Product) string { return p.ID })
+	if err != nil {
+		return nil, &repositories.ProductRepositoryError{Message: "opening product store", Err: err}
+	}
+	return &FileProductRepository{store: s}, nil
+}
+
+// Save guarda un producto en el repositorio
+func (r *FileProductRepository) Save(ctx context.Context, product *entities.Product) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := r.store.records[product.ID]
+
+	// Verificar la versión (concurrencia optimista)
+	if err := repositories.CheckProductVersion(previous, product); err != nil {
+		return err
+	}
+	messages, err := repositories.ProductOutboxMessages(ctx, product)
+	if err != nil {
+		return err
+	}
+
+	// Crear una copia del producto para evitar modificaciones externas
+	productCopy := product.Clone()
+	productCopy.Version++
+	r.store.records[product.ID] = productCopy
+	restoreOutbox := r.addOutbox(ctx, messages)
+
+	if err := r.store.write(ctx, &r.mutex, product.ID, previous, productCopy); err != nil {
+		restoreOutbox()
+		return &repositories.ProductRepositoryError{Message: "saving product", Err: err}
+	}
+
+	product.Version = productCopy.Version
+	return nil
+}
+
+// Update aplica una modificación de forma atómica bajo el lock de escritura
+func (r *FileProductRepository) Update(ctx context.Context, id string, mutate func(*entities.Product) error) (*entities.Product, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.store.records[id]
+	if !exists {
+		return nil, repositories.ErrProductNotFound
+	}
+
+	// Modificar una copia para no alterar el estado almacenado si mutate falla
+	product := stored.Clone()
+	if err := mutate(product); err != nil {
+		return nil, err
+	}
+	messages, err := repositories.ProductOutboxMessages(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+	product.Version++
+
+	written := product.Clone()
+	r.store.records[id] = written
+	restoreOutbox := r.addOutbox(ctx, messages)
+	if err := r.store.write(ctx, &r.mutex, id, stored, written); err != nil {
+		restoreOutbox()
+		return nil, &repositories.ProductRepositoryError{Message: "updating product", Err: err}
+	}
+	return product, nil
+}
+
+// FindByID busca un producto por su ID
+func (r *FileProductRepository) FindByID(ctx context.Context, id string) (*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	product, exists := r.store.records[id]
+	if !exists {
+		return nil, nil
+	}
+
+	// Retornar una copia para evitar modificaciones externas
+	productCopy := product.Clone()
+	return productCopy, nil
+}
+
+// FindByName busca productos por nombre
+func (r *FileProductRepository) FindByName(ctx context.Context, name string) ([]*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(p *entities.Product) bool { return p.Name == name }, len(r.store.records), 0), nil
+}
+
+// FindByCategory busca productos por categoría
+func (r *FileProductRepository) FindByCategory(ctx context.Context, category string, limit, offset int) ([]*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(p *entities.Product) bool { return p.Category == category }, limit, offset), nil
+}
+
+// FindAll retorna todos los productos ordenados por ID
+func (r *FileProductRepository) FindAll(ctx context.Context, limit, offset int) ([]*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(*entities.Product) bool { return true }, limit, offset), nil
+}
+
+// FindAvailable retorna productos disponibles (activos y con stock)
+func (r *FileProductRepository) FindAvailable(ctx context.Context, limit, offset int) ([]*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(p *entities.Product) bool { return p.IsAvailable() }, limit, offset), nil
+}
+
+// FindByPriceRange busca productos en un rango de precios
+func (r *FileProductRepository) FindByPriceRange(ctx context.Context, minPrice, maxPrice entities.Money, limit, offset int) ([]*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(p *entities.Product) bool {
+		return p.Price.Between(minPrice, maxPrice)
+	}, limit, offset), nil
+}
+
+// FindMatching retorna los productos que cumplen la especificación, ordenados por ID
+func (r *FileProductRepository) FindMatching(ctx context.Context, spec repositories.Specification, limit, offset int) ([]*entities.Product, error) {
+	if err := repositories.ValidateProductSpecification(spec); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(p *entities.Product) bool { return repositories.MatchProduct(spec, p) }, limit, offset), nil
+}
+
+// FindPage retorna una página de los productos que cumplen la especificación
+func (r *FileProductRepository) FindPage(ctx context.Context, spec repositories.Specification, request repositories.PageRequest) (repositories.Page[*entities.Product], error) {
+	if err := repositories.ValidateProductSpecification(spec); err != nil {
+		return repositories.Page[*entities.Product]{}, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var products []*entities.Product
+	for _, product := range r.store.records {
+		if repositories.MatchProduct(spec, product) {
+			products = append(products, product)
+		}
+	}
+	page, err := repositories.ProductPage(products, request)
+	for i, product := range page.Items {
+		page.Items[i] = product.Clone()
+	}
+	return page, err
+}
+
+// Delete elimina un producto del repositorio
+func (r *FileProductRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.store.records[id]
+	if !exists {
+		return repositories.ErrProductNotFound
+	}
+	messages, err := repositories.ProductDeleteOutboxMessages(ctx, previous)
+	if err != nil {
+		return err
+	}
+
+	delete(r.store.records, id)
+	restoreOutbox := r.addOutbox(ctx, messages)
+	if err := r.store.write(ctx, &r.mutex, id, previous, nil); err != nil {
+		restoreOutbox()
+		return &repositories.ProductRepositoryError{Message: "deleting product", Err: err}
+	}
+	return nil
+}
+
+// Exists verifica si un producto existe por ID
+func (r *FileProductRepository) Exists(ctx context.Context, id string) (bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, exists := r.store.records[id]
+	return exists, nil
+}
+
+// Count retorna el número total de productos
+func (r *FileProductRepository) Count(ctx context.Context) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.store.records), nil
+}
+
+// CountByCategory retorna el número de productos en una categoría
+func (r *FileProductRepository) CountByCategory(ctx context.Context, category string) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := 0
+	for _, product := range r.store.records {
+		if product.Category == category {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// filter retorna copias de los productos que cumplen la condición, ordenados y paginados
+// Debe llamarse con el mutex adquirido
+func (r *FileProductRepository) filter(match func(*entities.Product) bool, limit, offset int) []*entities.Product {
+	var products []*entities.Product
+	for _, product := range r.store.records {
+		if match(product) {
+			products = append(products, product)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+
+	page := paginate(products, limit, offset)
+	result := make([]*entities.Product, 0, len(page))
+	for _, product := range page {
+		productCopy := product.Clone()
+		result = append(result, productCopy)
+	}
+	return result
+}
+
+// addOutbox añade mensajes al outbox y retorna cómo retirarlos si la escritura falla
+// Dentro de una transacción los mensajes quedan en staged: el commit los escribe
+// en el fichero y solo pasan al outbox cuando los ficheros están aplicados, para
+// que el relay no publique eventos de cambios que aún pueden deshacerse
+// Debe llamarse con el mutex adquirido
+func (r *FileProductRepository) addOutbox(ctx context.Context, messages []*repositories.OutboxMessage) func() {
+	if tx := transactionFrom(ctx); tx != nil {
+		if len(messages) > 0 {
+			r.store.staged.Add(messages)
+			unstage := func() {
+				for _, message := range messages {
+					_ = r.store.staged.Remove(message.ID)
+				}
+			}
+			tx.onCommit(func() {
+				unstage()
+				r.store.outbox.Add(messages)
+			})
+			tx.onRollback(func() error {
+				r.mutex.Lock()
+				defer r.mutex.Unlock()
+
+				unstage()
+				return nil
+			})
+		}
+		return func() {}
+	}
+
+	previous := r.store.outbox
+	r.store.outbox.Add(messages)
+	return func() { r.store.outbox = previous }
+}
+
+// Pending retorna los mensajes del outbox pendientes de publicar
+func (r *FileProductRepository) Pending(ctx context.Context, limit int) ([]*repositories.OutboxMessage, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.store.outbox.Pending(limit), nil
+}
+
+// MarkSent retira un mensaje publicado del outbox y lo persiste
+func (r *FileProductRepository) MarkSent(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := r.store.outbox
+	if err := r.store.outbox.Remove(id); err != nil {
+		return err
+	}
+	if err := r.store.persist(); err != nil {
+		r.store.outbox = previous
+		return &repositories.ProductRepositoryError{Message: "updating outbox", Err: err}
+	}
+	return nil
+}
+
+// MarkFailed anota un intento de publicación fallido
+// El intento solo se anota en memoria: no merece una escritura del fichero completo
+func (r *FileProductRepository) MarkFailed(ctx context.Context, id string, reason string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.store.outbox.Fail(id, reason)
+}
@@ -0,0 +1,253 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"hexagonal-example/domain/repositories"
+	"hexagonal-example/infrastructure/repositories/internal/outbox"
+)
+
+// tempSuffix es la extensión de los ficheros temporales usados en la escritura atómica
+const tempSuffix = ".tmp"
+
+// store mantiene en memoria el contenido de un fichero JSON y lo persiste
+// de forma atómica (escritura en fichero temporal + fsync + rename)
+// No es seguro para uso concurrente: los repositorios lo protegen con su propio mutex
+type store[T any] struct {
+	path    string
+	records map[string]*T
+	idOf    func(*T) string
+
+	// outbox contiene los mensajes pendientes guardados en el mismo fichero que
+	// los registros, de modo que ambos se escriben en la misma operación atómica
+	outbox outbox.Messages
+
+	// staged contiene los mensajes de la transacción en curso: se escriben en el
+	// fichero junto al outbox, pero Pending no los ve hasta que la transacción se
+	// confirma y sus ficheros se aplican
+	staged outbox.Messages
+
+	// writes cuenta las veces que se ha escrito el fichero
+	writes int
+}
+
+// document es el formato del fichero cuando hay mensajes en el outbox
+// Sin mensajes pendientes el fichero es solo la lista de registros
+type document[T any] struct {
+	Records []*T                          `json:"records"`
+	Outbox  []*repositories.OutboxMessage `json:"outbox"`
+}
+
+// openStore abre (o crea) el fichero indicado dentro del directorio de datos
+// y recupera su estado tras una posible caída del proceso
+func openStore[T any](dataDir, name string, idOf func(*T) string) (*store[T], error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating data directory: %w", err)
+	}
+
+	s := &store[T]{
+		path:    filepath.Join(dataDir, name),
+		records: make(map[string]*T),
+		idOf:    idOf,
+	}
+	if err := recoverDataDir(dataDir); err != nil {
+		return nil, err
+	}
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// recover resuelve el estado dejado por una escritura interrumpida
+// Si el fichero principal existe, el temporal es una escritura a medias y se descarta
+// Si el fichero principal no existe pero el temporal es un JSON válido y completo,
+// la caída ocurrió justo antes del rename y el temporal se promueve
+func (s *store[T]) recover() error {
+	tempPath := s.path + tempSuffix
+	if _, err := os.Stat(tempPath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if _, err := os.Stat(s.path); errors.Is(err, fs.ErrNotExist) {
+		data, err := os.ReadFile(tempPath)
+		if err == nil && json.Valid(data) {
+			return os.Rename(tempPath, s.path)
+		}
+	}
+
+	return os.Remove(tempPath)
+}
+
+// load lee el fichero principal; un fichero inexistente equivale a un almacén vacío
+func (s *store[T]) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", s.path, err)
+	}
+
+	var doc document[T]
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(data, &doc)
+	} else {
+		err = json.Unmarshal(data, &doc.Records)
+	}
+	if err != nil {
+		return fmt.Errorf("decoding %s: %w", s.path, err)
+	}
+	s.outbox = doc.Outbox
+	for _, record := range doc.Records {
+		s.records[s.idOf(record)] = record
+	}
+	return nil
+}
+
+// persist escribe todos los registros y el outbox de forma atómica
+func (s *store[T]) persist() error {
+	_, data, err := s.encode()
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return err
+	}
+	s.writes++
+	return nil
+}
+
+// encode retorna la ruta del fichero y el contenido que le corresponde
+// Los registros se ordenan por ID para que el fichero sea estable entre escrituras
+func (s *store[T]) encode() (string, []byte, error) {
+	records := make([]*T, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return s.idOf(records[i]) < s.idOf(records[j])
+	})
+
+	messages := s.outbox
+	messages.Add(s.staged)
+
+	var value interface{} = records
+	if len(messages) > 0 {
+		value = document[T]{Records: records, Outbox: messages}
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	return s.path, data, err
+}
+
+// location retorna la ruta del fichero
+func (s *store[T]) location() string {
+	return s.path
+}
+
+// generation retorna el número de escrituras del fichero
+func (s *store[T]) generation() int {
+	return s.writes
+}
+
+// write persiste un cambio ya aplicado en records[id]: previous es el valor
+// anterior y written el nuevo (nil si no existía o si se eliminó)
+// Sin transacción escribe el fichero y, si falla, restaura previous
+// Dentro de una transacción la escritura se aplaza hasta el commit y se registra
+// cómo deshacer el cambio. Debe llamarse con mutex adquirido
+func (s *store[T]) write(ctx context.Context, mutex *sync.RWMutex, id string, previous, written *T) error {
+	if tx := transactionFrom(ctx); tx != nil {
+		tx.track(s, mutex)
+		tx.onRollback(func() error {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if s.records[id] != written {
+				return repositories.ErrRollbackConflict
+			}
+			s.restore(id, previous)
+			return nil
+		})
+		return nil
+	}
+
+	if err := s.persist(); err != nil {
+		// Restaurar el estado anterior para no divergir del fichero
+		s.restore(id, previous)
+		return err
+	}
+	return nil
+}
+
+// restore deja records[id] con el valor indicado, o sin registro si es nil
+func (s *store[T]) restore(id string, record *T) {
+	if record == nil {
+		delete(s.records, id)
+	} else {
+		s.records[id] = record
+	}
+}
+
+// writeFileAtomic escribe los datos en un fichero temporal, lo sincroniza a disco
+// y lo renombra sobre el destino, de modo que un lector nunca vea un fichero a medias
+func writeFileAtomic(path string, data []byte) error {
+	tempPath := path + tempSuffix
+
+	f, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir sincroniza el directorio para que el rename sea durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// Algunos sistemas de ficheros no soportan fsync sobre directorios
+	_ = d.Sync()
+	return nil
+}
+
+// paginate aplica limit y offset a un slice ya filtrado
+func paginate[T any](items []*T, limit, offset int) []*T {
+	start := offset
+	end := offset + limit
+	if start >= len(items) {
+		return []*T{}
+	}
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
@@ -0,0 +1,312 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"hexagonal-example/domain/repositories"
+)
+
+// journalFile es el fichero donde se guardan las escrituras de una transacción
+// antes de aplicarlas, dentro del directorio de datos
+const journalFile = "transaction.journal"
+
+// UnitOfWork implementa repositories.UnitOfWork para los repositorios de dataDir
+//
+// Dentro de una transacción los cambios se aplican en memoria y los ficheros no se
+// escriben hasta Commit. Commit guarda primero el contenido de todos los ficheros
+// modificados en un journal y después los reescribe; si el proceso cae a mitad,
+// el journal se aplica cuando el siguiente proceso abre el directorio por primera
+// vez, de modo que se ven todos los cambios o ninguno. Los repositorios deben abrirse
+// con el mismo dataDir que la unidad de trabajo
+//
+// Las transacciones se ejecutan de una en una, pero no están aisladas de las
+// escrituras hechas fuera de ellas, que pueden ver (y persistir) cambios aún no
+// confirmados. Rollback no sobrescribe una entidad modificada fuera de la
+// transacción y retorna ErrRollbackConflict
+type UnitOfWork struct {
+	dataDir string
+
+	// active serializa las transacciones: se adquiere en Begin y se libera al terminar
+	active sync.Mutex
+}
+
+// NewUnitOfWork crea la unidad de trabajo de los repositorios de dataDir
+func NewUnitOfWork(dataDir string) *UnitOfWork {
+	return &UnitOfWork{dataDir: dataDir}
+}
+
+// persistable es la parte de store que usa una transacción, independiente del tipo de registro
+type persistable interface {
+	location() string
+	encode() (path string, data []byte, err error)
+	persist() error
+	generation() int
+}
+
+// dirtyStore es un fichero modificado por una transacción
+type dirtyStore struct {
+	store persistable
+	mutex *sync.RWMutex
+
+	// startGeneration permite saber si otra escritura persistió el fichero durante
+	// la transacción, incluyendo cambios que Rollback deshace después
+	startGeneration int
+}
+
+// transaction es una transacción en curso de los repositorios de ficheros
+type transaction struct {
+	repositories.Transaction
+
+	uow *UnitOfWork
+
+	mutex  sync.Mutex
+	undo   []func() error
+	commit []func()
+	dirty  []*dirtyStore
+}
+
+// transactionKey es la clave de contexto de la transacción de ficheros
+type transactionKey struct{}
+
+// Begin inicia una transacción; espera a que termine la que esté en curso
+func (u *UnitOfWork) Begin(ctx context.Context) (context.Context, error) {
+	if transactionFrom(ctx) != nil {
+		return ctx, repositories.ErrTransactionInProgress
+	}
+	u.active.Lock()
+
+	tx := &transaction{uow: u}
+	ctx = repositories.WithTransaction(ctx, u, &tx.Transaction)
+	return context.WithValue(ctx, transactionKey{}, tx), nil
+}
+
+// Commit escribe de forma atómica todos los ficheros modificados por la transacción
+// Si no se puede guardar el journal, la transacción se deshace y se retorna el error
+func (u *UnitOfWork) Commit(ctx context.Context) error {
+	tx, err := u.finish(ctx)
+	if err != nil {
+		return err
+	}
+	defer u.active.Unlock()
+
+	if err := tx.apply(u.dataDir); err != nil {
+		return err
+	}
+	tx.RunAfterCommit(ctx)
+	return nil
+}
+
+// Rollback deshace los cambios de la transacción
+func (u *UnitOfWork) Rollback(ctx context.Context) error {
+	tx, err := u.finish(ctx)
+	if err != nil {
+		return err
+	}
+	defer u.active.Unlock()
+
+	return tx.rollback()
+}
+
+// finish termina la transacción de ctx
+func (u *UnitOfWork) finish(ctx context.Context) (*transaction, error) {
+	tx, _ := ctx.Value(transactionKey{}).(*transaction)
+	if tx == nil || tx.uow != u {
+		return nil, repositories.ErrNoTransaction
+	}
+	if err := tx.Finish(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// journalEntry es el contenido final de uno de los ficheros de una transacción
+// Path es el nombre del fichero dentro del directorio de datos, nunca una ruta
+type journalEntry struct {
+	Path string          `json:"path"`
+	Data json.RawMessage `json:"data"`
+}
+
+// apply escribe el journal y después los ficheros modificados
+// Los repositorios afectados quedan bloqueados mientras tanto, en orden de fichero
+// para que dos transacciones no puedan bloquearse entre sí
+func (tx *transaction) apply(dataDir string) error {
+	sort.Slice(tx.dirty, func(i, j int) bool {
+		return tx.dirty[i].store.location() < tx.dirty[j].store.location()
+	})
+	for _, dirty := range tx.dirty {
+		dirty.mutex.Lock()
+	}
+	unlock := func() {
+		for _, dirty := range tx.dirty {
+			dirty.mutex.Unlock()
+		}
+	}
+
+	entries := make([]journalEntry, 0, len(tx.dirty))
+	for _, dirty := range tx.dirty {
+		path, data, err := dirty.store.encode()
+		if err != nil {
+			unlock()
+			return errors.Join(fmt.Errorf("encoding %s: %w", path, err), tx.rollback())
+		}
+		if filepath.Dir(path) != filepath.Clean(dataDir) {
+			unlock()
+			return errors.Join(fmt.Errorf("%s is outside the unit of work directory %s", path, dataDir), tx.rollback())
+		}
+		entries = append(entries, journalEntry{Path: filepath.Base(path), Data: data})
+	}
+
+	journal, err := json.Marshal(entries)
+	if err == nil {
+		err = writeFileAtomic(filepath.Join(dataDir, journalFile), journal)
+	}
+	if err != nil {
+		unlock()
+		return errors.Join(fmt.Errorf("writing transaction journal: %w", err), tx.rollback())
+	}
+
+	// Con el journal en disco la transacción está confirmada; si algo falla desde
+	// aquí, el journal se aplica de nuevo al abrir los repositorios
+	defer unlock()
+	for _, dirty := range tx.dirty {
+		if err := dirty.store.persist(); err != nil {
+			return fmt.Errorf("applying committed transaction (it will be recovered on restart): %w", err)
+		}
+	}
+
+	// Los mensajes del outbox se hacen visibles ahora, con los ficheros aplicados
+	// y los repositorios aún bloqueados
+	for _, commit := range tx.commit {
+		commit()
+	}
+	return os.Remove(filepath.Join(dataDir, journalFile))
+}
+
+// rollback deshace los cambios en memoria en orden inverso y reescribe los
+// ficheros que otras escrituras persistieron con cambios de la transacción
+func (tx *transaction) rollback() error {
+	var errs []error
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, dirty := range tx.dirty {
+		dirty.mutex.Lock()
+		if dirty.store.generation() != dirty.startGeneration {
+			if err := dirty.store.persist(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		dirty.mutex.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// track registra un fichero modificado por la transacción
+// Debe llamarse con el mutex del repositorio adquirido
+func (tx *transaction) track(s persistable, mutex *sync.RWMutex) {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	for _, dirty := range tx.dirty {
+		if dirty.store == s {
+			return
+		}
+	}
+	tx.dirty = append(tx.dirty, &dirtyStore{store: s, mutex: mutex, startGeneration: s.generation()})
+}
+
+// transactionFrom retorna la transacción en curso de ctx, o nil si no hay ninguna
+func transactionFrom(ctx context.Context) *transaction {
+	tx, _ := ctx.Value(transactionKey{}).(*transaction)
+	if tx == nil || tx.Done() {
+		return nil
+	}
+	return tx
+}
+
+// onRollback registra cómo deshacer un cambio si la transacción se deshace
+func (tx *transaction) onRollback(undo func() error) {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	tx.undo = append(tx.undo, undo)
+}
+
+// onCommit registra fn para ejecutarse al confirmar la transacción, después de
+// aplicar sus ficheros y con los repositorios modificados bloqueados
+func (tx *transaction) onCommit(fn func()) {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	tx.commit = append(tx.commit, fn)
+}
+
+// recovered contiene los directorios de datos cuyo journal ya se recuperó
+var recovered = struct {
+	sync.Mutex
+	dirs map[string]bool
+}{dirs: make(map[string]bool)}
+
+// recoverDataDir recupera el journal de dataDir la primera vez que se abre en el
+// proceso, antes de leer ningún fichero. Las aperturas siguientes no lo tocan: un
+// journal presente entonces es de un Commit en curso en este mismo proceso
+// Si la recuperación falla se vuelve a intentar en la siguiente apertura
+func recoverDataDir(dataDir string) error {
+	dir, err := filepath.Abs(dataDir)
+	if err != nil {
+		return fmt.Errorf("resolving data directory: %w", err)
+	}
+
+	recovered.Lock()
+	defer recovered.Unlock()
+
+	if recovered.dirs[dir] {
+		return nil
+	}
+	if err := recoverJournal(dir); err != nil {
+		return err
+	}
+	recovered.dirs[dir] = true
+	return nil
+}
+
+// recoverJournal aplica el journal de una transacción confirmada cuyos ficheros
+// no llegaron a escribirse por completo
+// Solo se escriben ficheros de dataDir: una entrada con otra ruta invalida el journal
+func recoverJournal(dataDir string) error {
+	path := filepath.Join(dataDir, journalFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading transaction journal: %w", err)
+	}
+
+	var entries []journalEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("decoding transaction journal: %w", err)
+	}
+	for _, entry := range entries {
+		if !filepath.IsLocal(entry.Path) || entry.Path != filepath.Base(entry.Path) {
+			return fmt.Errorf("invalid transaction journal: %q is not a file of %s", entry.Path, dataDir)
+		}
+	}
+	for _, entry := range entries {
+		target := filepath.Join(dataDir, entry.Path)
+		if err := writeFileAtomic(target, entry.Data); err != nil {
+			return fmt.Errorf("recovering %s: %w", target, err)
+		}
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,207 @@
+package file
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// usersFile es el nombre del fichero de usuarios dentro del directorio de datos
+const usersFile = "users.json"
+
+// FileUserRepository implementa UserRepository persistiendo en un fichero JSON
+// Mantiene una copia en memoria para las lecturas y reescribe el fichero
+// de forma atómica en cada modificación
+type FileUserRepository struct {
+	store *store[entities.User]
+	mutex sync.RWMutex
+}
+
+// NewUserRepository crea un repositorio de usuarios respaldado por dataDir/users.json
+func NewUserRepository(dataDir string) (repositories.UserRepository, error) {
+	s, err := openStore(dataDir, usersFile, func(u *entities.User) string { return u.ID })
+	if err != nil {
+		return nil, &repositories.UserRepositoryError{Message: "opening user store", Err: err}
+	}
+	return &FileUserRepository{store: s}, nil
+}
+
+// Save guarda un usuario en el repositorio
+func (r *FileUserRepository) Save(ctx context.Context, user *entities.User) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := r.store.records[user.ID]
+
+	// Verificar la versión (concurrencia optimista)
+	if err := repositories.CheckUserVersion(previous, user); err != nil {
+		return err
+	}
+
+	// Verificar que ningún otro usuario tenga el email; se hace con el mutex
+	// adquirido para que dos altas simultáneas no puedan quedarse con el mismo
+	if other := r.findByEmail(user.Email); other != nil && other.ID != user.ID {
+		return repositories.ErrEmailAlreadyInUse
+	}
+
+	// Crear una copia del usuario para evitar modificaciones externas
+	userCopy := user.Clone()
+	userCopy.Version++
+	r.store.records[user.ID] = userCopy
+
+	if err := r.store.write(ctx, &r.mutex, user.ID, previous, userCopy); err != nil {
+		return &repositories.UserRepositoryError{Message: "saving user", Err: err}
+	}
+
+	user.Version = userCopy.Version
+	return nil
+}
+
+// FindByID busca un usuario por su ID
+func (r *FileUserRepository) FindByID(ctx context.Context, id string) (*entities.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	user, exists := r.store.records[id]
+	if !exists {
+		return nil, nil
+	}
+
+	// Retornar una copia para evitar modificaciones externas
+	userCopy := *user
+	return &userCopy, nil
+}
+
+// FindByEmail busca un usuario por su email
+func (r *FileUserRepository) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	user := r.findByEmail(email)
+	if user == nil {
+		return nil, nil
+	}
+
+	// Retornar una copia para evitar modificaciones externas
+	userCopy := *user
+	return &userCopy, nil
+}
+
+// findByEmail busca el usuario con el email normalizado dado
+// Debe llamarse con el mutex adquirido
+func (r *FileUserRepository) findByEmail(email string) *entities.User {
+	email = entities.NormalizeEmail(email)
+	for _, user := range r.store.records {
+		if entities.NormalizeEmail(user.Email) == email {
+			return user
+		}
+	}
+	return nil
+}
+
+// FindAll retorna todos los usuarios ordenados por ID
+func (r *FileUserRepository) FindAll(ctx context.Context, limit, offset int) ([]*entities.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(*entities.User) bool { return true }, limit, offset), nil
+}
+
+// FindActive retorna todos los usuarios activos ordenados por ID
+func (r *FileUserRepository) FindActive(ctx context.Context, limit, offset int) ([]*entities.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(u *entities.User) bool { return u.IsActive }, limit, offset), nil
+}
+
+// FindMatching retorna los usuarios que cumplen la especificación, ordenados por ID
+func (r *FileUserRepository) FindMatching(ctx context.Context, spec repositories.Specification, limit, offset int) ([]*entities.User, error) {
+	if err := repositories.ValidateUserSpecification(spec); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(u *entities.User) bool { return repositories.MatchUser(spec, u) }, limit, offset), nil
+}
+
+// FindPage retorna una página de los usuarios que cumplen la especificación
+func (r *FileUserRepository) FindPage(ctx context.Context, spec repositories.Specification, request repositories.PageRequest) (repositories.Page[*entities.User], error) {
+	if err := repositories.ValidateUserSpecification(spec); err != nil {
+		return repositories.Page[*entities.User]{}, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var users []*entities.User
+	for _, user := range r.store.records {
+		if repositories.MatchUser(spec, user) {
+			users = append(users, user)
+		}
+	}
+	page, err := repositories.UserPage(users, request)
+	for i, user := range page.Items {
+		page.Items[i] = user.Clone()
+	}
+	return page, err
+}
+
+// Delete elimina un usuario del repositorio
+func (r *FileUserRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.store.records[id]
+	if !exists {
+		return repositories.ErrUserNotFound
+	}
+
+	delete(r.store.records, id)
+	if err := r.store.write(ctx, &r.mutex, id, previous, nil); err != nil {
+		return &repositories.UserRepositoryError{Message: "deleting user", Err: err}
+	}
+	return nil
+}
+
+// Exists verifica si un usuario existe por ID
+func (r *FileUserRepository) Exists(ctx context.Context, id string) (bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, exists := r.store.records[id]
+	return exists, nil
+}
+
+// Count retorna el número total de usuarios
+func (r *FileUserRepository) Count(ctx context.Context) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.store.records), nil
+}
+
+// filter retorna copias de los usuarios que cumplen la condición, ordenados y paginados
+// Debe llamarse con el mutex adquirido
+func (r *FileUserRepository) filter(match func(*entities.User) bool, limit, offset int) []*entities.User {
+	var users []*entities.User
+	for _, user := range r.store.records {
+		if match(user) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	page := paginate(users, limit, offset)
+	result := make([]*entities.User, 0, len(page))
+	for _, user := range page {
+		userCopy := *user
+		result = append(result, &userCopy)
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+// Package outbox contiene la lista de mensajes pendientes que comparten los
+// adaptadores que guardan el outbox en memoria (memory y file)
+// Cada adaptador solo decide dónde la guarda, cómo la protege y cuándo la persiste
+package outbox
+
+import "hexagonal-example/domain/repositories"
+
+// Messages es la lista de mensajes pendientes de un repositorio, en orden de registro
+// No es segura para uso concurrente: el repositorio la protege con su propio mutex
+// Las operaciones nunca modifican el array de una versión anterior, así que un
+// repositorio puede guardar la lista antes de cambiarla y restaurarla si falla
+type Messages []*repositories.OutboxMessage
+
+// Add añade copias de los mensajes al final del outbox
+func (o *Messages) Add(messages []*repositories.OutboxMessage) {
+	added := make(Messages, 0, len(*o)+len(messages))
+	added = append(added, *o...)
+	for _, message := range messages {
+		messageCopy := *message
+		added = append(added, &messageCopy)
+	}
+	*o = added
+}
+
+// Pending retorna copias de los primeros limit mensajes (todos si limit no es positivo)
+func (o Messages) Pending(limit int) []*repositories.OutboxMessage {
+	if limit <= 0 || limit > len(o) {
+		limit = len(o)
+	}
+	result := make([]*repositories.OutboxMessage, 0, limit)
+	for _, message := range o[:limit] {
+		messageCopy := *message
+		result = append(result, &messageCopy)
+	}
+	return result
+}
+
+// Remove retira un mensaje del outbox
+func (o *Messages) Remove(id string) error {
+	for i, message := range *o {
+		if message.ID == id {
+			remaining := make(Messages, 0, len(*o)-1)
+			remaining = append(remaining, (*o)[:i]...)
+			*o = append(remaining, (*o)[i+1:]...)
+			return nil
+		}
+	}
+	return repositories.ErrOutboxMessageNotFound
+}
+
+// Fail anota un intento de publicación fallido de un mensaje
+func (o Messages) Fail(id, reason string) error {
+	for _, message := range o {
+		if message.ID == id {
+			message.Attempts++
+			message.LastError = reason
+			return nil
+		}
+	}
+	return repositories.ErrOutboxMessageNotFound
+}
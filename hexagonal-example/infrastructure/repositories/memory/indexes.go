@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"sort"
+
+	"hexagonal-example/domain/entities"
+)
+
+// Índices secundarios de los repositorios en memoria
+// Convierten en búsquedas directas las consultas que de otro modo recorren todas
+// las entidades. Los repositorios los actualizan en cada escritura (incluidas las
+// que deshace un rollback) con el mutex adquirido, así que no tienen lock propio
+
+// setIndex asocia cada valor de un campo con los IDs de las entidades que lo tienen
+type setIndex map[string]idSet
+
+// add añade id a los IDs de key
+func (x setIndex) add(key, id string) {
+	if x[key] == nil {
+		x[key] = make(idSet)
+	}
+	x[key].add(id)
+}
+
+// remove retira id de los IDs de key
+func (x setIndex) remove(key, id string) {
+	x[key].remove(id)
+	if len(x[key]) == 0 {
+		delete(x, key)
+	}
+}
+
+// ids retorna los IDs de key ordenados
+func (x setIndex) ids(key string) []string {
+	return x[key].sorted()
+}
+
+// uniqueIndex asocia cada valor de un campo único con el ID de la entidad que lo tiene
+type uniqueIndex map[string]string
+
+// add asocia key con id
+func (x uniqueIndex) add(key, id string) {
+	x[key] = id
+}
+
+// remove retira key si pertenece a id
+func (x uniqueIndex) remove(key, id string) {
+	if x[key] == id {
+		delete(x, key)
+	}
+}
+
+// owner retorna el ID de la entidad que tiene key
+func (x uniqueIndex) owner(key string) (string, bool) {
+	id, ok := x[key]
+	return id, ok
+}
+
+// idSet es un conjunto de IDs
+type idSet map[string]struct{}
+
+// add añade un ID al conjunto
+func (s idSet) add(id string) {
+	s[id] = struct{}{}
+}
+
+// remove retira un ID del conjunto
+func (s idSet) remove(id string) {
+	delete(s, id)
+}
+
+// sorted retorna los IDs del conjunto ordenados
+func (s idSet) sorted() []string {
+	ids := make([]string, 0, len(s))
+	for id := range s {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// priceIndex guarda, por moneda, los precios de los productos ordenados de menor a
+// mayor, de modo que un rango de precios se resuelve con una búsqueda binaria
+type priceIndex map[string][]priceEntry
+
+// priceEntry es el precio de un producto en priceIndex
+type priceEntry struct {
+	amount int64
+	id     string
+}
+
+// less indica si e va antes que other en el índice
+func (e priceEntry) less(other priceEntry) bool {
+	if e.amount != other.amount {
+		return e.amount < other.amount
+	}
+	return e.id < other.id
+}
+
+// add añade el precio de un producto
+func (x priceIndex) add(price entities.Money, id string) {
+	entry := priceEntry{amount: price.Amount(), id: id}
+	entries := x[price.Currency()]
+	i := sort.Search(len(entries), func(i int) bool { return !entries[i].less(entry) })
+	entries = append(entries, priceEntry{})
+	copy(entries[i+1:], entries[i:])
+	entries[i] = entry
+	x[price.Currency()] = entries
+}
+
+// remove retira el precio de un producto
+func (x priceIndex) remove(price entities.Money, id string) {
+	entry := priceEntry{amount: price.Amount(), id: id}
+	entries := x[price.Currency()]
+	i := sort.Search(len(entries), func(i int) bool { return !entries[i].less(entry) })
+	if i == len(entries) || entries[i] != entry {
+		return
+	}
+	entries = append(entries[:i], entries[i+1:]...)
+	if len(entries) == 0 {
+		delete(x, price.Currency())
+		return
+	}
+	x[price.Currency()] = entries
+}
+
+// between retorna los IDs de los productos con precio en [minPrice, maxPrice],
+// ordenados por ID. Como Money.Between, los límites deben estar en la moneda del
+// precio: si no coinciden no retorna nada
+func (x priceIndex) between(minPrice, maxPrice entities.Money) []string {
+	if minPrice.Currency() != maxPrice.Currency() {
+		return nil
+	}
+	entries := x[minPrice.Currency()]
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].amount >= minPrice.Amount() })
+
+	var ids []string
+	for ; i < len(entries) && entries[i].amount <= maxPrice.Amount(); i++ {
+		ids = append(ids, entries[i].id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// pageIDs aplica la paginación por desplazamiento a una lista de IDs
+func pageIDs(ids []string, limit, offset int) []string {
+	start := offset
+	end := offset + limit
+	if start >= len(ids) {
+		return nil
+	}
+	if end > len(ids) {
+		end = len(ids)
+	}
+	return ids[start:end]
+}
@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+	"sort"
+	"sync"
+)
+
+// InMemoryOrderRepository implementa OrderRepository usando memoria
+type InMemoryOrderRepository struct {
+	orders map[string]*entities.Order
+	mutex  sync.RWMutex
+}
+
+// NewOrderRepository crea una nueva instancia del repositorio de pedidos en memoria
+func NewOrderRepository() repositories.OrderRepository {
+	return &InMemoryOrderRepository{
+		orders: make(map[string]*entities.Order),
+	}
+}
+
+// Save guarda un pedido en el repositorio
+func (r *InMemoryOrderRepository) Save(ctx context.Context, order *entities.Order) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Verificar la versión (concurrencia optimista)
+	previous := r.orders[order.ID]
+	if err := repositories.CheckOrderVersion(previous, order); err != nil {
+		return err
+	}
+	order.Version++
+
+	// Crear una copia del pedido para evitar modificaciones externas
+	written := order.Clone()
+	r.orders[order.ID] = written
+	undoWrite(ctx, &r.mutex, r.orders, setRecord(r.orders), order.ID, previous, written)
+	return nil
+}
+
+// FindByID busca un pedido por su ID
+func (r *InMemoryOrderRepository) FindByID(ctx context.Context, id string) (*entities.Order, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	order, exists := r.orders[id]
+	if !exists {
+		return nil, nil
+	}
+
+	// Retornar una copia para evitar modificaciones externas
+	return order.Clone(), nil
+}
+
+// FindByUserID retorna los pedidos de un usuario ordenados por ID
+func (r *InMemoryOrderRepository) FindByUserID(ctx context.Context, userID string, limit, offset int) ([]*entities.Order, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(o *entities.Order) bool { return o.UserID == userID }, limit, offset), nil
+}
+
+// FindAll retorna todos los pedidos ordenados por ID
+func (r *InMemoryOrderRepository) FindAll(ctx context.Context, limit, offset int) ([]*entities.Order, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filter(func(*entities.Order) bool { return true }, limit, offset), nil
+}
+
+// Delete elimina un pedido del repositorio
+func (r *InMemoryOrderRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.orders[id]
+	if !exists {
+		return repositories.ErrOrderNotFound
+	}
+
+	delete(r.orders, id)
+	undoWrite(ctx, &r.mutex, r.orders, setRecord(r.orders), id, previous, nil)
+	return nil
+}
+
+// Exists verifica si un pedido existe por ID
+func (r *InMemoryOrderRepository) Exists(ctx context.Context, id string) (bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, exists := r.orders[id]
+	return exists, nil
+}
+
+// Count retorna el número total de pedidos
+func (r *InMemoryOrderRepository) Count(ctx context.Context) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.orders), nil
+}
+
+// filter retorna copias de los pedidos que cumplen la condición, ordenados y paginados
+// Debe llamarse con el mutex adquirido
+func (r *InMemoryOrderRepository) filter(match func(*entities.Order) bool, limit, offset int) []*entities.Order {
+	orders := make([]*entities.Order, 0)
+	for _, order := range r.orders {
+		if match(order) {
+			orders = append(orders, order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+
+	// Aplicar paginación
+	start := offset
+	end := offset + limit
+	if start >= len(orders) {
+		return []*entities.Order{}
+	}
+	if end > len(orders) {
+		end = len(orders)
+	}
+
+	// Retornar copias para evitar modificaciones externas
+	result := make([]*entities.Order, 0, end-start)
+	for i := start; i < end; i++ {
+		result = append(result, orders[i].Clone())
+	}
+	return result
+}
@@ -2,9 +2,10 @@ package memory
 
 import (
 	"context"
-	"errors"
 	"hexagonal-example/domain/entities"
 	"hexagonal-example/domain/repositories"
+	"hexagonal-example/infrastructure/repositories/internal/outbox"
+	"sort"
 	"sync"
 )
 
@@ -12,12 +13,27 @@ import (
 type InMemoryProductRepository struct {
 	products map[string]*entities.Product
 	mutex    sync.RWMutex
+
+	// outbox contiene los mensajes pendientes registrados junto a los productos
+	outbox outbox.Messages
+
+	// Índices secundarios de FindByCategory, FindByPriceRange y FindAvailable
+	byCategory setIndex
+	byPrice    priceIndex
+
+	// available contiene los productos activos con stock físico: los candidatos a
+	// estar disponibles. Las reservas caducan con el tiempo, así que el stock sin
+	// reservar se comprueba al consultar
+	available idSet
 }
 
 // NewProductRepository crea una nueva instancia del repositorio de productos en memoria
 func NewProductRepository() repositories.ProductRepository {
 	return &InMemoryProductRepository{
-		products: make(map[string]*entities.Product),
+		products:   make(map[string]*entities.Product),
+		byCategory: make(setIndex),
+		byPrice:    make(priceIndex),
+		available:  make(idSet),
 	}
 }
 
@@ -26,12 +42,53 @@ func (r *InMemoryProductRepository) Save(ctx context.Context, product *entities.
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	// Verificar la versión (concurrencia optimista)
+	previous := r.products[product.ID]
+	if err := repositories.CheckProductVersion(previous, product); err != nil {
+		return err
+	}
+	messages, err := repositories.ProductOutboxMessages(ctx, product)
+	if err != nil {
+		return err
+	}
+	product.Version++
+
 	// Crear una copia del producto para evitar modificaciones externas
-	productCopy := *product
-	r.products[product.ID] = &productCopy
+	productCopy := product.Clone()
+	r.write(product.ID, productCopy)
+	r.addOutbox(ctx, messages)
+	undoWrite(ctx, &r.mutex, r.products, r.write, product.ID, previous, productCopy)
 	return nil
 }
 
+// Update aplica una modificación de forma atómica bajo el lock de escritura
+func (r *InMemoryProductRepository) Update(ctx context.Context, id string, mutate func(*entities.Product) error) (*entities.Product, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.products[id]
+	if !exists {
+		return nil, repositories.ErrProductNotFound
+	}
+
+	// Modificar una copia para no alterar el estado almacenado si mutate falla
+	product := stored.Clone()
+	if err := mutate(product); err != nil {
+		return nil, err
+	}
+	messages, err := repositories.ProductOutboxMessages(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+	product.Version++
+
+	written := product.Clone()
+	r.write(id, written)
+	r.addOutbox(ctx, messages)
+	undoWrite(ctx, &r.mutex, r.products, r.write, id, stored, written)
+	return product, nil
+}
+
 // FindByID busca un producto por su ID
 func (r *InMemoryProductRepository) FindByID(ctx context.Context, id string) (*entities.Product, error) {
 	r.mutex.RLock()
@@ -43,8 +100,8 @@ func (r *InMemoryProductRepository) FindByID(ctx context.Context, id string) (*e
 	}
 
 	// Retornar una copia para evitar modificaciones externas
-	productCopy := *product
-	return &productCopy, nil
+	productCopy := product.Clone()
+	return productCopy, nil
 }
 
 // FindByName busca productos por nombre
@@ -56,8 +113,8 @@ func (r *InMemoryProductRepository) FindByName(ctx context.Context, name string)
 	for _, product := range r.products {
 		if product.Name == name {
 			// Retornar una copia para evitar modificaciones externas
-			productCopy := *product
-			result = append(result, &productCopy)
+			productCopy := product.Clone()
+			result = append(result, productCopy)
 		}
 	}
 
@@ -69,31 +126,8 @@ func (r *InMemoryProductRepository) FindByCategory(ctx context.Context, category
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	var products []*entities.Product
-	for _, product := range r.products {
-		if product.Category == category {
-			products = append(products, product)
-		}
-	}
-
-	// Aplicar paginación
-	start := offset
-	end := offset + limit
-	if start >= len(products) {
-		return []*entities.Product{}, nil
-	}
-	if end > len(products) {
-		end = len(products)
-	}
-
-	// Retornar copias para evitar modificaciones externas
-	result := make([]*entities.Product, 0, end-start)
-	for i := start; i < end; i++ {
-		productCopy := *products[i]
-		result = append(result, &productCopy)
-	}
-
-	return result, nil
+	// El índice retorna los IDs ordenados, así que las páginas son estables entre llamadas
+	return r.clones(pageIDs(r.byCategory.ids(category), limit, offset)), nil
 }
 
 // FindAll retorna todos los productos
@@ -106,6 +140,9 @@ func (r *InMemoryProductRepository) FindAll(ctx context.Context, limit, offset i
 		products = append(products, product)
 	}
 
+	// Ordenar por ID para que las páginas sean estables entre llamadas
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+
 	// Aplicar paginación
 	start := offset
 	end := offset + limit
@@ -119,8 +156,8 @@ func (r *InMemoryProductRepository) FindAll(ctx context.Context, limit, offset i
 	// Retornar copias para evitar modificaciones externas
 	result := make([]*entities.Product, 0, end-start)
 	for i := start; i < end; i++ {
-		productCopy := *products[i]
-		result = append(result, &productCopy)
+		productCopy := products[i].Clone()
+		result = append(result, productCopy)
 	}
 
 	return result, nil
@@ -131,44 +168,41 @@ func (r *InMemoryProductRepository) FindAvailable(ctx context.Context, limit, of
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	var availableProducts []*entities.Product
-	for _, product := range r.products {
-		if product.IsAvailable() {
-			availableProducts = append(availableProducts, product)
+	// Solo se revisan los candidatos del índice, ya ordenados por ID
+	var availableIDs []string
+	for _, id := range r.available.sorted() {
+		if r.products[id].IsAvailable() {
+			availableIDs = append(availableIDs, id)
 		}
 	}
 
-	// Aplicar paginación
-	start := offset
-	end := offset + limit
-	if start >= len(availableProducts) {
-		return []*entities.Product{}, nil
-	}
-	if end > len(availableProducts) {
-		end = len(availableProducts)
-	}
+	return r.clones(pageIDs(availableIDs, limit, offset)), nil
+}
 
-	// Retornar copias para evitar modificaciones externas
-	result := make([]*entities.Product, 0, end-start)
-	for i := start; i < end; i++ {
-		productCopy := *availableProducts[i]
-		result = append(result, &productCopy)
-	}
+// FindByPriceRange busca productos en un rango de precios
+func (r *InMemoryProductRepository) FindByPriceRange(ctx context.Context, minPrice, maxPrice entities.Money, limit, offset int) ([]*entities.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
 
-	return result, nil
+	return r.clones(pageIDs(r.byPrice.between(minPrice, maxPrice), limit, offset)), nil
 }
 
-// FindByPriceRange busca productos en un rango de precios
-func (r *InMemoryProductRepository) FindByPriceRange(ctx context.Context, minPrice, maxPrice float64, limit, offset int) ([]*entities.Product, error) {
+// FindMatching retorna los productos que cumplen la especificación, ordenados por ID
+func (r *InMemoryProductRepository) FindMatching(ctx context.Context, spec repositories.Specification, limit, offset int) ([]*entities.Product, error) {
+	if err := repositories.ValidateProductSpecification(spec); err != nil {
+		return nil, err
+	}
+
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	var products []*entities.Product
 	for _, product := range r.products {
-		if product.Price >= minPrice && product.Price <= maxPrice {
+		if repositories.MatchProduct(spec, product) {
 			products = append(products, product)
 		}
 	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
 
 	// Aplicar paginación
 	start := offset
@@ -183,23 +217,57 @@ func (r *InMemoryProductRepository) FindByPriceRange(ctx context.Context, minPri
 	// Retornar copias para evitar modificaciones externas
 	result := make([]*entities.Product, 0, end-start)
 	for i := start; i < end; i++ {
-		productCopy := *products[i]
-		result = append(result, &productCopy)
+		result = append(result, products[i].Clone())
 	}
 
 	return result, nil
 }
 
+// FindPage retorna una página de los productos que cumplen la especificación
+func (r *InMemoryProductRepository) FindPage(ctx context.Context, spec repositories.Specification, request repositories.PageRequest) (repositories.Page[*entities.Product], error) {
+	if err := repositories.ValidateProductSpecification(spec); err != nil {
+		return repositories.Page[*entities.Product]{}, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var products []*entities.Product
+	for _, product := range r.products {
+		if repositories.MatchProduct(spec, product) {
+			products = append(products, product)
+		}
+	}
+
+	page, err := repositories.ProductPage(products, request)
+	if err != nil {
+		return page, err
+	}
+
+	// Retornar copias para evitar modificaciones externas
+	for i, product := range page.Items {
+		page.Items[i] = product.Clone()
+	}
+	return page, nil
+}
+
 // Delete elimina un producto del repositorio
 func (r *InMemoryProductRepository) Delete(ctx context.Context, id string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.products[id]; !exists {
-		return errors.New("product not found")
+	product, exists := r.products[id]
+	if !exists {
+		return repositories.ErrProductNotFound
+	}
+	messages, err := repositories.ProductDeleteOutboxMessages(ctx, product)
+	if err != nil {
+		return err
 	}
 
-	delete(r.products, id)
+	r.write(id, nil)
+	r.addOutbox(ctx, messages)
+	undoWrite(ctx, &r.mutex, r.products, r.write, id, product, nil)
 	return nil
 }
 
@@ -225,12 +293,79 @@ func (r *InMemoryProductRepository) CountByCategory(ctx context.Context, categor
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	count := 0
-	for _, product := range r.products {
-		if product.Category == category {
-			count++
-		}
+	return len(r.byCategory[category]), nil
+}
+
+// write guarda product como el producto id, o lo elimina si es nil, y actualiza
+// los índices. Debe llamarse con el mutex adquirido
+func (r *InMemoryProductRepository) write(id string, product *entities.Product) {
+	if previous, exists := r.products[id]; exists {
+		r.byCategory.remove(previous.Category, id)
+		r.byPrice.remove(previous.Price, id)
+		r.available.remove(id)
+	}
+	if product == nil {
+		delete(r.products, id)
+		return
+	}
+	r.products[id] = product
+	r.byCategory.add(product.Category, id)
+	r.byPrice.add(product.Price, id)
+	if product.IsActive && product.Stock > 0 {
+		r.available.add(id)
 	}
+}
+
+// clones retorna copias de los productos indicados, en el mismo orden
+// Debe llamarse con el mutex adquirido
+func (r *InMemoryProductRepository) clones(ids []string) []*entities.Product {
+	// Retornar copias para evitar modificaciones externas
+	result := make([]*entities.Product, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, r.products[id].Clone())
+	}
+	return result
+}
+
+// addOutbox añade mensajes al outbox
+// Dentro de una transacción se añaden al confirmarla, para que el relay no publique
+// eventos de cambios que todavía pueden deshacerse
+// Debe llamarse con el mutex adquirido
+func (r *InMemoryProductRepository) addOutbox(ctx context.Context, messages []*repositories.OutboxMessage) {
+	if len(messages) == 0 {
+		return
+	}
+	deferred := onCommit(ctx, func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		r.outbox.Add(messages)
+	})
+	if !deferred {
+		r.outbox.Add(messages)
+	}
+}
+
+// Pending retorna los mensajes del outbox pendientes de publicar
+func (r *InMemoryProductRepository) Pending(ctx context.Context, limit int) ([]*repositories.OutboxMessage, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.outbox.Pending(limit), nil
+}
+
+// MarkSent retira un mensaje publicado del outbox
+func (r *InMemoryProductRepository) MarkSent(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.outbox.Remove(id)
+}
+
+// MarkFailed anota un intento de publicación fallido
+func (r *InMemoryProductRepository) MarkFailed(ctx context.Context, id string, reason string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 
-	return count, nil
+	return r.outbox.Fail(id, reason)
 }
\ No newline at end of file
@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"hexagonal-example/domain/repositories"
+)
+
+// UnitOfWork implementa repositories.UnitOfWork para los repositorios en memoria
+// Las escrituras se aplican en el momento y la transacción guarda cómo deshacerlas
+// Las transacciones se ejecutan de una en una, pero no están aisladas de las
+// escrituras hechas fuera de ellas: si una de esas escrituras modifica una entidad
+// de la transacción, Rollback no la sobrescribe y retorna ErrRollbackConflict
+type UnitOfWork struct {
+	// active serializa las transacciones: se adquiere en Begin y se libera al terminar
+	active sync.Mutex
+}
+
+// NewUnitOfWork crea la unidad de trabajo de los repositorios en memoria
+func NewUnitOfWork() *UnitOfWork {
+	return &UnitOfWork{}
+}
+
+// transaction es una transacción en curso de los repositorios en memoria
+type transaction struct {
+	repositories.Transaction
+
+	uow *UnitOfWork
+
+	mutex  sync.Mutex
+	undo   []func() error
+	commit []func()
+}
+
+// transactionKey es la clave de contexto de la transacción en memoria
+type transactionKey struct{}
+
+// Begin inicia una transacción; espera a que termine la que esté en curso
+func (u *UnitOfWork) Begin(ctx context.Context) (context.Context, error) {
+	if transactionFrom(ctx) != nil {
+		return ctx, repositories.ErrTransactionInProgress
+	}
+	u.active.Lock()
+
+	tx := &transaction{uow: u}
+	ctx = repositories.WithTransaction(ctx, u, &tx.Transaction)
+	return context.WithValue(ctx, transactionKey{}, tx), nil
+}
+
+// Commit confirma la transacción: las escrituras ya están aplicadas y solo
+// quedan por hacer visibles los mensajes del outbox
+func (u *UnitOfWork) Commit(ctx context.Context) error {
+	tx, err := u.finish(ctx)
+	if err != nil {
+		return err
+	}
+	for _, commit := range tx.commit {
+		commit()
+	}
+	tx.RunAfterCommit(ctx)
+	return nil
+}
+
+// Rollback deshace las escrituras de la transacción en orden inverso
+func (u *UnitOfWork) Rollback(ctx context.Context) error {
+	tx, err := u.finish(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// finish termina la transacción de ctx y deja paso a la siguiente
+func (u *UnitOfWork) finish(ctx context.Context) (*transaction, error) {
+	tx, _ := ctx.Value(transactionKey{}).(*transaction)
+	if tx == nil || tx.uow != u {
+		return nil, repositories.ErrNoTransaction
+	}
+	if err := tx.Finish(); err != nil {
+		return nil, err
+	}
+	u.active.Unlock()
+	return tx, nil
+}
+
+// transactionFrom retorna la transacción en curso de ctx, o nil si no hay ninguna
+func transactionFrom(ctx context.Context) *transaction {
+	tx, _ := ctx.Value(transactionKey{}).(*transaction)
+	if tx == nil || tx.Done() {
+		return nil
+	}
+	return tx
+}
+
+// onRollback registra cómo deshacer una escritura si la transacción de ctx se deshace
+// Sin transacción no hace nada
+func onRollback(ctx context.Context, undo func() error) {
+	tx := transactionFrom(ctx)
+	if tx == nil {
+		return
+	}
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	tx.undo = append(tx.undo, undo)
+}
+
+// onCommit registra fn para ejecutarse al confirmar la transacción de ctx
+// Retorna false si ctx no tiene transacción, en cuyo caso fn no se registra
+func onCommit(ctx context.Context, fn func()) bool {
+	tx := transactionFrom(ctx)
+	if tx == nil {
+		return false
+	}
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	tx.commit = append(tx.commit, fn)
+	return true
+}
+
+// undoWrite registra cómo restaurar records[id] al valor previous tras guardar written
+// (nil si la escritura eliminó la entidad). El valor solo se restaura si nadie lo ha
+// vuelto a escribir fuera de la transacción
+// write es la escritura del repositorio, para que la restauración actualice también
+// sus índices (ver setRecord para los repositorios sin índices)
+func undoWrite[T any](ctx context.Context, mutex *sync.RWMutex, records map[string]*T, write func(id string, value *T), id string, previous, written *T) {
+	onRollback(ctx, func() error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if records[id] != written {
+			return repositories.ErrRollbackConflict
+		}
+		write(id, previous)
+		return nil
+	})
+}
+
+// setRecord retorna la escritura de un repositorio sin índices: asigna records[id]
+// o lo elimina si el valor es nil
+func setRecord[T any](records map[string]*T) func(id string, value *T) {
+	return func(id string, value *T) {
+		if value == nil {
+			delete(records, id)
+		} else {
+			records[id] = value
+		}
+	}
+}
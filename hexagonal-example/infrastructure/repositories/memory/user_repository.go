@@ -2,9 +2,9 @@ package memory
 
 import (
 	"context"
-	"errors"
 	"hexagonal-example/domain/entities"
 	"hexagonal-example/domain/repositories"
+	"sort"
 	"sync"
 )
 
@@ -14,12 +14,17 @@ import (
 type InMemoryUserRepository struct {
 	users map[string]*entities.User
 	mutex sync.RWMutex
+
+	// byEmail indexa los usuarios por email normalizado para que FindByEmail no
+	// recorra el mapa y Save compruebe la unicidad del email
+	byEmail uniqueIndex
 }
 
 // NewUserRepository crea una nueva instancia del repositorio en memoria
 func NewUserRepository() repositories.UserRepository {
 	return &InMemoryUserRepository{
-		users: make(map[string]*entities.User),
+		users:   make(map[string]*entities.User),
+		byEmail: make(uniqueIndex),
 	}
 }
 
@@ -28,9 +33,23 @@ func (r *InMemoryUserRepository) Save(ctx context.Context, user *entities.User)
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	// Verificar la versión (concurrencia optimista)
+	previous := r.users[user.ID]
+	if err := repositories.CheckUserVersion(previous, user); err != nil {
+		return err
+	}
+
+	// Verificar que ningún otro usuario tenga el email; se hace con el mutex
+	// adquirido para que dos altas simultáneas no puedan quedarse con el mismo
+	if owner, taken := r.byEmail.owner(entities.NormalizeEmail(user.Email)); taken && owner != user.ID {
+		return repositories.ErrEmailAlreadyInUse
+	}
+	user.Version++
+
 	// Crear una copia del usuario para evitar modificaciones externas
-	userCopy := *user
-	r.users[user.ID] = &userCopy
+	userCopy := user.Clone()
+	r.write(user.ID, userCopy)
+	undoWrite(ctx, &r.mutex, r.users, r.write, user.ID, previous, userCopy)
 	return nil
 }
 
@@ -54,15 +73,14 @@ func (r *InMemoryUserRepository) FindByEmail(ctx context.Context, email string)
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	for _, user := range r.users {
-		if user.Email == email {
-			// Retornar una copia para evitar modificaciones externas
-			userCopy := *user
-			return &userCopy, nil
-		}
+	id, exists := r.byEmail.owner(entities.NormalizeEmail(email))
+	if !exists {
+		return nil, nil
 	}
 
-	return nil, nil
+	// Retornar una copia para evitar modificaciones externas
+	userCopy := *r.users[id]
+	return &userCopy, nil
 }
 
 // FindAll retorna todos los usuarios
@@ -75,6 +93,9 @@ func (r *InMemoryUserRepository) FindAll(ctx context.Context, limit, offset int)
 		users = append(users, user)
 	}
 
+	// Ordenar por ID para que las páginas sean estables entre llamadas
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
 	// Aplicar paginación
 	start := offset
 	end := offset + limit
@@ -95,6 +116,72 @@ func (r *InMemoryUserRepository) FindAll(ctx context.Context, limit, offset int)
 	return result, nil
 }
 
+// FindMatching retorna los usuarios que cumplen la especificación, ordenados por ID
+func (r *InMemoryUserRepository) FindMatching(ctx context.Context, spec repositories.Specification, limit, offset int) ([]*entities.User, error) {
+	if err := repositories.ValidateUserSpecification(spec); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var users []*entities.User
+	for _, user := range r.users {
+		if repositories.MatchUser(spec, user) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	// Aplicar paginación
+	start := offset
+	end := offset + limit
+	if start >= len(users) {
+		return []*entities.User{}, nil
+	}
+	if end > len(users) {
+		end = len(users)
+	}
+
+	// Retornar copias para evitar modificaciones externas
+	result := make([]*entities.User, 0, end-start)
+	for i := start; i < end; i++ {
+		userCopy := *users[i]
+		result = append(result, &userCopy)
+	}
+
+	return result, nil
+}
+
+// FindPage retorna una página de los usuarios que cumplen la especificación
+func (r *InMemoryUserRepository) FindPage(ctx context.Context, spec repositories.Specification, request repositories.PageRequest) (repositories.Page[*entities.User], error) {
+	if err := repositories.ValidateUserSpecification(spec); err != nil {
+		return repositories.Page[*entities.User]{}, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var users []*entities.User
+	for _, user := range r.users {
+		if repositories.MatchUser(spec, user) {
+			users = append(users, user)
+		}
+	}
+
+	page, err := repositories.UserPage(users, request)
+	if err != nil {
+		return page, err
+	}
+
+	// Retornar copias para evitar modificaciones externas
+	for i, user := range page.Items {
+		userCopy := *user
+		page.Items[i] = &userCopy
+	}
+	return page, nil
+}
+
 // FindActive retorna todos los usuarios activos
 func (r *InMemoryUserRepository) FindActive(ctx context.Context, limit, offset int) ([]*entities.User, error) {
 	r.mutex.RLock()
@@ -107,6 +194,9 @@ func (r *InMemoryUserRepository) FindActive(ctx context.Context, limit, offset i
 		}
 	}
 
+	// Ordenar por ID para que las páginas sean estables entre llamadas
+	sort.Slice(activeUsers, func(i, j int) bool { return activeUsers[i].ID < activeUsers[j].ID })
+
 	// Aplicar paginación
 	start := offset
 	end := offset + limit
@@ -132,11 +222,13 @@ func (r *InMemoryUserRepository) Delete(ctx context.Context, id string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.users[id]; !exists {
-		return errors.New("user not found")
+	previous, exists := r.users[id]
+	if !exists {
+		return repositories.ErrUserNotFound
 	}
 
-	delete(r.users, id)
+	r.write(id, nil)
+	undoWrite(ctx, &r.mutex, r.users, r.write, id, previous, nil)
 	return nil
 }
 
@@ -155,4 +247,18 @@ func (r *InMemoryUserRepository) Count(ctx context.Context) (int, error) {
 	defer r.mutex.RUnlock()
 
 	return len(r.users), nil
+}
+
+// write guarda user como el usuario id, o lo elimina si es nil, y actualiza el índice
+// Debe llamarse con el mutex adquirido
+func (r *InMemoryUserRepository) write(id string, user *entities.User) {
+	if previous, exists := r.users[id]; exists {
+		r.byEmail.remove(entities.NormalizeEmail(previous.Email), id)
+	}
+	if user == nil {
+		delete(r.users, id)
+		return
+	}
+	r.users[id] = user
+	r.byEmail.add(entities.NormalizeEmail(user.Email), id)
 }
\ No newline at end of file
@@ -0,0 +1,151 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect encapsula las diferencias de SQL entre los motores soportados
+// Las consultas del adaptador se escriben con placeholders "?" y el dialecto
+// las reescribe al formato del motor (por ejemplo "$1" en Postgres)
+type Dialect struct {
+	// Name identifica el dialecto
+	Name string
+
+	// numberedPlaceholders indica si el motor usa $1, $2... en lugar de ?
+	numberedPlaceholders bool
+
+	// binaryCollation es la colación que compara el texto byte a byte, como
+	// strings.Compare, sin depender de la configuración regional de la base de datos
+	binaryCollation string
+}
+
+// Dialectos soportados
+var (
+	SQLite   = Dialect{Name: "sqlite", binaryCollation: "BINARY"}
+	Postgres = Dialect{Name: "postgres", numberedPlaceholders: true, binaryCollation: `"C"`}
+)
+
+// DialectFor retorna el dialecto correspondiente a un nombre de driver de database/sql
+func DialectFor(driverName string) (Dialect, error) {
+	switch driverName {
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	case "postgres", "pgx":
+		return Postgres, nil
+	default:
+		return Dialect{}, fmt.Errorf("unsupported SQL driver %q", driverName)
+	}
+}
+
+// rebind reescribe los placeholders "?" de la consulta al formato del dialecto
+// Los "?" dentro de literales ('...') e identificadores entre comillas ("...") no
+// son placeholders y se conservan; una comilla duplicada dentro de un literal
+// lo cierra y lo vuelve a abrir, así que no necesita tratamiento especial
+func (d Dialect) rebind(query string) string {
+	if !d.numberedPlaceholders {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sqlStateError es implementado por los errores de drivers que exponen el SQLSTATE (pgx, pq)
+type sqlStateError interface {
+	SQLState() string
+}
+
+// uniqueViolationState es el SQLSTATE estándar de violación de restricción única
+const uniqueViolationState = "23505"
+
+// sqliteCodeError es implementado por los errores de drivers de SQLite que exponen
+// el código extendido del motor (modernc.org/sqlite)
+type sqliteCodeError interface {
+	Code() int
+}
+
+// Códigos extendidos de SQLite de violación de restricción única
+const (
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+)
+
+// sqliteUniqueFailed precede a las columnas de la restricción en los mensajes de SQLite
+const sqliteUniqueFailed = "UNIQUE constraint failed: "
+
+// uniqueConstraint identifica una restricción única en los errores de los motores
+// PostgreSQL nombra la restricción y SQLite sus columnas ("tabla.columna")
+type uniqueConstraint struct {
+	name    string
+	columns []string
+}
+
+// Restricciones únicas que el adaptador traduce a errores de dominio
+// El nombre es el que PostgreSQL asigna a la restricción UNIQUE de la migración 1
+var usersEmailUnique = uniqueConstraint{name: "users_email_key", columns: []string{"users.email"}}
+
+// isUniqueViolation detecta violaciones de restricciones únicas sin depender
+// de un driver concreto: primero por el código del driver (SQLSTATE o código
+// extendido de SQLite) y, si no lo expone, por el mensaje del motor
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var stateErr sqlStateError
+	if errors.As(err, &stateErr) {
+		return stateErr.SQLState() == uniqueViolationState
+	}
+	var codeErr sqliteCodeError
+	if errors.As(err, &codeErr) {
+		return codeErr.Code() == sqliteConstraintUnique || codeErr.Code() == sqliteConstraintPrimaryKey
+	}
+
+	message := err.Error()
+	return strings.Contains(message, sqliteUniqueFailed) ||
+		strings.Contains(message, "duplicate key value violates unique constraint")
+}
+
+// isUniqueViolationOn detecta violaciones de una restricción única concreta
+// Los drivers no exponen la restricción de forma portable, así que se busca en el
+// mensaje su nombre exacto entre comillas (PostgreSQL) o la lista exacta de sus
+// columnas (SQLite), nunca un fragmento que pueda aparecer en otra restricción
+func isUniqueViolationOn(err error, constraint uniqueConstraint) bool {
+	if !isUniqueViolation(err) {
+		return false
+	}
+
+	message := err.Error()
+	if strings.Contains(message, `"`+constraint.name+`"`) {
+		return true
+	}
+	_, columns, found := strings.Cut(message, sqliteUniqueFailed)
+	if !found {
+		return false
+	}
+	// modernc.org/sqlite añade el código tras la lista: "users.email (2067)"
+	columns, _, _ = strings.Cut(columns, " (")
+	return columns == strings.Join(constraint.columns, ", ")
+}
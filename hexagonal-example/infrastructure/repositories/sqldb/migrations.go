@@ -0,0 +1,335 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"hexagonal-example/domain/entities"
+)
+
+// migration representa un cambio versionado del esquema
+type migration struct {
+	version    int
+	statements []string
+
+	// migrate se ejecuta después de statements, en la misma transacción, para los
+	// cambios de datos que no se pueden expresar en SQL portable; puede ser nil
+	migrate func(ctx context.Context, tx *sql.Tx, dialect Dialect) error
+}
+
+// legacyCurrency es la moneda de los precios guardados antes de la migración 5 en
+// la columna price: hasta entonces la aplicación solo manejaba dólares, así que
+// se asume USD aunque DefaultCurrency cambie
+const legacyCurrency = "USD"
+
+// legacyMinorUnits es el número de unidades menores de una unidad de legacyCurrency
+// (10 elevado a su exponente ISO 4217), usado para convertir la columna price
+var legacyMinorUnits = entities.MustParseMoney("1", legacyCurrency).Amount()
+
+// migrations contiene todas las migraciones en orden de versión
+// Nunca se modifica una migración ya publicada: los cambios se añaden como versiones nuevas
+var migrations = []migration{
+	{
+		version: 1,
+		statements: []string{
+			`CREATE TABLE users (
+				id         TEXT PRIMARY KEY,
+				email      TEXT NOT NULL UNIQUE,
+				name       TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL,
+				is_active  BOOLEAN NOT NULL
+			)`,
+			`CREATE INDEX idx_users_is_active ON users (is_active)`,
+			`CREATE TABLE products (
+				id          TEXT PRIMARY KEY,
+				name        TEXT NOT NULL,
+				description TEXT NOT NULL,
+				price       DOUBLE PRECISION NOT NULL,
+				stock       INTEGER NOT NULL,
+				category    TEXT NOT NULL,
+				created_at  TIMESTAMP NOT NULL,
+				updated_at  TIMESTAMP NOT NULL,
+				is_active   BOOLEAN NOT NULL
+			)`,
+			`CREATE INDEX idx_products_name ON products (name)`,
+			`CREATE INDEX idx_products_category ON products (category)`,
+			`CREATE INDEX idx_products_price ON products (price)`,
+			`CREATE INDEX idx_products_available ON products (is_active, stock)`,
+		},
+	},
+	{
+		// Versión de las entidades para el control de concurrencia optimista
+		version: 2,
+		statements: []string{
+			`ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE products ADD COLUMN version INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		// Reservas de stock: el detalle se guarda como JSON y reserved_stock
+		// permite filtrar por stock disponible sin decodificarlo
+		version: 3,
+		statements: []string{
+			`ALTER TABLE products ADD COLUMN reserved_stock INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE products ADD COLUMN reservations TEXT NOT NULL DEFAULT '[]'`,
+		},
+	},
+	{
+		// Pedidos: las líneas se guardan como JSON junto a la raíz del agregado
+		version: 4,
+		statements: []string{
+			`CREATE TABLE orders (
+				id         TEXT PRIMARY KEY,
+				user_id    TEXT NOT NULL,
+				status     TEXT NOT NULL,
+				items      TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL,
+				version    INTEGER NOT NULL DEFAULT 0
+			)`,
+			`CREATE INDEX idx_orders_user_id ON orders (user_id)`,
+		},
+	},
+	{
+		// Precios exactos: unidades menores y moneda ISO 4217
+		// Los precios existentes se convierten desde la columna price, que está en
+		// legacyCurrency, con el exponente de esa moneda
+		version: 5,
+		statements: []string{
+			`ALTER TABLE products ADD COLUMN price_minor BIGINT NOT NULL DEFAULT 0`,
+			`ALTER TABLE products ADD COLUMN currency TEXT NOT NULL DEFAULT '` + legacyCurrency + `'`,
+			fmt.Sprintf(`UPDATE products SET price_minor = CAST(ROUND(price * %d) AS BIGINT)`, legacyMinorUnits),
+			`CREATE INDEX idx_products_currency_price ON products (currency, price_minor)`,
+			`ALTER TABLE orders ADD COLUMN currency TEXT NOT NULL DEFAULT '` + legacyCurrency + `'`,
+		},
+	},
+	{
+		// Outbox: eventos pendientes de publicar, escritos en la misma transacción
+		// que el cambio que los produce; sent_at es NULL mientras están pendientes
+		version: 6,
+		statements: []string{
+			`CREATE TABLE outbox (
+				id           TEXT PRIMARY KEY,
+				event_type   TEXT NOT NULL,
+				aggregate_id TEXT NOT NULL,
+				payload      TEXT NOT NULL,
+				created_at   TIMESTAMP NOT NULL,
+				attempts     INTEGER NOT NULL DEFAULT 0,
+				last_error   TEXT NOT NULL DEFAULT '',
+				sent_at      TIMESTAMP
+			)`,
+			`CREATE INDEX idx_outbox_pending ON outbox (sent_at, created_at)`,
+		},
+	},
+	{
+		// Los emails se guardan normalizados para que la restricción UNIQUE no
+		// admita el mismo email con otras mayúsculas. Si ya hay duplicados la
+		// migración falla y deben resolverse a mano antes de aplicarla
+		version: 7,
+		statements: []string{
+			`UPDATE users SET email = LOWER(TRIM(email))`,
+		},
+	},
+	{
+		// Reservas de stock en su propia tabla, para que las consultas por stock
+		// disponible descuenten solo las reservas vigentes: reserved_stock incluye
+		// las expiradas hasta que se limpian. Las reservas existentes se copian
+		// desde la columna reservations
+		version: 8,
+		statements: []string{
+			`CREATE TABLE stock_reservations (
+				product_id TEXT NOT NULL,
+				id         TEXT NOT NULL,
+				quantity   INTEGER NOT NULL,
+				expires_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (product_id, id)
+			)`,
+			`CREATE INDEX idx_stock_reservations_expires_at ON stock_reservations (product_id, expires_at)`,
+		},
+		migrate: copyReservations,
+	},
+	{
+		// La columna price, aproximada y sin moneda, ya no se escribe desde la
+		// migración 5; el precio está solo en price_minor y currency
+		version: 9,
+		statements: []string{
+			`DROP INDEX idx_products_price`,
+			`ALTER TABLE products DROP COLUMN price`,
+		},
+	},
+	{
+		// Columnas de texto plegadas con fold para los filtros OpContains: LOWER
+		// solo pliega ASCII en SQLite. Los valores existentes se pliegan en Go
+		version: 10,
+		statements: []string{
+			`ALTER TABLE products ADD COLUMN id_folded TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE products ADD COLUMN name_folded TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE products ADD COLUMN category_folded TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE users ADD COLUMN id_folded TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE users ADD COLUMN name_folded TEXT NOT NULL DEFAULT ''`,
+		},
+		migrate: foldTextColumns,
+	},
+}
+
+// Migrate aplica las migraciones pendientes en orden
+// Cada migración se ejecuta en su propia transacción junto con el registro
+// de su versión, de modo que una migración fallida no deja el esquema a medias
+func Migrate(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	current, err := currentVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, db, dialect, m); err != nil {
+			return fmt.Errorf("applying migration %d: %w", m.version, err)
+		}
+	}
+	return nil
+}
+
+// currentVersion retorna la última versión aplicada (0 si ninguna)
+func currentVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// applyMigration ejecuta una migración y registra su versión en una transacción
+func applyMigration(ctx context.Context, db *sql.DB, dialect Dialect, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range m.statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	if m.migrate != nil {
+		if err := m.migrate(ctx, tx, dialect); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		dialect.rebind(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`),
+		m.version, time.Now().UTC(),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// copyReservations copia las reservas de la columna reservations a stock_reservations
+// Los productos se leen completos antes de insertar porque algunos drivers no
+// admiten otra consulta en la conexión mientras hay filas abiertas
+func copyReservations(ctx context.Context, tx *sql.Tx, dialect Dialect) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, reservations FROM products`)
+	if err != nil {
+		return err
+	}
+	reservations := make(map[string][]entities.StockReservation)
+	for rows.Next() {
+		var id, encoded string
+		if err := rows.Scan(&id, &encoded); err != nil {
+			rows.Close()
+			return err
+		}
+		var decoded []entities.StockReservation
+		if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+			rows.Close()
+			return fmt.Errorf("decoding reservations of product %s: %w", id, err)
+		}
+		reservations[id] = decoded
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, productReservations := range reservations {
+		if err := insertReservations(ctx, tx, dialect, id, productReservations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// foldTextColumns rellena las columnas *_folded de las filas existentes
+// Como en copyReservations, las filas se leen completas antes de actualizar
+func foldTextColumns(ctx context.Context, tx *sql.Tx, dialect Dialect) error {
+	products, err := readTextRows(ctx, tx, `SELECT id, name, category FROM products`)
+	if err != nil {
+		return err
+	}
+	for _, row := range products {
+		if _, err := tx.ExecContext(ctx, dialect.rebind(`
+			UPDATE products SET id_folded = ?, name_folded = ?, category_folded = ? WHERE id = ?`),
+			fold(row[0]), fold(row[1]), fold(row[2]), row[0],
+		); err != nil {
+			return err
+		}
+	}
+
+	users, err := readTextRows(ctx, tx, `SELECT id, name FROM users`)
+	if err != nil {
+		return err
+	}
+	for _, row := range users {
+		if _, err := tx.ExecContext(ctx, dialect.rebind(`
+			UPDATE users SET id_folded = ?, name_folded = ? WHERE id = ?`),
+			fold(row[0]), fold(row[1]), row[0],
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTextRows lee todas las filas de una consulta cuyas columnas son de texto
+func readTextRows(ctx context.Context, tx *sql.Tx, query string) ([][]string, error) {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result [][]string
+	for rows.Next() {
+		row := make([]string, len(columns))
+		targets := make([]interface{}, len(row))
+		for i := range row {
+			targets[i] = &row[i]
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,39 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+)
+
+// Open abre la base de datos, detecta el dialecto a partir del driver y aplica
+// las migraciones pendientes
+//
+// El driver no se importa desde este paquete: el binario que lo use debe
+// registrarlo con un import en blanco (por ejemplo _ "modernc.org/sqlite"
+// o _ "github.com/jackc/pgx/v5/stdlib"). cmd/hexagonal registra solo SQLite
+func Open(ctx context.Context, driverName, dsn string) (*sql.DB, Dialect, error) {
+	dialect, err := DialectFor(driverName)
+	if err != nil {
+		return nil, Dialect{}, err
+	}
+	if !slices.Contains(sql.Drivers(), driverName) {
+		return nil, Dialect{}, fmt.Errorf("SQL driver %q is not registered in this binary", driverName)
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, Dialect{}, fmt.Errorf("opening database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, Dialect{}, fmt.Errorf("connecting to database: %w", err)
+	}
+
+	if err := Migrate(ctx, db, dialect); err != nil {
+		db.Close()
+		return nil, Dialect{}, err
+	}
+	return db, dialect, nil
+}
@@ -0,0 +1,193 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// orderColumns es la lista de columnas usada en todas las consultas de pedidos
+const orderColumns = `id, user_id, status, items, created_at, updated_at, version, currency`
+
+// SQLOrderRepository implementa OrderRepository sobre database/sql
+// Las líneas del pedido se guardan como JSON en la columna items: siempre se
+// leen y escriben junto con el pedido, que es la raíz del agregado
+// Los precios de las líneas están en la moneda de la columna currency
+type SQLOrderRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewOrderRepository crea un repositorio de pedidos sobre una base de datos ya migrada
+func NewOrderRepository(db *sql.DB, dialect Dialect) repositories.OrderRepository {
+	return &SQLOrderRepository{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+// Save guarda un pedido en el repositorio
+// Los pedidos nuevos (Version 0) se insertan; el resto se actualiza solo si la
+// versión almacenada coincide
+func (r *SQLOrderRepository) Save(ctx context.Context, order *entities.Order) error {
+	items, err := json.Marshal(order.Items)
+	if err != nil {
+		return &repositories.OrderRepositoryError{Message: "saving order", Err: err}
+	}
+
+	if order.Version == 0 {
+		_, err := connFor(ctx, r.db).ExecContext(ctx, r.dialect.rebind(`
+			INSERT INTO orders (`+orderColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+			order.ID, order.UserID, string(order.Status), string(items),
+			order.CreatedAt, order.UpdatedAt, 1, order.Currency(),
+		)
+		if isUniqueViolation(err) {
+			return repositories.ErrOrderAlreadyExists
+		}
+		if err != nil {
+			return &repositories.OrderRepositoryError{Message: "saving order", Err: err}
+		}
+		order.Version = 1
+		return nil
+	}
+
+	result, err := connFor(ctx, r.db).ExecContext(ctx, r.dialect.rebind(`
+		UPDATE orders SET user_id = ?, status = ?, items = ?, updated_at = ?, currency = ?, version = version + 1
+		WHERE id = ? AND version = ?`),
+		order.UserID, string(order.Status), string(items), order.UpdatedAt, order.Currency(),
+		order.ID, order.Version,
+	)
+	if err != nil {
+		return &repositories.OrderRepositoryError{Message: "saving order", Err: err}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return &repositories.OrderRepositoryError{Message: "saving order", Err: err}
+	}
+	if affected == 0 {
+		return repositories.ErrOrderVersionConflict
+	}
+	order.Version++
+	return nil
+}
+
+// FindByID busca un pedido por su ID
+func (r *SQLOrderRepository) FindByID(ctx context.Context, id string) (*entities.Order, error) {
+	row := connFor(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(`SELECT `+orderColumns+` FROM orders WHERE id = ?`), id)
+	order, err := scanOrder(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &repositories.OrderRepositoryError{Message: "finding order", Err: err}
+	}
+	return order, nil
+}
+
+// FindByUserID retorna los pedidos de un usuario ordenados por ID
+func (r *SQLOrderRepository) FindByUserID(ctx context.Context, userID string, limit, offset int) ([]*entities.Order, error) {
+	return r.query(ctx, `SELECT `+orderColumns+` FROM orders WHERE user_id = ? ORDER BY id LIMIT ? OFFSET ?`, userID, limit, offset)
+}
+
+// FindAll retorna todos los pedidos ordenados por ID
+func (r *SQLOrderRepository) FindAll(ctx context.Context, limit, offset int) ([]*entities.Order, error) {
+	return r.query(ctx, `SELECT `+orderColumns+` FROM orders ORDER BY id LIMIT ? OFFSET ?`, limit, offset)
+}
+
+// Delete elimina un pedido del repositorio
+func (r *SQLOrderRepository) Delete(ctx context.Context, id string) error {
+	result, err := connFor(ctx, r.db).ExecContext(ctx, r.dialect.rebind(`DELETE FROM orders WHERE id = ?`), id)
+	if err != nil {
+		return &repositories.OrderRepositoryError{Message: "deleting order", Err: err}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return &repositories.OrderRepositoryError{Message: "deleting order", Err: err}
+	}
+	if affected == 0 {
+		return repositories.ErrOrderNotFound
+	}
+	return nil
+}
+
+// Exists verifica si un pedido existe por ID
+func (r *SQLOrderRepository) Exists(ctx context.Context, id string) (bool, error) {
+	var exists int
+	err := connFor(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(`SELECT 1 FROM orders WHERE id = ?`), id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, &repositories.OrderRepositoryError{Message: "checking order", Err: err}
+	}
+	return true, nil
+}
+
+// Count retorna el número total de pedidos
+func (r *SQLOrderRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := connFor(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM orders`).Scan(&count); err != nil {
+		return 0, &repositories.OrderRepositoryError{Message: "counting orders", Err: err}
+	}
+	return count, nil
+}
+
+// query ejecuta una consulta que retorna múltiples pedidos
+func (r *SQLOrderRepository) query(ctx context.Context, query string, args ...interface{}) ([]*entities.Order, error) {
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, &repositories.OrderRepositoryError{Message: "querying orders", Err: err}
+	}
+	defer rows.Close()
+
+	orders := []*entities.Order{}
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, &repositories.OrderRepositoryError{Message: "scanning order", Err: err}
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &repositories.OrderRepositoryError{Message: "querying orders", Err: err}
+	}
+	return orders, nil
+}
+
+// scanOrder lee un pedido de la fila actual
+func scanOrder(s scanner) (*entities.Order, error) {
+	var order entities.Order
+	var status, items, currency string
+	if err := s.Scan(
+		&order.ID, &order.UserID, &status, &items,
+		&order.CreatedAt, &order.UpdatedAt, &order.Version, &currency,
+	); err != nil {
+		return nil, err
+	}
+	order.Status = entities.OrderStatus(status)
+
+	var rows []struct {
+		ProductID string      `json:"product_id"`
+		Quantity  int         `json:"quantity"`
+		UnitPrice json.Number `json:"unit_price"`
+	}
+	if err := json.Unmarshal([]byte(items), &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		unitPrice, err := entities.ParseMoney(row.UnitPrice.String(), currency)
+		if err != nil {
+			return nil, err
+		}
+		order.Items = append(order.Items, entities.OrderItem{
+			ProductID: row.ProductID,
+			Quantity:  row.Quantity,
+			UnitPrice: unitPrice,
+		})
+	}
+	return &order, nil
+}
@@ -0,0 +1,117 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hexagonal-example/domain/repositories"
+)
+
+// outboxColumns es la lista de columnas usada al leer mensajes del outbox
+const outboxColumns = `id, event_type, aggregate_id, payload, created_at, attempts, last_error`
+
+// execer es la parte común de *sql.DB y *sql.Tx que usan las escrituras
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// writeWithOutbox ejecuta write y guarda los mensajes del outbox en la misma transacción
+// Si ctx lleva una transacción de UnitOfWork se usa esa; si no, se abre una, ya que
+// write puede ejecutar varias sentencias (el producto y sus reservas)
+func writeWithOutbox(ctx context.Context, db *sql.DB, dialect Dialect, messages []*repositories.OutboxMessage, write func(execer) error) error {
+	if tx := transactionFrom(ctx, db); tx != nil {
+		if err := write(tx); err != nil {
+			return err
+		}
+		return insertOutbox(ctx, tx, dialect, messages)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := write(tx); err != nil {
+		return err
+	}
+	if err := insertOutbox(ctx, tx, dialect, messages); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertOutbox guarda los mensajes del outbox con la conexión indicada
+func insertOutbox(ctx context.Context, db execer, dialect Dialect, messages []*repositories.OutboxMessage) error {
+	for _, message := range messages {
+		if _, err := db.ExecContext(ctx, dialect.rebind(`
+			INSERT INTO outbox (`+outboxColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+			message.ID, message.EventType, message.AggregateID, string(message.Payload),
+			message.CreatedAt, message.Attempts, message.LastError,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingOutbox lee los mensajes pendientes del outbox en orden de registro
+func pendingOutbox(ctx context.Context, db *sql.DB, dialect Dialect, limit int) ([]*repositories.OutboxMessage, error) {
+	query := `SELECT ` + outboxColumns + ` FROM outbox WHERE sent_at IS NULL ORDER BY created_at, id`
+	args := []interface{}{}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := db.QueryContext(ctx, dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*repositories.OutboxMessage{}
+	for rows.Next() {
+		var message repositories.OutboxMessage
+		var payload string
+		if err := rows.Scan(
+			&message.ID, &message.EventType, &message.AggregateID, &payload,
+			&message.CreatedAt, &message.Attempts, &message.LastError,
+		); err != nil {
+			return nil, err
+		}
+		message.Payload = []byte(payload)
+		messages = append(messages, &message)
+	}
+	return messages, rows.Err()
+}
+
+// markOutboxSent marca un mensaje pendiente como publicado
+func markOutboxSent(ctx context.Context, db *sql.DB, dialect Dialect, id string) error {
+	result, err := db.ExecContext(ctx, dialect.rebind(`UPDATE outbox SET sent_at = ? WHERE id = ? AND sent_at IS NULL`),
+		time.Now().UTC(), id)
+	return outboxAffected(result, err)
+}
+
+// markOutboxFailed anota un intento fallido de publicar un mensaje pendiente
+func markOutboxFailed(ctx context.Context, db *sql.DB, dialect Dialect, id, reason string) error {
+	result, err := db.ExecContext(ctx, dialect.rebind(`UPDATE outbox SET attempts = attempts + 1, last_error = ? WHERE id = ? AND sent_at IS NULL`),
+		reason, id)
+	return outboxAffected(result, err)
+}
+
+// outboxAffected traduce a ErrOutboxMessageNotFound una actualización que no afectó a ninguna fila
+func outboxAffected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repositories.ErrOutboxMessageNotFound
+	}
+	return nil
+}
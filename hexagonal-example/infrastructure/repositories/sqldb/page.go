@@ -0,0 +1,118 @@
+package sqldb
+
+import (
+	"strconv"
+	"strings"
+
+	"hexagonal-example/domain/repositories"
+)
+
+// sortColumns son las columnas por las que se ordena cada campo, de la más a la
+// menos significativa. Todas terminan en id para que el orden sea total, igual que
+// repositories.CompareCursorKeys
+var sortColumns = map[repositories.SortField][]sortColumn{
+	repositories.SortByID:        {{name: "id", text: true}},
+	repositories.SortByName:      {{name: "name", text: true}, {name: "id", text: true}},
+	repositories.SortByPrice:     {{name: "currency", text: true}, {name: "price_minor"}, {name: "id", text: true}},
+	repositories.SortByCreatedAt: {{name: "created_at"}, {name: "id", text: true}},
+}
+
+// sortColumn es una columna de orden
+// Las columnas de texto se ordenan y comparan con la colación binaria del dialecto
+// para coincidir con strings.Compare; con la colación por defecto el orden depende
+// del motor y de su configuración regional (en Postgres "a" < "B" con en_US y no
+// con C), y el cursor saltaría o repetiría filas. created_at es una marca de tiempo
+// y se ordena cronológicamente sin colación
+type sortColumn struct {
+	name string
+	text bool
+}
+
+// expr retorna la expresión de la columna para ORDER BY y las comparaciones
+func (c sortColumn) expr(dialect Dialect) string {
+	if c.text {
+		return c.name + " COLLATE " + dialect.binaryCollation
+	}
+	return c.name
+}
+
+// pageQuery es la parte de una consulta de página que depende de la petición
+type pageQuery struct {
+	// after es la condición que selecciona las filas posteriores al cursor
+	after     string
+	afterArgs []interface{}
+
+	// orderBy es la cláusula ORDER BY completa, con LIMIT si la petición lo tiene
+	orderBy string
+}
+
+// newPageQuery traduce una petición de página ya validada al SQL del dialecto
+// Se pide una fila más que el límite para saber si hay página siguiente
+func newPageQuery(request repositories.PageRequest, dialect Dialect) (pageQuery, error) {
+	after, err := repositories.DecodeCursor(request)
+	if err != nil {
+		return pageQuery{}, err
+	}
+
+	field := request.Sort.Field
+	if field == "" {
+		field = repositories.SortByID
+	}
+	columns := make([]string, len(sortColumns[field]))
+	for i, column := range sortColumns[field] {
+		columns[i] = column.expr(dialect)
+	}
+	direction, comparison := "", " > ?"
+	if request.Sort.Descending {
+		direction, comparison = " DESC", " < ?"
+	}
+
+	query := pageQuery{after: "1 = 1"}
+	ordered := make([]string, len(columns))
+	for i, column := range columns {
+		ordered[i] = column + direction
+	}
+	query.orderBy = " ORDER BY " + strings.Join(ordered, ", ")
+	if request.Limit > 0 {
+		query.orderBy += " LIMIT " + strconv.Itoa(request.Limit+1)
+	}
+
+	if after != nil {
+		// (c1 > ?) OR (c1 = ? AND c2 > ?) OR ...
+		values := cursorValues(field, *after)
+		alternatives := make([]string, len(columns))
+		for i := range columns {
+			parts := make([]string, 0, i+1)
+			for j := 0; j < i; j++ {
+				parts = append(parts, columns[j]+" = ?")
+				query.afterArgs = append(query.afterArgs, values[j])
+			}
+			parts = append(parts, columns[i]+comparison)
+			query.afterArgs = append(query.afterArgs, values[i])
+			alternatives[i] = "(" + strings.Join(parts, " AND ") + ")"
+		}
+		query.after = strings.Join(alternatives, " OR ")
+	}
+	return query, nil
+}
+
+// cursorValues retorna los valores de una posición en el orden de sortColumns
+func cursorValues(field repositories.SortField, key repositories.CursorKey) []interface{} {
+	switch field {
+	case repositories.SortByName:
+		return []interface{}{key.Name, key.ID}
+	case repositories.SortByPrice:
+		return []interface{}{key.Currency, key.Amount, key.ID}
+	case repositories.SortByCreatedAt:
+		return []interface{}{key.CreatedAt, key.ID}
+	}
+	return []interface{}{key.ID}
+}
+
+// trimPage recorta la fila de más que pidió newPageQuery y retorna si la había
+func trimPage[T any](items []T, limit int) ([]T, bool) {
+	if limit > 0 && len(items) > limit {
+		return items[:limit], true
+	}
+	return items, false
+}
@@ -0,0 +1,422 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// productColumns es la lista de columnas usada en todas las consultas de productos
+// El precio está en price_minor (unidades menores) y currency. reserved_stock
+// incluye las reservas expiradas, así que los filtros usan stock_reservations
+// Las columnas *_folded solo se escriben: las usan los filtros OpContains
+const productColumns = `id, name, description, stock, category, created_at, updated_at, is_active, version, reserved_stock, reservations, price_minor, currency`
+
+// availableStockColumn calcula el stock disponible descontando solo las reservas
+// vigentes en el instante del placeholder, igual que Product.AvailableStock
+const availableStockColumn = `(stock - COALESCE((SELECT SUM(r.quantity) FROM stock_reservations r
+	WHERE r.product_id = products.id AND r.expires_at > ?), 0))`
+
+// maxUpdateAttempts limita los reintentos de Update ante escrituras concurrentes
+const maxUpdateAttempts = 10
+
+// SQLProductRepository implementa ProductRepository sobre database/sql
+// Los filtros se resuelven con consultas apoyadas en índices (categoría, precio,
+// disponibilidad y nombre) en lugar de recorrer toda la tabla
+type SQLProductRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewProductRepository crea un repositorio de productos sobre una base de datos ya migrada
+func NewProductRepository(db *sql.DB, dialect Dialect) repositories.ProductRepository {
+	return &SQLProductRepository{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+// Save guarda un producto en el repositorio
+// Los productos nuevos (Version 0) se insertan; el resto se actualiza solo si la
+// versión almacenada coincide, de modo que la comprobación es atómica en el motor
+func (r *SQLProductRepository) Save(ctx context.Context, product *entities.Product) error {
+	reservations, err := encodeReservations(product.Reservations)
+	if err != nil {
+		return &repositories.ProductRepositoryError{Message: "saving product", Err: err}
+	}
+
+	messages, err := repositories.ProductOutboxMessages(ctx, product)
+	if err != nil {
+		return err
+	}
+
+	if product.Version == 0 {
+		err := writeWithOutbox(ctx, r.db, r.dialect, messages, func(db execer) error {
+			_, err := db.ExecContext(ctx, r.dialect.rebind(`
+				INSERT INTO products (`+productColumns+`, id_folded, name_folded, category_folded)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+				product.ID, product.Name, product.Description, product.Stock,
+				product.Category, product.CreatedAt, product.UpdatedAt, product.IsActive, 1,
+				product.ReservedStock(), reservations, product.Price.Amount(), product.Price.Currency(),
+				fold(product.ID), fold(product.Name), fold(product.Category),
+			)
+			if err != nil {
+				return err
+			}
+			return insertReservations(ctx, db, r.dialect, product.ID, product.Reservations)
+		})
+		if isUniqueViolation(err) {
+			return repositories.ErrProductAlreadyExists
+		}
+		if err != nil {
+			return &repositories.ProductRepositoryError{Message: "saving product", Err: err}
+		}
+		product.Version = 1
+		return nil
+	}
+
+	err = writeWithOutbox(ctx, r.db, r.dialect, messages, func(db execer) error {
+		result, err := db.ExecContext(ctx, r.dialect.rebind(`
+			UPDATE products SET name = ?, description = ?, stock = ?, category = ?,
+				updated_at = ?, is_active = ?, reserved_stock = ?, reservations = ?,
+				price_minor = ?, currency = ?, name_folded = ?, category_folded = ?, version = version + 1
+			WHERE id = ? AND version = ?`),
+			product.Name, product.Description, product.Stock, product.Category,
+			product.UpdatedAt, product.IsActive, product.ReservedStock(), reservations,
+			product.Price.Amount(), product.Price.Currency(), fold(product.Name), fold(product.Category),
+			product.ID, product.Version,
+		)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return repositories.ErrProductVersionConflict
+		}
+		return replaceReservations(ctx, db, r.dialect, product.ID, product.Reservations)
+	})
+	if errors.Is(err, repositories.ErrProductVersionConflict) {
+		return err
+	}
+	if isUniqueViolation(err) {
+		return repositories.ErrProductAlreadyExists
+	}
+	if err != nil {
+		return &repositories.ProductRepositoryError{Message: "saving product", Err: err}
+	}
+	product.Version++
+	return nil
+}
+
+// Update aplica una modificación de forma atómica
+// Se apoya en la comprobación de versión de Save: si otra escritura se intercala,
+// el UPDATE no afecta a ninguna fila y la modificación se repite sobre el estado actual
+func (r *SQLProductRepository) Update(ctx context.Context, id string, mutate func(*entities.Product) error) (*entities.Product, error) {
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		product, err := r.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			return nil, repositories.ErrProductNotFound
+		}
+
+		if err := mutate(product); err != nil {
+			return nil, err
+		}
+
+		err = r.Save(ctx, product)
+		if errors.Is(err, repositories.ErrProductVersionConflict) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return product, nil
+	}
+	return nil, repositories.ErrProductVersionConflict
+}
+
+// FindByID busca un producto por su ID
+func (r *SQLProductRepository) FindByID(ctx context.Context, id string) (*entities.Product, error) {
+	row := connFor(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(`SELECT `+productColumns+` FROM products WHERE id = ?`), id)
+	product, err := scanProduct(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &repositories.ProductRepositoryError{Message: "finding product", Err: err}
+	}
+	return product, nil
+}
+
+// FindByName busca productos por nombre
+func (r *SQLProductRepository) FindByName(ctx context.Context, name string) ([]*entities.Product, error) {
+	return r.query(ctx, `SELECT `+productColumns+` FROM products WHERE name = ? ORDER BY id`, name)
+}
+
+// FindByCategory busca productos por categoría
+func (r *SQLProductRepository) FindByCategory(ctx context.Context, category string, limit, offset int) ([]*entities.Product, error) {
+	return r.query(ctx, `SELECT `+productColumns+` FROM products WHERE category = ? ORDER BY id LIMIT ? OFFSET ?`, category, limit, offset)
+}
+
+// FindAll retorna todos los productos ordenados por ID
+func (r *SQLProductRepository) FindAll(ctx context.Context, limit, offset int) ([]*entities.Product, error) {
+	return r.query(ctx, `SELECT `+productColumns+` FROM products ORDER BY id LIMIT ? OFFSET ?`, limit, offset)
+}
+
+// FindAvailable retorna productos disponibles (activos y con stock)
+// Las reservas expiradas no descuentan stock aunque todavía no se hayan limpiado
+func (r *SQLProductRepository) FindAvailable(ctx context.Context, limit, offset int) ([]*entities.Product, error) {
+	return r.query(ctx, `SELECT `+productColumns+` FROM products WHERE is_active = ? AND `+availableStockColumn+` > 0 ORDER BY id LIMIT ? OFFSET ?`,
+		true, reservationClock(), limit, offset)
+}
+
+// FindByPriceRange busca productos en un rango de precios
+func (r *SQLProductRepository) FindByPriceRange(ctx context.Context, minPrice, maxPrice entities.Money, limit, offset int) ([]*entities.Product, error) {
+	if minPrice.Currency() != maxPrice.Currency() {
+		return []*entities.Product{}, nil
+	}
+	return r.query(ctx, `SELECT `+productColumns+` FROM products WHERE currency = ? AND price_minor >= ? AND price_minor <= ? ORDER BY id LIMIT ? OFFSET ?`,
+		minPrice.Currency(), minPrice.Amount(), maxPrice.Amount(), limit, offset)
+}
+
+// FindMatching retorna los productos que cumplen la especificación, ordenados por ID
+func (r *SQLProductRepository) FindMatching(ctx context.Context, spec repositories.Specification, limit, offset int) ([]*entities.Product, error) {
+	if err := repositories.ValidateProductSpecification(spec); err != nil {
+		return nil, err
+	}
+	clause, args := productSpecColumns.where(spec)
+	return r.query(ctx, `SELECT `+productColumns+` FROM products WHERE `+clause+` ORDER BY id LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+}
+
+// FindPage retorna una página de los productos que cumplen la especificación
+// La página continúa tras el cursor con una condición sobre las columnas de orden
+// (keyset), de modo que no se saltan ni repiten filas aunque cambie la tabla
+func (r *SQLProductRepository) FindPage(ctx context.Context, spec repositories.Specification, request repositories.PageRequest) (repositories.Page[*entities.Product], error) {
+	if err := repositories.ValidateProductSpecification(spec); err != nil {
+		return repositories.Page[*entities.Product]{}, err
+	}
+	if err := repositories.ValidateProductPageRequest(request); err != nil {
+		return repositories.Page[*entities.Product]{}, err
+	}
+	page, err := newPageQuery(request, r.dialect)
+	if err != nil {
+		return repositories.Page[*entities.Product]{}, err
+	}
+	clause, args := productSpecColumns.where(spec)
+
+	var total int
+	err = connFor(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM products WHERE `+clause), args...).Scan(&total)
+	if err != nil {
+		return repositories.Page[*entities.Product]{}, &repositories.ProductRepositoryError{Message: "counting products", Err: err}
+	}
+
+	products, err := r.query(ctx, `SELECT `+productColumns+` FROM products WHERE (`+clause+`) AND (`+page.after+`)`+page.orderBy,
+		append(args, page.afterArgs...)...)
+	if err != nil {
+		return repositories.Page[*entities.Product]{}, err
+	}
+	products, more := trimPage(products, request.Limit)
+
+	result := repositories.Page[*entities.Product]{Items: products, Total: total}
+	if more {
+		result.NextCursor = repositories.EncodeCursor(request.Sort, repositories.ProductCursorKey(products[len(products)-1]))
+	}
+	return result, nil
+}
+
+// Delete elimina un producto del repositorio
+// El producto se lee antes de eliminarlo para construir los mensajes del outbox
+func (r *SQLProductRepository) Delete(ctx context.Context, id string) error {
+	product, err := r.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return repositories.ErrProductNotFound
+	}
+	messages, err := repositories.ProductDeleteOutboxMessages(ctx, product)
+	if err != nil {
+		return err
+	}
+
+	err = writeWithOutbox(ctx, r.db, r.dialect, messages, func(db execer) error {
+		result, err := db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM products WHERE id = ?`), id)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return repositories.ErrProductNotFound
+		}
+		return replaceReservations(ctx, db, r.dialect, id, nil)
+	})
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		return err
+	}
+	if err != nil {
+		return &repositories.ProductRepositoryError{Message: "deleting product", Err: err}
+	}
+	return nil
+}
+
+// Exists verifica si un producto existe por ID
+func (r *SQLProductRepository) Exists(ctx context.Context, id string) (bool, error) {
+	var exists int
+	err := connFor(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(`SELECT 1 FROM products WHERE id = ?`), id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, &repositories.ProductRepositoryError{Message: "checking product", Err: err}
+	}
+	return true, nil
+}
+
+// Count retorna el número total de productos
+func (r *SQLProductRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := connFor(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM products`).Scan(&count); err != nil {
+		return 0, &repositories.ProductRepositoryError{Message: "counting products", Err: err}
+	}
+	return count, nil
+}
+
+// CountByCategory retorna el número de productos en una categoría
+func (r *SQLProductRepository) CountByCategory(ctx context.Context, category string) (int, error) {
+	var count int
+	err := connFor(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM products WHERE category = ?`), category).Scan(&count)
+	if err != nil {
+		return 0, &repositories.ProductRepositoryError{Message: "counting products", Err: err}
+	}
+	return count, nil
+}
+
+// Pending retorna los mensajes del outbox pendientes de publicar
+func (r *SQLProductRepository) Pending(ctx context.Context, limit int) ([]*repositories.OutboxMessage, error) {
+	messages, err := pendingOutbox(ctx, r.db, r.dialect, limit)
+	if err != nil {
+		return nil, &repositories.ProductRepositoryError{Message: "reading outbox", Err: err}
+	}
+	return messages, nil
+}
+
+// MarkSent marca un mensaje del outbox como publicado
+func (r *SQLProductRepository) MarkSent(ctx context.Context, id string) error {
+	return r.markOutbox(markOutboxSent(ctx, r.db, r.dialect, id))
+}
+
+// MarkFailed anota un intento de publicación fallido
+func (r *SQLProductRepository) MarkFailed(ctx context.Context, id string, reason string) error {
+	return r.markOutbox(markOutboxFailed(ctx, r.db, r.dialect, id, reason))
+}
+
+// markOutbox envuelve los errores de las actualizaciones del outbox
+func (r *SQLProductRepository) markOutbox(err error) error {
+	if err == nil || errors.Is(err, repositories.ErrOutboxMessageNotFound) {
+		return err
+	}
+	return &repositories.ProductRepositoryError{Message: "updating outbox", Err: err}
+}
+
+// query ejecuta una consulta que retorna múltiples productos
+func (r *SQLProductRepository) query(ctx context.Context, query string, args ...interface{}) ([]*entities.Product, error) {
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, &repositories.ProductRepositoryError{Message: "querying products", Err: err}
+	}
+	defer rows.Close()
+
+	products := []*entities.Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, &repositories.ProductRepositoryError{Message: "scanning product", Err: err}
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &repositories.ProductRepositoryError{Message: "querying products", Err: err}
+	}
+	return products, nil
+}
+
+// scanProduct lee un producto de la fila actual
+func scanProduct(s scanner) (*entities.Product, error) {
+	var product entities.Product
+	var reservedStock int
+	var reservations string
+	var priceMinor int64
+	var currency string
+	if err := s.Scan(
+		&product.ID, &product.Name, &product.Description, &product.Stock,
+		&product.Category, &product.CreatedAt, &product.UpdatedAt, &product.IsActive, &product.Version,
+		&reservedStock, &reservations, &priceMinor, &currency,
+	); err != nil {
+		return nil, err
+	}
+	price, err := entities.NewMoney(priceMinor, currency)
+	if err != nil {
+		return nil, err
+	}
+	product.Price = price
+	if err := json.Unmarshal([]byte(reservations), &product.Reservations); err != nil {
+		return nil, err
+	}
+	if len(product.Reservations) == 0 {
+		product.Reservations = nil
+	}
+	return &product, nil
+}
+
+// encodeReservations serializa las reservas para la columna reservations
+func encodeReservations(reservations []entities.StockReservation) (string, error) {
+	if reservations == nil {
+		reservations = []entities.StockReservation{}
+	}
+	data, err := json.Marshal(reservations)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// replaceReservations sustituye las filas de stock_reservations de un producto
+func replaceReservations(ctx context.Context, db execer, dialect Dialect, productID string, reservations []entities.StockReservation) error {
+	if _, err := db.ExecContext(ctx, dialect.rebind(`DELETE FROM stock_reservations WHERE product_id = ?`), productID); err != nil {
+		return err
+	}
+	return insertReservations(ctx, db, dialect, productID, reservations)
+}
+
+// insertReservations guarda las reservas de un producto en stock_reservations
+// Las expiraciones se guardan en UTC para que se comparen bien con reservationClock
+func insertReservations(ctx context.Context, db execer, dialect Dialect, productID string, reservations []entities.StockReservation) error {
+	for _, reservation := range reservations {
+		if _, err := db.ExecContext(ctx, dialect.rebind(`
+			INSERT INTO stock_reservations (product_id, id, quantity, expires_at) VALUES (?, ?, ?, ?)`),
+			productID, reservation.ID, reservation.Quantity, reservation.ExpiresAt.UTC(),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reservationClock retorna el instante, en UTC, con el que se decide qué reservas
+// siguen vigentes en las consultas
+func reservationClock() time.Time {
+	return time.Now().UTC()
+}
@@ -0,0 +1,114 @@
+package sqldb
+
+import (
+	"strings"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// specColumns relaciona los campos de una especificación con su expresión SQL
+type specColumns map[repositories.Field]specColumn
+
+// specColumn es la expresión SQL de un campo
+// Si la expresión tiene placeholders "?", args retorna sus valores en cada consulta
+// Los campos de texto indican en folded la columna que guarda su valor plegado con
+// fold, sobre la que se resuelve OpContains
+type specColumn struct {
+	expr   string
+	args   func() []interface{}
+	folded string
+}
+
+// Columnas de cada tabla que admiten especificaciones
+// El email ya se guarda normalizado, en minúsculas, y es su propia columna plegada
+// El precio se compara en unidades menores y solo dentro de su moneda, y el stock
+// disponible descuenta solo las reservas vigentes
+var (
+	productSpecColumns = specColumns{
+		repositories.FieldID:             {expr: "id", folded: "id_folded"},
+		repositories.FieldName:           {expr: "name", folded: "name_folded"},
+		repositories.FieldCategory:       {expr: "category", folded: "category_folded"},
+		repositories.FieldPrice:          {expr: "price_minor"},
+		repositories.FieldStock:          {expr: "stock"},
+		repositories.FieldAvailableStock: {expr: availableStockColumn, args: func() []interface{} { return []interface{}{reservationClock()} }},
+		repositories.FieldActive:         {expr: "is_active"},
+	}
+	userSpecColumns = specColumns{
+		repositories.FieldID:     {expr: "id", folded: "id_folded"},
+		repositories.FieldName:   {expr: "name", folded: "name_folded"},
+		repositories.FieldEmail:  {expr: "email", folded: "email"},
+		repositories.FieldActive: {expr: "is_active"},
+	}
+)
+
+// sqlOperators son los operadores de comparación de SQL
+var sqlOperators = map[repositories.Operator]string{
+	repositories.OpEqual:          "=",
+	repositories.OpGreaterOrEqual: ">=",
+	repositories.OpLessOrEqual:    "<=",
+}
+
+// where traduce una especificación ya validada a una condición SQL con
+// placeholders "?" y sus argumentos
+func (c specColumns) where(spec repositories.Specification) (string, []interface{}) {
+	switch s := spec.(type) {
+	case repositories.Condition:
+		return c.condition(s)
+	case repositories.AndSpecification:
+		return c.join(s.Specs, " AND ", "1 = 1")
+	case repositories.OrSpecification:
+		return c.join(s.Specs, " OR ", "1 = 0")
+	case repositories.NotSpecification:
+		clause, args := c.where(s.Spec)
+		return "NOT (" + clause + ")", args
+	}
+	return "1 = 1", nil
+}
+
+// condition traduce una condición sobre un campo
+func (c specColumns) condition(condition repositories.Condition) (string, []interface{}) {
+	column := c[condition.Field]
+	var args []interface{}
+	if column.args != nil {
+		args = column.args()
+	}
+	switch value := condition.Value.(type) {
+	case entities.Money:
+		return "(currency = ? AND " + column.expr + " " + sqlOperators[condition.Operator] + " ?)",
+			append(append([]interface{}{value.Currency()}, args...), value.Amount())
+	case string:
+		if condition.Operator == repositories.OpContains {
+			return column.folded + ` LIKE ? ESCAPE '\'`, []interface{}{"%" + escapeLike(fold(value)) + "%"}
+		}
+	}
+	return column.expr + " " + sqlOperators[condition.Operator] + " ?", append(args, condition.Value)
+}
+
+// join traduce una lista de especificaciones unidas por separator
+// Una lista vacía equivale a empty
+func (c specColumns) join(specs []repositories.Specification, separator, empty string) (string, []interface{}) {
+	if len(specs) == 0 {
+		return empty, nil
+	}
+	clauses := make([]string, 0, len(specs))
+	var args []interface{}
+	for _, spec := range specs {
+		clause, specArgs := c.where(spec)
+		clauses = append(clauses, "("+clause+")")
+		args = append(args, specArgs...)
+	}
+	return strings.Join(clauses, separator), args
+}
+
+// fold pliega un texto a minúsculas igual que la especificación en memoria
+// Se aplica en Go y se guarda en las columnas *_folded porque LOWER solo pliega
+// ASCII en SQLite y depende de la configuración regional en otros motores
+func fold(text string) string {
+	return strings.ToLower(text)
+}
+
+// escapeLike escapa los comodines de LIKE para buscar el texto literal
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+}
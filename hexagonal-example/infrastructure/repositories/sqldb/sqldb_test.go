@@ -0,0 +1,390 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+	"hexagonal-example/infrastructure/repositories/memory"
+)
+
+// openTestDB abre una base de datos SQLite migrada en un directorio temporal
+func openTestDB(t *testing.T) (*sql.DB, Dialect) {
+	t.Helper()
+	db, dialect, err := Open(context.Background(), "sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("No se pudo abrir la base de datos: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, dialect
+}
+
+// TestMigrations verifica que todas las migraciones se aplican y que volver a
+// migrar una base de datos al día no hace nada
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, dialect := openTestDB(t)
+
+	if err := Migrate(ctx, db, dialect); err != nil {
+		t.Fatalf("Migrar una base de datos al día falló: %v", err)
+	}
+	version, err := currentVersion(ctx, db)
+	if err != nil {
+		t.Fatalf("No se pudo leer la versión: %v", err)
+	}
+	if last := migrations[len(migrations)-1].version; version != last {
+		t.Errorf("Versión %d, se esperaba %d", version, last)
+	}
+}
+
+// TestLegacyPriceMigration verifica que los precios guardados en la columna price
+// antes de la migración 5 se convierten a unidades menores de legacyCurrency, que
+// los productos se leen tras eliminar la columna y que su nombre plegado se rellena
+func TestLegacyPriceMigration(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "legacy.db"))
+	if err != nil {
+		t.Fatalf("No se pudo abrir la base de datos: %v", err)
+	}
+	defer db.Close()
+
+	all := migrations
+	migrations = all[:4]
+	err = Migrate(ctx, db, SQLite)
+	migrations = all
+	if err != nil {
+		t.Fatalf("No se pudo migrar a la versión 4: %v", err)
+	}
+
+	now := time.Now().UTC()
+	if _, err := db.ExecContext(ctx, `INSERT INTO products (id, name, description, price, stock, category, created_at, updated_at, is_active)
+		VALUES ('prod-1', 'Teclado', '', 19.99, 3, 'cat', ?, ?, TRUE)`, now, now); err != nil {
+		t.Fatalf("No se pudo guardar el producto heredado: %v", err)
+	}
+	if err := Migrate(ctx, db, SQLite); err != nil {
+		t.Fatalf("No se pudieron aplicar las migraciones pendientes: %v", err)
+	}
+
+	product, err := NewProductRepository(db, SQLite).FindByID(ctx, "prod-1")
+	if err != nil || product == nil {
+		t.Fatalf("No se pudo leer el producto migrado: %v", err)
+	}
+	if want := entities.MustParseMoney("19.99", "USD"); product.Price != want {
+		t.Errorf("Precio %s, se esperaba %s", product.Price, want)
+	}
+	found, err := NewProductRepository(db, SQLite).FindMatching(ctx, repositories.NameContains("TECLA"), 10, 0)
+	if err != nil || len(found) != 1 {
+		t.Errorf("Se esperaba encontrar el producto migrado por su nombre, se obtuvo %d (%v)", len(found), err)
+	}
+}
+
+// TestContainsFoldsNonASCII verifica que OpContains no distingue mayúsculas fuera
+// de ASCII, igual que la especificación en memoria
+func TestContainsFoldsNonASCII(t *testing.T) {
+	ctx := context.Background()
+	db, dialect := openTestDB(t)
+	sqlRepo := NewProductRepository(db, dialect)
+	memoryRepo := memory.NewProductRepository()
+
+	for i, name := range []string{"ÁRBOL de Navidad", "árbol", "Arbusto", "Cañón", "CAÑÓN"} {
+		product, _ := entities.NewProduct(fmt.Sprintf("prod-%d", i), name, "", "Decoración",
+			entities.MustParseMoney("1", "EUR"), 1)
+		for _, repo := range []repositories.ProductRepository{sqlRepo, memoryRepo} {
+			if err := repo.Save(ctx, product.Clone()); err != nil {
+				t.Fatalf("No se pudo guardar el producto: %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		spec  repositories.Specification
+		count int
+	}{
+		{spec: repositories.NameContains("Árbol"), count: 2},
+		{spec: repositories.NameContains("cañón"), count: 2},
+		{spec: repositories.Where(repositories.FieldCategory, repositories.OpContains, "DECORACIÓN"), count: 5},
+	}
+	for _, tt := range tests {
+		want, err := memoryRepo.FindMatching(ctx, tt.spec, 10, 0)
+		if err != nil {
+			t.Fatalf("La búsqueda en memoria falló: %v", err)
+		}
+		got, err := sqlRepo.FindMatching(ctx, tt.spec, 10, 0)
+		if err != nil {
+			t.Fatalf("La búsqueda en SQL falló: %v", err)
+		}
+		if len(want) != tt.count || fmt.Sprint(productIDs(got)) != fmt.Sprint(productIDs(want)) {
+			t.Errorf("%v: SQL retornó %v, memoria %v", tt.spec, productIDs(got), productIDs(want))
+		}
+	}
+}
+
+// productIDs retorna los IDs de una lista de productos
+func productIDs(products []*entities.Product) []string {
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	return ids
+}
+
+// TestUserRepository verifica la traducción de las violaciones de unicidad y el
+// UPDATE condicionado a la versión
+func TestUserRepository(t *testing.T) {
+	ctx := context.Background()
+	db, dialect := openTestDB(t)
+	repo := NewUserRepository(db, dialect)
+
+	ana, _ := entities.NewUser("user-ana", "ana@example.com", "Ana")
+	if err := repo.Save(ctx, ana); err != nil {
+		t.Fatalf("No se pudo guardar el usuario: %v", err)
+	}
+	luis, _ := entities.NewUser("user-luis", "luis@example.com", "Luis")
+	if err := repo.Save(ctx, luis); err != nil {
+		t.Fatalf("No se pudo guardar el usuario: %v", err)
+	}
+
+	duplicateID, _ := entities.NewUser("user-ana", "otra@example.com", "Otra")
+	if err := repo.Save(ctx, duplicateID); !errors.Is(err, repositories.ErrUserAlreadyExists) {
+		t.Errorf("Se esperaba ErrUserAlreadyExists con un ID repetido, se obtuvo %v", err)
+	}
+	duplicateEmail, _ := entities.NewUser("user-otra", "Ana@Example.com", "Otra")
+	if err := repo.Save(ctx, duplicateEmail); !errors.Is(err, repositories.ErrEmailAlreadyInUse) {
+		t.Errorf("Se esperaba ErrEmailAlreadyInUse con un email repetido, se obtuvo %v", err)
+	}
+
+	luis.UpdateEmail("ana@example.com")
+	if err := repo.Save(ctx, luis); !errors.Is(err, repositories.ErrEmailAlreadyInUse) {
+		t.Errorf("Se esperaba ErrEmailAlreadyInUse al cambiar a un email en uso, se obtuvo %v", err)
+	}
+
+	stale, _ := repo.FindByID(ctx, "user-ana")
+	ana.UpdateName("Ana María")
+	if err := repo.Save(ctx, ana); err != nil {
+		t.Fatalf("No se pudo actualizar el usuario: %v", err)
+	}
+	stale.UpdateName("Ana Obsoleta")
+	if err := repo.Save(ctx, stale); !errors.Is(err, repositories.ErrUserVersionConflict) {
+		t.Errorf("Se esperaba ErrUserVersionConflict con una versión obsoleta, se obtuvo %v", err)
+	}
+
+	found, err := repo.FindByEmail(ctx, " ANA@example.com")
+	if err != nil || found == nil || found.Name != "Ana María" || found.Version != 2 {
+		t.Errorf("Se esperaba user-ana en la versión 2, se obtuvo %v (%v)", found, err)
+	}
+}
+
+// TestProductPagesMatchMemory verifica que las páginas por cursor de SQL tienen los
+// mismos límites que las del adaptador en memoria en todos los órdenes
+func TestProductPagesMatchMemory(t *testing.T) {
+	ctx := context.Background()
+	db, dialect := openTestDB(t)
+	sqlRepo := NewProductRepository(db, dialect)
+	memoryRepo := memory.NewProductRepository()
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	names := []string{"teclado", "Ratón", "monitor", "Teclado", "cable", "ratón", "Árbol", "árbol"}
+	for i := 0; i < 12; i++ {
+		currency := "EUR"
+		if i%3 == 0 {
+			currency = "USD"
+		}
+		product, _ := entities.NewProduct(fmt.Sprintf("prod-%02d", (i*7)%12), names[i%len(names)], "", "cat",
+			entities.MustParseMoney(fmt.Sprint(i%4+1), currency), i)
+		product.CreatedAt = base.Add(time.Duration(i%5) * time.Hour)
+		for _, repo := range []repositories.ProductRepository{sqlRepo, memoryRepo} {
+			if err := repo.Save(ctx, product.Clone()); err != nil {
+				t.Fatalf("No se pudo guardar el producto: %v", err)
+			}
+		}
+	}
+
+	for _, sort := range []string{"id", "name", "price:desc", "created_at", "created_at:desc"} {
+		order, err := repositories.ParseSortOrder(sort)
+		if err != nil {
+			t.Fatalf("Orden inválido %s: %v", sort, err)
+		}
+		want := collectPages(t, memoryRepo, order)
+		got := collectPages(t, sqlRepo, order)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Orden %s: SQL retornó %v, memoria %v", sort, got, want)
+		}
+	}
+}
+
+// TestPageQueryCollation verifica que las columnas de texto del orden usan la
+// colación binaria de cada dialecto y las marcas de tiempo ninguna
+func TestPageQueryCollation(t *testing.T) {
+	cursor := repositories.EncodeCursor(repositories.SortOrder{Field: repositories.SortByName},
+		repositories.CursorKey{ID: "prod-1", Name: "Teclado"})
+	tests := []struct {
+		dialect Dialect
+		sort    repositories.SortField
+		orderBy string
+		after   string
+	}{
+		{SQLite, repositories.SortByName, " ORDER BY name COLLATE BINARY, id COLLATE BINARY LIMIT 6", "(name COLLATE BINARY > ?)"},
+		{Postgres, repositories.SortByName, ` ORDER BY name COLLATE "C", id COLLATE "C" LIMIT 6`, `(name COLLATE "C" > ?)`},
+		{Postgres, repositories.SortByCreatedAt, ` ORDER BY created_at, id COLLATE "C" LIMIT 6`, "1 = 1"},
+	}
+	for _, tt := range tests {
+		request := repositories.PageRequest{Sort: repositories.SortOrder{Field: tt.sort}, Limit: 5}
+		if tt.sort == repositories.SortByName {
+			request.Cursor = cursor
+		}
+		query, err := newPageQuery(request, tt.dialect)
+		if err != nil {
+			t.Fatalf("No se pudo construir la consulta: %v", err)
+		}
+		if query.orderBy != tt.orderBy {
+			t.Errorf("%s por %s: ORDER BY %q, se esperaba %q", tt.dialect.Name, tt.sort, query.orderBy, tt.orderBy)
+		}
+		if !strings.HasPrefix(query.after, tt.after) {
+			t.Errorf("%s por %s: condición %q, se esperaba que empezara por %q", tt.dialect.Name, tt.sort, query.after, tt.after)
+		}
+	}
+}
+
+// collectPages recorre todas las páginas de tamaño 5 y retorna sus IDs por página
+func collectPages(t *testing.T, repo repositories.ProductRepository, order repositories.SortOrder) [][]string {
+	t.Helper()
+	var pages [][]string
+	request := repositories.PageRequest{Sort: order, Limit: 5}
+	for {
+		page, err := repo.FindPage(context.Background(), nil, request)
+		if err != nil {
+			t.Fatalf("No se pudo leer la página: %v", err)
+		}
+		ids := make([]string, 0, len(page.Items))
+		for _, product := range page.Items {
+			ids = append(ids, product.ID)
+		}
+		pages = append(pages, ids)
+		if page.NextCursor == "" {
+			return pages
+		}
+		request.Cursor = page.NextCursor
+	}
+}
+
+// TestAvailableIgnoresExpiredReservations verifica que las reservas expiradas no
+// descuentan stock en SQL aunque no se hayan limpiado, igual que en memoria
+func TestAvailableIgnoresExpiredReservations(t *testing.T) {
+	ctx := context.Background()
+	db, dialect := openTestDB(t)
+	sqlRepo := NewProductRepository(db, dialect)
+	memoryRepo := memory.NewProductRepository()
+
+	for i, expiresIn := range []time.Duration{-time.Minute, time.Hour} {
+		product, _ := entities.NewProduct(fmt.Sprintf("prod-%d", i), "Producto", "", "cat", entities.MustParseMoney("5", "EUR"), 2)
+		product.Reserve("res-1", 2, time.Now().Add(expiresIn))
+		for _, repo := range []repositories.ProductRepository{sqlRepo, memoryRepo} {
+			if err := repo.Save(ctx, product.Clone()); err != nil {
+				t.Fatalf("No se pudo guardar el producto: %v", err)
+			}
+		}
+	}
+
+	for _, repo := range []repositories.ProductRepository{sqlRepo, memoryRepo} {
+		available, err := repo.FindAvailable(ctx, 10, 0)
+		if err != nil || len(available) != 1 || available[0].ID != "prod-0" {
+			t.Errorf("%T: se esperaba solo prod-0 disponible, se obtuvo %v (%v)", repo, available, err)
+		}
+		matching, err := repo.FindMatching(ctx, repositories.IsAvailable(), 10, 0)
+		if err != nil || len(matching) != 1 || matching[0].ID != "prod-0" {
+			t.Errorf("%T: se esperaba solo prod-0 con IsAvailable, se obtuvo %v (%v)", repo, matching, err)
+		}
+	}
+}
+
+// TestSQLOutbox verifica que los mensajes del outbox se guardan en la transacción
+// del producto y que se marcan como publicados
+func TestSQLOutbox(t *testing.T) {
+	ctx := context.Background()
+	db, dialect := openTestDB(t)
+	repo := NewProductRepository(db, dialect)
+	outbox := repo.(repositories.OutboxRepository)
+	uow := NewUnitOfWork(db)
+
+	ctx = repositories.WithProductOutbox(ctx, func(product *entities.Product) ([]*repositories.OutboxMessage, error) {
+		return []*repositories.OutboxMessage{{
+			ID:          "msg-" + product.ID,
+			EventType:   "product.saved",
+			AggregateID: product.ID,
+			Payload:     []byte(`{}`),
+			CreatedAt:   time.Now().UTC(),
+		}}, nil
+	})
+
+	for _, commit := range []bool{false, true} {
+		product, _ := entities.NewProduct(fmt.Sprintf("prod-%t", commit), "Producto", "", "cat", entities.MustParseMoney("5", "EUR"), 1)
+		txCtx, err := uow.Begin(ctx)
+		if err != nil {
+			t.Fatalf("No se pudo iniciar la transacción: %v", err)
+		}
+		if err := repo.Save(txCtx, product); err != nil {
+			t.Fatalf("No se pudo guardar el producto: %v", err)
+		}
+		if commit {
+			err = uow.Commit(txCtx)
+		} else {
+			err = uow.Rollback(txCtx)
+		}
+		if err != nil {
+			t.Fatalf("No se pudo terminar la transacción: %v", err)
+		}
+	}
+
+	pending, err := outbox.Pending(ctx, 10)
+	if err != nil || len(pending) != 1 || pending[0].ID != "msg-prod-true" {
+		t.Fatalf("Se esperaba solo el mensaje confirmado, se obtuvo %v (%v)", pending, err)
+	}
+	if err := outbox.MarkFailed(ctx, "msg-prod-true", "bus caído"); err != nil {
+		t.Errorf("No se pudo anotar el fallo: %v", err)
+	}
+	if err := outbox.MarkSent(ctx, "msg-prod-true"); err != nil {
+		t.Errorf("No se pudo marcar el mensaje: %v", err)
+	}
+	if err := outbox.MarkSent(ctx, "msg-prod-true"); !errors.Is(err, repositories.ErrOutboxMessageNotFound) {
+		t.Errorf("Se esperaba ErrOutboxMessageNotFound al marcar dos veces, se obtuvo %v", err)
+	}
+	if pending, _ := outbox.Pending(ctx, 10); len(pending) != 0 {
+		t.Errorf("No se esperaban mensajes pendientes, se obtuvo %v", pending)
+	}
+}
+
+// TestDialectHelpers verifica la reescritura de placeholders y la detección de
+// violaciones de una restricción concreta
+func TestDialectHelpers(t *testing.T) {
+	query := `SELECT '¿?', "a?b" FROM t WHERE x = ? AND y LIKE ? ESCAPE '\' AND z = 'it''s?'`
+	want := `SELECT '¿?', "a?b" FROM t WHERE x = $1 AND y LIKE $2 ESCAPE '\' AND z = 'it''s?'`
+	if got := Postgres.rebind(query); got != want {
+		t.Errorf("rebind retornó %s, se esperaba %s", got, want)
+	}
+	if got := SQLite.rebind(query); got != query {
+		t.Errorf("SQLite no debe reescribir la consulta, se obtuvo %s", got)
+	}
+
+	for message, want := range map[string]bool{
+		"UNIQUE constraint failed: users.email":                                       true,
+		"constraint failed: UNIQUE constraint failed: users.email (2067)":             true,
+		"UNIQUE constraint failed: users.email_backup":                                false,
+		"UNIQUE constraint failed: users.id":                                          false,
+		`pq: duplicate key value violates unique constraint "users_email_key"`:        true,
+		`ERROR: duplicate key value violates unique constraint "users_email_key_old"`: false,
+		"no such table: users.email":                                                  false,
+	} {
+		if got := isUniqueViolationOn(errors.New(message), usersEmailUnique); got != want {
+			t.Errorf("isUniqueViolationOn(%q) = %t, se esperaba %t", message, got, want)
+		}
+	}
+}
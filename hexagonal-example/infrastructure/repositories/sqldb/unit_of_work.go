@@ -0,0 +1,108 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"hexagonal-example/domain/repositories"
+)
+
+// UnitOfWork implementa repositories.UnitOfWork con transacciones de la base de datos
+// Los repositorios creados sobre la misma *sql.DB usan la transacción del contexto
+// en todas sus consultas, de modo que el aislamiento es el del motor
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+// NewUnitOfWork crea la unidad de trabajo de los repositorios creados sobre db
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// transaction es una transacción en curso de la base de datos
+type transaction struct {
+	repositories.Transaction
+
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// transactionKey es la clave de contexto de la transacción de la base de datos
+type transactionKey struct{}
+
+// Begin inicia una transacción de la base de datos
+func (u *UnitOfWork) Begin(ctx context.Context) (context.Context, error) {
+	if transactionFrom(ctx, u.db) != nil {
+		return ctx, repositories.ErrTransactionInProgress
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ctx, err
+	}
+	t := &transaction{db: u.db, tx: tx}
+	ctx = repositories.WithTransaction(ctx, u, &t.Transaction)
+	return context.WithValue(ctx, transactionKey{}, t), nil
+}
+
+// Commit confirma la transacción del contexto
+func (u *UnitOfWork) Commit(ctx context.Context) error {
+	t, err := u.finish(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.tx.Commit(); err != nil {
+		return err
+	}
+	t.RunAfterCommit(ctx)
+	return nil
+}
+
+// Rollback deshace la transacción del contexto
+func (u *UnitOfWork) Rollback(ctx context.Context) error {
+	t, err := u.finish(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return err
+	}
+	return nil
+}
+
+// finish termina la transacción de ctx
+func (u *UnitOfWork) finish(ctx context.Context) (*transaction, error) {
+	t, _ := ctx.Value(transactionKey{}).(*transaction)
+	if t == nil || t.db != u.db {
+		return nil, repositories.ErrNoTransaction
+	}
+	if err := t.Finish(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// transactionFrom retorna la transacción en curso de ctx sobre db, o nil si no hay ninguna
+func transactionFrom(ctx context.Context, db *sql.DB) *sql.Tx {
+	t, _ := ctx.Value(transactionKey{}).(*transaction)
+	if t == nil || t.db != db || t.Done() {
+		return nil
+	}
+	return t.tx
+}
+
+// conn es la parte común de *sql.DB y *sql.Tx que usan los repositorios
+type conn interface {
+	execer
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// connFor retorna la transacción de ctx si la hay, o db en caso contrario
+func connFor(ctx context.Context, db *sql.DB) conn {
+	if tx := transactionFrom(ctx, db); tx != nil {
+		return tx
+	}
+	return db
+}
@@ -0,0 +1,228 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// userColumns es la lista de columnas usada en todas las consultas de usuarios
+// Las columnas *_folded solo se escriben: las usan los filtros OpContains
+const userColumns = `id, email, name, created_at, updated_at, is_active, version`
+
+// SQLUserRepository implementa UserRepository sobre database/sql
+type SQLUserRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewUserRepository crea un repositorio de usuarios sobre una base de datos ya migrada
+func NewUserRepository(db *sql.DB, dialect Dialect) repositories.UserRepository {
+	return &SQLUserRepository{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+// Save guarda un usuario en el repositorio
+// Los usuarios nuevos (Version 0) se insertan; el resto se actualiza solo si la
+// versión almacenada coincide, de modo que la comprobación es atómica en el motor
+// La unicidad del email la garantiza la restricción UNIQUE de la columna
+func (r *SQLUserRepository) Save(ctx context.Context, user *entities.User) error {
+	if user.Version == 0 {
+		_, err := connFor(ctx, r.db).ExecContext(ctx, r.dialect.rebind(`
+			INSERT INTO users (`+userColumns+`, id_folded, name_folded) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			user.ID, user.Email, user.Name, user.CreatedAt, user.UpdatedAt, user.IsActive, 1,
+			fold(user.ID), fold(user.Name),
+		)
+		if isUniqueViolationOn(err, usersEmailUnique) {
+			return repositories.ErrEmailAlreadyInUse
+		}
+		if isUniqueViolation(err) {
+			return repositories.ErrUserAlreadyExists
+		}
+		if err != nil {
+			return &repositories.UserRepositoryError{Message: "saving user", Err: err}
+		}
+		user.Version = 1
+		return nil
+	}
+
+	result, err := connFor(ctx, r.db).ExecContext(ctx, r.dialect.rebind(`
+		UPDATE users SET email = ?, name = ?, name_folded = ?, updated_at = ?, is_active = ?, version = version + 1
+		WHERE id = ? AND version = ?`),
+		user.Email, user.Name, fold(user.Name), user.UpdatedAt, user.IsActive, user.ID, user.Version,
+	)
+	// El email es la única columna única que puede cambiar
+	if isUniqueViolationOn(err, usersEmailUnique) {
+		return repositories.ErrEmailAlreadyInUse
+	}
+	if err != nil {
+		return &repositories.UserRepositoryError{Message: "saving user", Err: err}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return &repositories.UserRepositoryError{Message: "saving user", Err: err}
+	}
+	if affected == 0 {
+		return repositories.ErrUserVersionConflict
+	}
+	user.Version++
+	return nil
+}
+
+// FindByID busca un usuario por su ID
+func (r *SQLUserRepository) FindByID(ctx context.Context, id string) (*entities.User, error) {
+	row := connFor(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(`SELECT `+userColumns+` FROM users WHERE id = ?`), id)
+	return scanUserRow(row)
+}
+
+// FindByEmail busca un usuario por su email normalizado (índice único)
+func (r *SQLUserRepository) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
+	row := connFor(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(`SELECT `+userColumns+` FROM users WHERE email = ?`), entities.NormalizeEmail(email))
+	return scanUserRow(row)
+}
+
+// FindAll retorna todos los usuarios ordenados por ID
+func (r *SQLUserRepository) FindAll(ctx context.Context, limit, offset int) ([]*entities.User, error) {
+	return r.query(ctx, `SELECT `+userColumns+` FROM users ORDER BY id LIMIT ? OFFSET ?`, limit, offset)
+}
+
+// FindActive retorna los usuarios activos ordenados por ID
+func (r *SQLUserRepository) FindActive(ctx context.Context, limit, offset int) ([]*entities.User, error) {
+	return r.query(ctx, `SELECT `+userColumns+` FROM users WHERE is_active = ? ORDER BY id LIMIT ? OFFSET ?`, true, limit, offset)
+}
+
+// FindMatching retorna los usuarios que cumplen la especificación, ordenados por ID
+func (r *SQLUserRepository) FindMatching(ctx context.Context, spec repositories.Specification, limit, offset int) ([]*entities.User, error) {
+	if err := repositories.ValidateUserSpecification(spec); err != nil {
+		return nil, err
+	}
+	clause, args := userSpecColumns.where(spec)
+	return r.query(ctx, `SELECT `+userColumns+` FROM users WHERE `+clause+` ORDER BY id LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+}
+
+// FindPage retorna una página de los usuarios que cumplen la especificación
+// La página continúa tras el cursor con una condición sobre las columnas de orden
+// (keyset), de modo que no se saltan ni repiten filas aunque cambie la tabla
+func (r *SQLUserRepository) FindPage(ctx context.Context, spec repositories.Specification, request repositories.PageRequest) (repositories.Page[*entities.User], error) {
+	if err := repositories.ValidateUserSpecification(spec); err != nil {
+		return repositories.Page[*entities.User]{}, err
+	}
+	if err := repositories.ValidateUserPageRequest(request); err != nil {
+		return repositories.Page[*entities.User]{}, err
+	}
+	page, err := newPageQuery(request, r.dialect)
+	if err != nil {
+		return repositories.Page[*entities.User]{}, err
+	}
+	clause, args := userSpecColumns.where(spec)
+
+	var total int
+	err = connFor(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM users WHERE `+clause), args...).Scan(&total)
+	if err != nil {
+		return repositories.Page[*entities.User]{}, &repositories.UserRepositoryError{Message: "counting users", Err: err}
+	}
+
+	users, err := r.query(ctx, `SELECT `+userColumns+` FROM users WHERE (`+clause+`) AND (`+page.after+`)`+page.orderBy,
+		append(args, page.afterArgs...)...)
+	if err != nil {
+		return repositories.Page[*entities.User]{}, err
+	}
+	users, more := trimPage(users, request.Limit)
+
+	result := repositories.Page[*entities.User]{Items: users, Total: total}
+	if more {
+		result.NextCursor = repositories.EncodeCursor(request.Sort, repositories.UserCursorKey(users[len(users)-1]))
+	}
+	return result, nil
+}
+
+// Delete elimina un usuario del repositorio
+func (r *SQLUserRepository) Delete(ctx context.Context, id string) error {
+	result, err := connFor(ctx, r.db).ExecContext(ctx, r.dialect.rebind(`DELETE FROM users WHERE id = ?`), id)
+	if err != nil {
+		return &repositories.UserRepositoryError{Message: "deleting user", Err: err}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return &repositories.UserRepositoryError{Message: "deleting user", Err: err}
+	}
+	if affected == 0 {
+		return repositories.ErrUserNotFound
+	}
+	return nil
+}
+
+// Exists verifica si un usuario existe por ID
+func (r *SQLUserRepository) Exists(ctx context.Context, id string) (bool, error) {
+	var exists int
+	err := connFor(ctx, r.db).QueryRowContext(ctx, r.dialect.rebind(`SELECT 1 FROM users WHERE id = ?`), id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, &repositories.UserRepositoryError{Message: "checking user", Err: err}
+	}
+	return true, nil
+}
+
+// Count retorna el número total de usuarios
+func (r *SQLUserRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := connFor(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return 0, &repositories.UserRepositoryError{Message: "counting users", Err: err}
+	}
+	return count, nil
+}
+
+// query ejecuta una consulta que retorna múltiples usuarios
+func (r *SQLUserRepository) query(ctx context.Context, query string, args ...interface{}) ([]*entities.User, error) {
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, &repositories.UserRepositoryError{Message: "querying users", Err: err}
+	}
+	defer rows.Close()
+
+	users := []*entities.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, &repositories.UserRepositoryError{Message: "scanning user", Err: err}
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &repositories.UserRepositoryError{Message: "querying users", Err: err}
+	}
+	return users, nil
+}
+
+// scanner abstrae *sql.Row y *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUser lee un usuario de la fila actual
+func scanUser(s scanner) (*entities.User, error) {
+	var user entities.User
+	if err := s.Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt, &user.IsActive, &user.Version); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// scanUserRow lee un único usuario; retorna nil si no existe, igual que el resto de adaptadores
+func scanUserRow(row *sql.Row) (*entities.User, error) {
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &repositories.UserRepositoryError{Message: "finding user", Err: err}
+	}
+	return user, nil
+}
@@ -0,0 +1,190 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"hexagonal-example/domain/entities"
+	"hexagonal-example/domain/repositories"
+)
+
+// Peso de cada campo del producto: un término del nombre cuenta más que uno de la
+// categoría, y este más que uno de la descripción
+const (
+	nameWeight        = 3.0
+	categoryWeight    = 2.0
+	descriptionWeight = 1.0
+)
+
+// Peso de cada forma de coincidir un término de búsqueda con uno indexado
+const (
+	exactMatch  = 1.0
+	prefixMatch = 0.6
+	fuzzyMatch  = 0.4
+)
+
+// minPrefixLength es la longitud mínima de un término para buscarlo como prefijo
+const minPrefixLength = 2
+
+// ProductIndex es un índice invertido en memoria del nombre, la descripción y la
+// categoría de los productos. Implementa repositories.ProductSearchIndex
+//
+// Un término de búsqueda coincide con los términos indexados iguales, con los que
+// empiezan por él y con los que están a pocas erratas (ver maxEdits). La relevancia
+// de un producto suma, por cada término, el peso de la coincidencia por el peso
+// de los campos donde aparece y por lo poco frecuente que es el término (IDF)
+type ProductIndex struct {
+	// postings asocia cada término con los productos que lo contienen y su peso
+	postings map[string]map[string]float64
+
+	// documents guarda los términos de cada producto para poder retirarlos
+	documents map[string][]string
+
+	mutex sync.RWMutex
+}
+
+// NewProductIndex crea un índice vacío
+func NewProductIndex() *ProductIndex {
+	return &ProductIndex{
+		postings:  make(map[string]map[string]float64),
+		documents: make(map[string][]string),
+	}
+}
+
+// Index añade un producto al índice, o lo reemplaza si ya estaba
+func (i *ProductIndex) Index(product *entities.Product) {
+	weights := make(map[string]float64)
+	for _, field := range []struct {
+		text   string
+		weight float64
+	}{
+		{product.Name, nameWeight},
+		{product.Category, categoryWeight},
+		{product.Description, descriptionWeight},
+	} {
+		for _, term := range tokenize(field.text) {
+			weights[term] += field.weight
+		}
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.remove(product.ID)
+	terms := make([]string, 0, len(weights))
+	for term, weight := range weights {
+		if i.postings[term] == nil {
+			i.postings[term] = make(map[string]float64)
+		}
+		i.postings[term][product.ID] = weight
+		terms = append(terms, term)
+	}
+	i.documents[product.ID] = terms
+}
+
+// Remove retira un producto del índice
+func (i *ProductIndex) Remove(id string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.remove(id)
+}
+
+// remove retira un producto; debe llamarse con el mutex adquirido
+func (i *ProductIndex) remove(id string) {
+	for _, term := range i.documents[id] {
+		delete(i.postings[term], id)
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+		}
+	}
+	delete(i.documents, id)
+}
+
+// Len retorna el número de productos indexados
+func (i *ProductIndex) Len() int {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	return len(i.documents)
+}
+
+// Search retorna hasta limit productos que contienen todos los términos de query,
+// del más al menos relevante; a igual relevancia, por ID. Con limit 0 retorna todos
+func (i *ProductIndex) Search(ctx context.Context, query string, limit int) ([]repositories.ProductSearchHit, error) {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return []repositories.ProductSearchHit{}, nil
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	var scores map[string]float64
+	for _, term := range terms {
+		termScores := i.scoreTerm(term)
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		// Un producto debe coincidir con todos los términos
+		for id, score := range scores {
+			if termScore, ok := termScores[id]; ok {
+				scores[id] = score + termScore
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]repositories.ProductSearchHit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, repositories.ProductSearchHit{ProductID: id, Score: score})
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].ProductID < hits[b].ProductID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// scoreTerm retorna la relevancia de cada producto para un término de búsqueda
+// Si el término coincide de varias formas en un producto cuenta la mejor
+// Debe llamarse con el mutex adquirido
+func (i *ProductIndex) scoreTerm(term string) map[string]float64 {
+	scores := make(map[string]float64)
+	edits := maxEdits(term)
+	for indexed, products := range i.postings {
+		match := matchWeight(term, indexed, edits)
+		if match == 0 {
+			continue
+		}
+		idf := 1 + math.Log(float64(len(i.documents))/float64(len(products)))
+		for id, weight := range products {
+			scores[id] = max(scores[id], match*weight*idf)
+		}
+	}
+	return scores
+}
+
+// matchWeight retorna el peso de la coincidencia entre un término de búsqueda y uno
+// indexado, o 0 si no coinciden
+func matchWeight(term, indexed string, edits int) float64 {
+	switch {
+	case term == indexed:
+		return exactMatch
+	case len(term) >= minPrefixLength && strings.HasPrefix(indexed, term):
+		return prefixMatch
+	case edits > 0 && editDistance(term, indexed, edits) <= edits:
+		return fuzzyMatch
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"context"
+
+	"hexagonal-example/domain/repositories"
+	"hexagonal-example/infrastructure/events"
+)
+
+// ProductIndexer mantiene un ProductIndex al día con los eventos de producto
+// Los eventos no llevan la descripción, así que ante cada uno se vuelve a leer el
+// producto del repositorio; así el índice refleja el último estado guardado aunque
+// los eventos lleguen tarde o desordenados (por ejemplo desde el outbox)
+type ProductIndexer struct {
+	index       *ProductIndex
+	productRepo repositories.ProductRepository
+
+	subscriptions []*events.Subscription
+}
+
+// NewProductIndexer crea un indexador que lee los productos de productRepo
+func NewProductIndexer(index *ProductIndex, productRepo repositories.ProductRepository) *ProductIndexer {
+	return &ProductIndexer{
+		index:       index,
+		productRepo: productRepo,
+	}
+}
+
+// Subscribe suscribe el indexador a los eventos de creación, modificación y
+// eliminación de productos del bus
+func (x *ProductIndexer) Subscribe(bus events.EventBus) *ProductIndexer {
+	x.subscriptions = append(x.subscriptions,
+		events.Subscribe(bus, func(ctx context.Context, event events.ProductCreatedEvent) error {
+			return x.Refresh(ctx, event.ProductID)
+		}),
+		events.Subscribe(bus, func(ctx context.Context, event events.ProductUpdatedEvent) error {
+			return x.Refresh(ctx, event.ProductID)
+		}),
+		events.Subscribe(bus, func(ctx context.Context, event events.ProductDeletedEvent) error {
+			x.index.Remove(event.ProductID)
+			return nil
+		}),
+	)
+	return x
+}
+
+// Close cancela las suscripciones del indexador
+func (x *ProductIndexer) Close() error {
+	for _, subscription := range x.subscriptions {
+		subscription.Unsubscribe()
+	}
+	x.subscriptions = nil
+	return nil
+}
+
+// Refresh vuelve a indexar un producto con su estado guardado, o lo retira del
+// índice si ya no existe
+func (x *ProductIndexer) Refresh(ctx context.Context, id string) error {
+	product, err := x.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		x.index.Remove(id)
+		return nil
+	}
+	x.index.Index(product)
+	return nil
+}
+
+// Rebuild indexa todos los productos del repositorio
+// Se usa al arrancar con un repositorio que ya tiene datos (ficheros, SQL)
+func (x *ProductIndexer) Rebuild(ctx context.Context) error {
+	page, err := x.productRepo.FindPage(ctx, nil, repositories.PageRequest{})
+	if err != nil {
+		return err
+	}
+	for _, product := range page.Items {
+		x.index.Index(product)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// foldedRunes son las letras acentuadas que se buscan como su letra base, de modo
+// que "electronicos" encuentra "Electrónicos" y al revés
+var foldedRunes = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ä': 'a', 'ã': 'a', 'å': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'ö': 'o', 'õ': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ñ': 'n', 'ç': 'c',
+}
+
+// stopWords son palabras tan frecuentes que no ayudan a distinguir productos
+// No se indexan ni se exigen en las búsquedas
+var stopWords = map[string]bool{
+	"a": true, "al": true, "con": true, "de": true, "del": true, "el": true,
+	"en": true, "la": true, "las": true, "los": true, "para": true, "por": true,
+	"un": true, "una": true, "y": true,
+	"an": true, "and": true, "for": true, "of": true, "the": true, "with": true,
+}
+
+// tokenize divide un texto en términos normalizados: en minúsculas, sin acentos
+// y sin signos de puntuación ni palabras vacías
+func tokenize(text string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(normalize(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !stopWords[word] {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// normalize pasa un texto a minúsculas y sustituye las letras acentuadas por su base
+func normalize(text string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if folded, ok := foldedRunes[r]; ok {
+			return folded
+		}
+		return r
+	}, text)
+}
+
+// maxEdits es el número de erratas que se toleran en un término de búsqueda
+// Los términos cortos deben escribirse bien: con una errata ya coinciden con
+// demasiadas palabras
+func maxEdits(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance retorna la distancia de Levenshtein entre a y b, o limit+1 si es
+// mayor que limit
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > limit {
+		return limit + 1
+	}
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		rowMin := current[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			rowMin = min(rowMin, current[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// abs retorna el valor absoluto de n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
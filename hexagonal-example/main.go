@@ -2,13 +2,26 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
+	"time"
+	"hexagonal-example/application/services"
+	"hexagonal-example/domain/entities"
 	"hexagonal-example/infrastructure/config"
 	"hexagonal-example/infrastructure/events"
 )
 
 func main() {
+	addr := flag.String("addr", "", "dirección en la que servir la API HTTP (por ejemplo :8080); si se omite se ejecuta la demo")
+	flag.Parse()
+
+	if *addr != "" {
+		serveHTTP(*addr)
+		return
+	}
+
 	fmt.Println("=== Ejemplo de Arquitectura Hexagonal con Patrones Repository y Factory ===")
 	fmt.Println()
 
@@ -22,46 +35,62 @@ func main() {
 	// Ejecutar ejemplos
 	runUserExamples(container)
 	runProductExamples(container)
+	runOrderExamples(container)
 	runManagementExamples(container)
 }
 
+// reservationExpiryInterval es la frecuencia con la que se liberan reservas expiradas
+const reservationExpiryInterval = 30 * time.Second
+
+// serveHTTP expone los servicios a través del adaptador HTTP
+func serveHTTP(addr string) {
+	container := config.NewContainer()
+	container.StartReservationExpiry(reservationExpiryInterval)
+	defer container.Close()
+
+	log.Printf("API HTTP escuchando en %s", addr)
+	if err := http.ListenAndServe(addr, container.GetHTTPHandler()); err != nil {
+		log.Fatal(err)
+	}
+}
+
 // setupEventHandlers configura los handlers de eventos para demostrar el sistema
+// Los handlers son tipados: el tipo de evento se deriva del struct que reciben
 func setupEventHandlers(container *config.Container) {
 	eventBus := container.GetEventBus()
 
 	// Handler para eventos de usuario
-	eventBus.Subscribe("user.created", events.EventHandlerFunc(func(ctx context.Context, event interface{}) error {
-		if userEvent, ok := event.(events.UserCreatedEvent); ok {
-			fmt.Printf("📧 Evento: Usuario creado - ID: %s, Email: %s, Nombre: %s\n", 
-				userEvent.UserID, userEvent.Email, userEvent.Name)
-		}
+	events.Subscribe(eventBus, func(ctx context.Context, userEvent events.UserCreatedEvent) error {
+		fmt.Printf("📧 Evento: Usuario creado - ID: %s, Email: %s, Nombre: %s\n",
+			userEvent.UserID, userEvent.Email, userEvent.Name)
 		return nil
-	}))
+	})
 
-	eventBus.Subscribe("user.updated", events.EventHandlerFunc(func(ctx context.Context, event interface{}) error {
-		if userEvent, ok := event.(events.UserUpdatedEvent); ok {
-			fmt.Printf("📧 Evento: Usuario actualizado - ID: %s, Email: %s, Nombre: %s\n", 
-				userEvent.UserID, userEvent.Email, userEvent.Name)
-		}
+	events.Subscribe(eventBus, func(ctx context.Context, userEvent events.UserUpdatedEvent) error {
+		fmt.Printf("📧 Evento: Usuario actualizado - ID: %s, Email: %s, Nombre: %s\n",
+			userEvent.UserID, userEvent.Email, userEvent.Name)
 		return nil
-	}))
+	})
 
 	// Handler para eventos de producto
-	eventBus.Subscribe("product.created", events.EventHandlerFunc(func(ctx context.Context, event interface{}) error {
-		if productEvent, ok := event.(events.ProductCreatedEvent); ok {
-			fmt.Printf("📦 Evento: Producto creado - ID: %s, Nombre: %s, Precio: $%.2f\n", 
-				productEvent.ProductID, productEvent.Name, productEvent.Price)
-		}
+	events.Subscribe(eventBus, func(ctx context.Context, productEvent events.ProductCreatedEvent) error {
+		fmt.Printf("📦 Evento: Producto creado - ID: %s, Nombre: %s, Precio: %s\n",
+			productEvent.ProductID, productEvent.Name, productEvent.Price)
 		return nil
-	}))
+	})
 
-	eventBus.Subscribe("product.stock.updated", events.EventHandlerFunc(func(ctx context.Context, event interface{}) error {
-		if stockEvent, ok := event.(events.StockUpdatedEvent); ok {
-			fmt.Printf("📦 Evento: Stock actualizado - Producto: %s, Stock anterior: %d, Stock nuevo: %d\n", 
-				stockEvent.Name, stockEvent.OldStock, stockEvent.NewStock)
-		}
+	events.Subscribe(eventBus, func(ctx context.Context, stockEvent events.StockUpdatedEvent) error {
+		fmt.Printf("📦 Evento: Stock actualizado - Producto: %s, Stock anterior: %d, Stock nuevo: %d\n",
+			stockEvent.Name, stockEvent.OldStock, stockEvent.NewStock)
+		return nil
+	})
+
+	// Handler para eventos de pedido
+	events.Subscribe(eventBus, func(ctx context.Context, orderEvent events.OrderCreatedEvent) error {
+		fmt.Printf("🛒 Evento: Pedido creado - ID: %s, Usuario: %s, Total: %s\n",
+			orderEvent.OrderID, orderEvent.UserID, orderEvent.Total)
 		return nil
-	}))
+	})
 }
 
 // runUserExamples demuestra el uso del servicio de usuarios
@@ -136,27 +165,27 @@ func runProductExamples(container *config.Container) {
 
 	// Crear productos
 	fmt.Println("\n1. Creando productos...")
-	product1, err := productService.CreateProduct(ctx, "prod1", "Laptop Gaming", "Laptop de alto rendimiento para gaming", "Electrónicos", 1299.99, 10)
+	product1, err := productService.CreateProduct(ctx, "prod1", "Laptop Gaming", "Laptop de alto rendimiento para gaming", "Electrónicos", entities.MustParseMoney("1299.99", "USD"), 10)
 	if err != nil {
 		log.Printf("Error creando producto 1: %v", err)
 	} else {
-		fmt.Printf("✅ Producto creado: %s - $%.2f (Stock: %d)\n", product1.Name, product1.Price, product1.Stock)
+		fmt.Printf("✅ Producto creado: %s - %s (Stock: %d)\n", product1.Name, product1.Price, product1.Stock)
 	}
 
-	product2, err := productService.CreateProduct(ctx, "prod2", "Mouse Inalámbrico", "Mouse inalámbrico ergonómico", "Accesorios", 29.99, 50)
+	product2, err := productService.CreateProduct(ctx, "prod2", "Mouse Inalámbrico", "Mouse inalámbrico ergonómico", "Accesorios", entities.MustParseMoney("29.99", "USD"), 50)
 	if err != nil {
 		log.Printf("Error creando producto 2: %v", err)
 	} else {
-		fmt.Printf("✅ Producto creado: %s - $%.2f (Stock: %d)\n", product2.Name, product2.Price, product2.Stock)
+		fmt.Printf("✅ Producto creado: %s - %s (Stock: %d)\n", product2.Name, product2.Price, product2.Stock)
 	}
 
 	// Actualizar precio
 	fmt.Println("\n2. Actualizando precio...")
-	updatedProduct, err := productService.UpdateProduct(ctx, "prod1", nil, nil, nil, float64Ptr(1199.99), nil)
+	updatedProduct, err := productService.UpdateProduct(ctx, "prod1", nil, nil, nil, moneyPtr(entities.MustParseMoney("1199.99", "USD")), nil)
 	if err != nil {
 		log.Printf("Error actualizando producto: %v", err)
 	} else {
-		fmt.Printf("✅ Precio actualizado: %s - $%.2f\n", updatedProduct.Name, updatedProduct.Price)
+		fmt.Printf("✅ Precio actualizado: %s - %s\n", updatedProduct.Name, updatedProduct.Price)
 	}
 
 	// Actualizar stock
@@ -176,7 +205,7 @@ func runProductExamples(container *config.Container) {
 	} else {
 		fmt.Printf("✅ Productos disponibles: %d\n", len(availableProducts))
 		for _, product := range availableProducts {
-			fmt.Printf("   - %s - $%.2f (Stock: %d) - Disponible: %t\n", 
+			fmt.Printf("   - %s - %s (Stock: %d) - Disponible: %t\n", 
 				product.Name, product.Price, product.Stock, product.IsAvailable())
 		}
 	}
@@ -189,11 +218,49 @@ func runProductExamples(container *config.Container) {
 	} else {
 		fmt.Printf("✅ Productos en Electrónicos: %d\n", len(electronics))
 		for _, product := range electronics {
-			fmt.Printf("   - %s - $%.2f\n", product.Name, product.Price)
+			fmt.Printf("   - %s - %s\n", product.Name, product.Price)
 		}
 	}
 }
 
+// runOrderExamples demuestra el uso del servicio de pedidos
+func runOrderExamples(container *config.Container) {
+	fmt.Println("\n=== EJEMPLOS DE PEDIDOS ===")
+
+	ctx := context.Background()
+	orderService := container.GetOrderService()
+
+	// Crear un pedido: descuenta el stock de cada línea
+	fmt.Println("\n1. Creando pedido...")
+	order, err := orderService.CreateOrder(ctx, "order1", "user1", []services.OrderLine{
+		{ProductID: "prod1", Quantity: 1},
+		{ProductID: "prod2", Quantity: 2},
+	})
+	if err != nil {
+		log.Printf("Error creando pedido: %v", err)
+	} else {
+		fmt.Printf("✅ Pedido creado: %s - %d líneas, Total: %s\n", order.ID, len(order.Items), order.Total())
+	}
+
+	// Un pedido que excede el stock no descuenta nada
+	fmt.Println("\n2. Creando pedido sin stock suficiente...")
+	if _, err := orderService.CreateOrder(ctx, "order2", "user1", []services.OrderLine{
+		{ProductID: "prod2", Quantity: 1},
+		{ProductID: "prod1", Quantity: 1000},
+	}); err != nil {
+		fmt.Printf("✅ Pedido rechazado: %v\n", err)
+	}
+
+	// Cancelar el pedido devuelve el stock
+	fmt.Println("\n3. Cancelando pedido...")
+	cancelled, err := orderService.CancelOrder(ctx, "order1")
+	if err != nil {
+		log.Printf("Error cancelando pedido: %v", err)
+	} else {
+		fmt.Printf("✅ Pedido %s: %s\n", cancelled.ID, cancelled.Status)
+	}
+}
+
 // runManagementExamples demuestra el uso de los servicios de gestión
 func runManagementExamples(container *config.Container) {
 	fmt.Println("\n=== EJEMPLOS DE SERVICIOS DE GESTIÓN ===")
@@ -265,8 +332,8 @@ func stringPtr(s string) *string {
 	return &s
 }
 
-func float64Ptr(f float64) *float64 {
-	return &f
+func moneyPtr(m entities.Money) *entities.Money {
+	return &m
 }
 
 func intPtr(i int) *int {